@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Agency of a rail line, read from the agencies CSV.
+type agencyRow struct {
+	line    string // Name of the rail line the agency runs
+	agency  string
+	contact string // Contact details of the agency, if any
+	row     int    // Line of the agencies CSV the agency was read from
+}
+
+// Agency running rail lines, emitted to the Agencies table.
+type agency struct {
+	name    string
+	contact string // Contact details, emitted as an AgencyAttributes row if any
+}
+
+// Parse the agencies CSV, with the name of a rail line, the agency running it,
+// and optionally a contact for the agency on each row.
+func (c *conversion) parseAgencies(reader *csv.Reader) ([]agencyRow, error) {
+	header, err := reader.Read()
+	if nil != err {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+	if 2 != len(header) && 3 != len(header) {
+		return nil, fmt.Errorf("Expected a header row of line,agency,contact, found %d columns", len(header))
+	}
+
+	var rows []agencyRow
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read record for agency %d: %w", len(rows)+1, err)
+		}
+		if err := c.nul.apply(reader, record); nil != err {
+			return nil, err
+		}
+
+		row, _ := reader.FieldPos(0)
+		current := agencyRow{line: strings.TrimSpace(record[0]), agency: strings.TrimSpace(record[1]), row: row}
+		if 3 == len(record) {
+			current.contact = strings.TrimSpace(record[2])
+		}
+		if 0 >= len(current.agency) {
+			return nil, fmt.Errorf("Missing agency in row %d", row)
+		}
+		rows = append(rows, current)
+	}
+	return rows, nil
+}
+
+// Give each rail line its agency from the agencies CSV, adding the prefix given
+// to the rail line names to the names in the CSV, and return the contact of
+// each agency, which is the first one given for it. A row for a rail line
+// that is not in the lines CSV is an error, as it usually means that the rail
+// line was renamed, and so is a rail line given two agencies.
+func applyAgencies(lines []railLine, rows []agencyRow, prefix string) (map[string]string, error) {
+	contacts := make(map[string]string)
+	given := make(map[string]int, len(rows))
+	for _, current := range rows {
+		index := slices.IndexFunc(lines, func(line railLine) bool { return prefix+current.line == line.name })
+		if 0 > index {
+			return nil, fmt.Errorf("Unknown rail line %s for agency in row %d, it may have been renamed", current.line, current.row)
+		}
+		if row, found := given[current.line]; found && lines[index].agency != current.agency {
+			return nil, fmt.Errorf("Rail line %s has the agency %s in row %d and %s in row %d", current.line, lines[index].agency, row, current.agency, current.row)
+		}
+		given[current.line] = current.row
+		lines[index].agency = current.agency
+		if "" == contacts[current.agency] {
+			contacts[current.agency] = current.contact
+		}
+	}
+	return contacts, nil
+}
+
+// List the distinct agencies of the rail lines in the order they are first seen,
+// along with the findings for the rail lines without one. The ID of each
+// agency is its position in the list plus one.
+func collectAgencies(lines []railLine, contacts map[string]string) ([]agency, []string) {
+	var agencies []agency
+	var findings []string
+	for _, line := range lines {
+		if "" == line.agency {
+			findings = append(findings, fmt.Sprintf("Rail line %s in row %d has no agency in the agencies CSV", line.name, line.row))
+		} else if !slices.ContainsFunc(agencies, func(a agency) bool { return line.agency == a.name }) {
+			agencies = append(agencies, agency{line.agency, contacts[line.agency]})
+		}
+	}
+	return agencies, findings
+}
+
+// Column of the 'RailLines' table with the Agencies row of the agency running
+// the rail line.
+var agencyIdColumn = columnDefinition{"agency_id", "INTEGER", ""}
+
+// Fill the agency_id column of every rail line with the ID of its agency.
+func assignAgencyFields(lines []railLine, agencies []agency) {
+	for i := range lines {
+		literal := "NULL"
+		if agencyId := slices.IndexFunc(agencies, func(a agency) bool { return lines[i].agency == a.name }); 0 <= agencyId {
+			literal = strconv.Itoa(agencyId + 1)
+		}
+		lines[i].fields = append(lines[i].fields, field{agencyIdColumn.name, literal})
+	}
+}
+
+// Generate the SQL statements for populating the 'Agencies' table and the
+// 'AgencyAttributes' table with their contacts.
+func (e *emitter) agencyStatements(agencies []agency, writer io.Writer) error {
+	for i, current := range agencies {
+		if err := e.writeInsert(writer, "Agencies", []string{"id", "name"}, []string{strconv.Itoa(i + 1), e.quoteSqlString(current.name)}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write agency insert statement: %w", err)
+		}
+	}
+	for i, current := range agencies {
+		if "" == current.contact {
+			continue
+		}
+		if err := e.writeInsert(writer, "AgencyAttributes", []string{"agency_id", "name", "value"},
+			[]string{strconv.Itoa(i + 1), e.quoteSqlString("contact"), e.quoteSqlString(current.contact)}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write agency attribute insert statement: %w", err)
+		}
+	}
+	return nil
+}
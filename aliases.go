@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Other name of a station, read from the aliases CSV.
+type alias struct {
+	station string // Name of the station the alias is for
+	alias   string
+	row     int // Line of the aliases CSV the alias was read from
+}
+
+// Parse the aliases CSV, with the name of a station and one of its aliases on
+// each row.
+func (c *conversion) parseAliases(reader *csv.Reader) ([]alias, error) {
+	reader.FieldsPerRecord = 2
+	if _, err := reader.Read(); nil != err {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+
+	var aliases []alias
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read record for alias %d: %w", len(aliases)+1, err)
+		}
+		if err := c.nul.apply(reader, record); nil != err {
+			return nil, err
+		}
+
+		row, _ := reader.FieldPos(0)
+		current := alias{station: strings.TrimSpace(record[0]), alias: strings.TrimSpace(record[1]), row: row}
+		if 0 >= len(current.alias) {
+			return nil, fmt.Errorf("Missing alias in row %d", row)
+		}
+		aliases = append(aliases, current)
+	}
+	return aliases, nil
+}
+
+// Give each station the aliases from the aliases CSV, adding the prefix given
+// to the station names to the names in the CSV. An alias the stations CSV gave
+// to another station is taken away from it.
+func (c *conversion) applyAliases(stations []station, aliases []alias, prefix string) error {
+	indices := make(map[string]int, len(stations))
+	for i, current := range stations {
+		indices[current.name] = i
+	}
+
+	for _, current := range aliases {
+		index, found := indices[prefix+current.station]
+		if !found {
+			if err := c.forgive("unknown-stations", fmt.Errorf("Unknown station %s for alias in row %d", current.station, current.row)); nil != err {
+				return err
+			}
+			continue
+		}
+		for i := range stations {
+			if i != index {
+				stations[i].aliases = slices.DeleteFunc(stations[i].aliases, func(a string) bool { return current.alias == a })
+			}
+		}
+		if !slices.Contains(stations[index].aliases, current.alias) {
+			stations[index].aliases = append(stations[index].aliases, current.alias)
+		}
+	}
+	return nil
+}
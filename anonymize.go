@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Real name of a rail line, station, or alarm zone and the label replacing it.
+type anonymizedName struct {
+	kind, name, label string
+}
+
+// Replace every rail line, station, and alarm zone name with a label that only
+// depends on its ID, like "Line A", "Station 001", and "Zone 1", leaving the IDs
+// and the rail lines each station is on untouched, and dropping the aliases and
+// translated names of the stations. The attributes and the
+// additional 'Stations' columns read from the stations CSV are dropped, or with
+// hash set the attributes and the text columns have their values replaced by a
+// hash, keeping the other columns dropped. Returns the names replaced.
+func (c *conversion) anonymize(lines []railLine, stations []station, hash bool) []anonymizedName {
+	var names []anonymizedName
+	for i := range lines {
+		label := "Line " + lineLabel(i+1)
+		names = append(names, anonymizedName{"line", lines[i].name, label})
+		lines[i].name = label
+	}
+
+	width := 3
+	for _, current := range stations {
+		width = max(width, len(strconv.Itoa(current.id)))
+	}
+	for i := range stations {
+		label := fmt.Sprintf("Station %0*d", width, stations[i].id)
+		names = append(names, anonymizedName{"station", stations[i].name, label})
+		stations[i].name = label
+		stations[i].aliases, stations[i].names = nil, nil
+	}
+
+	zones := collectZones(stations)
+	for i, zone := range zones {
+		names = append(names, anonymizedName{"zone", zone, "Zone " + strconv.Itoa(i+1)})
+	}
+	for i := range stations {
+		if zoneId := slices.Index(zones, stations[i].zone); 0 <= zoneId {
+			stations[i].zone = "Zone " + strconv.Itoa(zoneId+1)
+		}
+	}
+
+	for i := range stations {
+		current := &stations[i]
+		if !hash {
+			current.attributes = nil
+			current.fields = slices.DeleteFunc(current.fields, isStationField)
+			continue
+		}
+		for j := range current.attributes {
+			current.attributes[j].value = hashValue(current.attributes[j].value)
+		}
+		current.fields = slices.DeleteFunc(current.fields, func(f field) bool { return isStationField(f) && !isTextField(f) })
+		for j := range current.fields {
+			if isTextField(current.fields[j]) && "NULL" != current.fields[j].literal {
+				current.fields[j].literal = c.quoteSqlString(hashValue(current.fields[j].literal))
+			}
+		}
+	}
+	return names
+}
+
+// Spell out a rail line ID in letters like a spreadsheet column: A to Z, then
+// AA, AB, and so on.
+func lineLabel(lineId int) string {
+	var label []byte
+	for ; 0 < lineId; lineId = (lineId - 1) / 26 {
+		label = append([]byte{byte('A' + (lineId-1)%26)}, label...)
+	}
+	return string(label)
+}
+
+// Whether the field was read from one of the [stationFields] columns of the
+// stations CSV, as opposed to being added by an option.
+func isStationField(f field) bool {
+	return slices.ContainsFunc(stationFields, func(column fieldColumn) bool { return column.column == f.column })
+}
+
+// Whether the field was read from one of the [stationFields] columns holding
+// text, as opposed to a number or a boolean.
+func isTextField(f field) bool {
+	return slices.ContainsFunc(stationFields, func(column fieldColumn) bool {
+		return column.column == f.column && (strings.HasPrefix(column.definition, "VARCHAR") || "TEXT" == column.definition)
+	})
+}
+
+// Replace a value by the start of its SHA-256 hash, so that equal values stay
+// equal without revealing what they are.
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:6])
+}
+
+// Write the real names and their labels to a CSV file at path.
+func writeAnonymizeMap(path string, names []anonymizedName, logger *log.Logger) error {
+	records := [][]string{{"Kind", "Name", "Anonymous"}}
+	for _, name := range names {
+		records = append(records, []string{name.kind, name.name, name.label})
+	}
+	return writeCsvFile(path, records, logger)
+}
+
+// Write the records to a new CSV file at path, logging a failure to close it
+// to logger.
+func writeCsvFile(path string, records [][]string, logger *log.Logger) error {
+	file, err := os.Create(path)
+	if nil != err {
+		return fmt.Errorf("Failed to create %s: %w", path, err)
+	}
+	defer func(file *os.File, path string) {
+		if err := file.Close(); nil != err {
+			logger.Printf("Failed to close %s: %v\n", path, err)
+		}
+	}(file, path)
+
+	writer := csv.NewWriter(file)
+	writer.WriteAll(records)
+	if err := writer.Error(); nil != err {
+		return fmt.Errorf("Failed to write %s: %w", path, err)
+	}
+	return nil
+}
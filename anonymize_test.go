@@ -0,0 +1,25 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnonymizeHashKeepsTypes(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv": testLines,
+		"stations.csv": "Station,Ruby,Emerald,exits,accessible,latitude,status,@district\n" +
+			"Foo,true,false,2,true,38.9,open,North\n" +
+			"Bar,true,true,,false,,closed,North\n",
+	})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-stations-format", "matrix", "-anonymize", "-anonymize-columns", "hash", "-self-test")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, dropped := range []string{"exit_count", "accessible", "latitude", "'open'", "North"} {
+		if strings.Contains(stdout, dropped) {
+			t.Errorf("Anonymized output still has %s:\n%s", dropped, stdout)
+		}
+	}
+	checkGolden(t, "anonymize-hash.sql", stdout)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Write the statements checking that every table the rows were planned for has
+// as many rows, or at least as many when appending to tables that may already
+// have others, with [conversion.guardStatement]. The other dialects have no way
+// to fail a script, so they get SELECTs of the counts after comments giving the
+// expected numbers.
+func (c *conversion) countAssertions(writer io.Writer, planned tableRows, appending bool) error {
+	comparison, expected := "<>", "Expected %d rows in %s"
+	if appending {
+		comparison, expected = "<", "Expected at least %d rows in %s"
+	}
+	statements := []string{"-- Row counts the statements were expected to load"}
+	for _, key := range slices.Sorted(maps.Keys(planned)) {
+		table := key
+		if index := slices.IndexFunc(loadedTables, func(name string) bool { return strings.EqualFold(key, name) }); 0 <= index {
+			table = loadedTables[index]
+		}
+		count := len(planned[key])
+		message := fmt.Sprintf(expected, count, table)
+		if guard, found := c.guardStatement(fmt.Sprintf("(SELECT COUNT(*) FROM %s) %s %d", table, comparison, count), message); found {
+			statements = append(statements, guard)
+		} else {
+			statements = append(statements, "-- "+message, fmt.Sprintf("SELECT COUNT(*) FROM %s;", table))
+		}
+	}
+	return writeStatements(writer, statements)
+}
+
+// Build the statement stopping the script with the message when the SQL
+// condition holds, in the dialects that can: SQLite fails on a JSON path made of
+// the message, as RAISE is only allowed in triggers, and PostgreSQL raises an
+// exception from a DO block. Reports false for the other dialects.
+func (c *conversion) guardStatement(condition string, message string) (string, bool) {
+	switch c.dialect {
+	case "sqlite":
+		return fmt.Sprintf("SELECT CASE WHEN %s THEN json_extract('{}', %s) END;", condition, QuoteLiteral(message, Dialect(c.dialect))), true
+	case "postgres":
+		return fmt.Sprintf("DO $$ BEGIN IF %s THEN RAISE EXCEPTION %s; END IF; END $$;", condition, QuoteLiteral(message, Dialect(c.dialect))), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// Stations CSV with quotes and Unicode in the keys and values of the attributes.
+const attributesStations = "Station,Ruby,Emerald,@O'Brien's note,@Größe,\"@\"\"quoted\"\" key\"\n" +
+	"Foo,true,false,It's here,groß,\"say \"\"hi\"\"\"\n" +
+	"Bar,true,true,,日本語 ✓,\n"
+
+func TestAttributesGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": attributesStations})
+	for _, dialect := range []string{"standard", "mysql"} {
+		t.Run(dialect, func(t *testing.T) {
+			stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-dialect", dialect)
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, "attributes/"+dialect+".sql", stdout)
+		})
+	}
+}
+
+// The attributes come back out of a database the same as they went in.
+func TestAttributesRoundTrip(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": attributesStations})
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-sqlite-out", "network.db"); nil != err {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", "network.db")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	want := map[[2]string]string{
+		{"1", "O'Brien's note"}: "It's here",
+		{"1", "Größe"}:          "groß",
+		{"1", `"quoted" key`}:   `say "hi"`,
+		{"2", "Größe"}:          "日本語 ✓",
+	}
+	rows, err := db.Query("SELECT station_id, name, value FROM StationAttributes")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	got := make(map[[2]string]string)
+	for rows.Next() {
+		var stationId, name, value string
+		if err := rows.Scan(&stationId, &name, &value); nil != err {
+			t.Fatal(err)
+		}
+		got[[2]string{stationId, name}] = value
+	}
+	if len(want) != len(got) {
+		t.Errorf("Expected %d attributes, got %v", len(want), got)
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("Attribute %v is %q, want %q", key, got[key], value)
+		}
+	}
+}
+
+// Columns prefixed with '!' are left out whatever their values, without taking
+// a rail line number.
+func TestIgnoredColumnsGolden(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,!Silver,Ruby,! notes,Emerald,!exits\nFoo,maybe,true,\"It's, here\",false,-1\nBar's,,true,,true,x\n",
+	})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "basic.sql", stdout)
+}
+
+// A '!' wins over flags, fixed column names and '@', which only makes an
+// attribute of a column no flag or fixed name claims.
+func TestColumnPrefixes(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		zoneColumn string
+		want       stationColumn
+	}{
+		{"ignored line", "!Ruby", "", stationColumn{ignored: true}},
+		{"ignored zone", "!zone", "!zone", stationColumn{ignored: true}},
+		{"ignored attribute", "!@district", "", stationColumn{ignored: true}},
+		{"ignored fixed name", "!aliases", "", stationColumn{ignored: true}},
+		{"ignored field", "!exits", "", stationColumn{ignored: true}},
+		{"zone flag over attribute", "@zone", "@zone", stationColumn{zone: true}},
+		{"attribute", "@district", "", stationColumn{attribute: "district"}},
+		{"attribute of a fixed name", "@aliases", "", stationColumn{attribute: "aliases"}},
+		{"attribute of a field", "@exits", "", stationColumn{attribute: "exits"}},
+		{"attribute of a translation", "@name:es", "", stationColumn{attribute: "name:es"}},
+		{"attribute key trimmed", "@ district ", "", stationColumn{attribute: "district"}},
+		{"fixed name", "Aliases", "", stationColumn{aliases: true}},
+		{"line", "Emerald", "", stationColumn{lineId: 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			columns, err := parseStationColumns([]string{"Station", "Ruby", test.header, "Emerald"}, stationOptions{zoneColumn: test.zoneColumn})
+			if nil != err {
+				t.Fatal(err)
+			}
+			if got := columns[1]; test.want != got {
+				t.Errorf("Column %q is %+v, want %+v", test.header, got, test.want)
+			}
+			last := 2
+			if 0 < test.want.lineId {
+				last = 3
+			}
+			if last != columns[2].lineId {
+				t.Errorf("Expected the rail line after %q to be numbered %d, got %d", test.header, last, columns[2].lineId)
+			}
+		})
+	}
+	if _, err := parseStationColumns([]string{"Station", "Ruby", "@ "}, stationOptions{}); nil == err || "Missing attribute name in column 3" != err.Error() {
+		t.Errorf("Expected the missing attribute name, got %v", err)
+	}
+}
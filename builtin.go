@@ -0,0 +1,63 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+)
+
+// Example networks shipped inside the binary, one directory each holding a
+// lines.csv and a stations.csv.
+//
+//go:embed wmata/lines.csv wmata/stations.csv
+var builtinFiles embed.FS
+
+// Prefix of the paths of files read from builtinFiles rather than the disk.
+const builtinPrefix = "builtin:"
+
+// A network that can be converted with -builtin.
+type builtinDataset struct {
+	name        string // Directory of the CSV files in builtinFiles
+	description string
+}
+
+var builtinDatasets = []builtinDataset{
+	{"wmata", "Washington Metropolitan Area Transit Authority Metrorail"},
+}
+
+// Whether path names a file in builtinFiles.
+func isBuiltin(path string) bool {
+	return strings.HasPrefix(path, builtinPrefix)
+}
+
+// builtinPaths returns the paths of the rail lines and stations CSV files of the
+// named dataset, for reading with parseCsvFile.
+func builtinPaths(name string) (linesPath, stationsPath string, err error) {
+	for _, dataset := range builtinDatasets {
+		if dataset.name == name {
+			return builtinPrefix + name + "/lines.csv", builtinPrefix + name + "/stations.csv", nil
+		}
+	}
+	return "", "", fmt.Errorf("Unknown built-in dataset %s, see -list-builtins", name)
+}
+
+// openBuiltin opens a file in builtinFiles by its path with the prefix.
+func openBuiltin(path string) (fs.File, error) {
+	file, err := builtinFiles.Open(strings.TrimPrefix(path, builtinPrefix))
+	if nil != err {
+		return nil, fmt.Errorf("Failed to open %s: %w", path, err)
+	}
+	return file, nil
+}
+
+// writeBuiltins lists the datasets for -list-builtins, one per line.
+func writeBuiltins(writer io.Writer) error {
+	for _, dataset := range builtinDatasets {
+		if _, err := fmt.Fprintf(writer, "%s\t%s\n", dataset.name, dataset.description); nil != err {
+			return err
+		}
+	}
+	return nil
+}
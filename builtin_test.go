@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// The embedded copy of WMATA converts to the golden output, the same as the
+// files it was embedded from.
+func TestBuiltinWmata(t *testing.T) {
+	stdout, _, err := runArgs(t, "-builtin", "wmata")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "wmata.sql", stdout)
+
+	fromFiles, _, err := runArgs(t, "-lines", "wmata/lines.csv", "-stations", "wmata/stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if fromFiles != stdout {
+		t.Error("The built-in dataset converts differently from the files in wmata")
+	}
+}
+
+func TestBuiltinUnknown(t *testing.T) {
+	if _, _, err := runArgs(t, "-builtin", "bart"); nil == err || !strings.Contains(err.Error(), "Unknown built-in dataset bart") {
+		t.Errorf("Expected an unknown dataset error, got %v", err)
+	}
+	stdout, _, err := runArgs(t, "-list-builtins")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(stdout, "wmata\t") {
+		t.Errorf("Expected wmata to be listed, got %q", stdout)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Tables the statements can insert into, by their names as written in the
+// statements.
+var loadedTables = []string{"Networks", "Modes", "Agencies", "AgencyAttributes", "RailLines", "Stations", "LineStations", "StationAttributes", "StationAliases",
+	"StationNames", "Complexes", "AlarmZones", "StationZones", "Panels", "Devices", "Entrances", "Connections"}
+
+// Write the statements turning off the checks that slow down a bulk load in the
+// dialect of the conversion, before any data. Tables are only locked in MySQL
+// without transactions, since starting a transaction releases the locks, and
+// only those the rows were planned for, since MySQL refuses to lock a table
+// that is not in the database.
+func (c *conversion) bulkLoadPrologue(writer io.Writer, noTransaction bool, planned tableRows) error {
+	statements := []string{"-- Bulk load: unsafe while anyone else uses the database"}
+	switch c.dialect {
+	case "mysql":
+		statements = append(statements, "SET unique_checks = 0;", "SET foreign_key_checks = 0;")
+		if noTransaction {
+			var locked []string
+			for _, table := range loadedTables {
+				if 0 < len(planned[strings.ToLower(table)]) {
+					locked = append(locked, table+" WRITE")
+				}
+			}
+			statements = append(statements, "LOCK TABLES "+strings.Join(locked, ", ")+";")
+		}
+	case "sqlite":
+		statements = append(statements, "PRAGMA synchronous = OFF;")
+	default:
+		return fmt.Errorf("Bulk loading has no equivalent in the %s dialect", c.dialect)
+	}
+	return writeStatements(writer, statements)
+}
+
+// Write the statements turning the checks turned off by
+// [conversion.bulkLoadPrologue] back on, after all of the data.
+func (c *conversion) bulkLoadEpilogue(writer io.Writer, noTransaction bool) error {
+	statements := []string{"-- End of bulk load"}
+	switch c.dialect {
+	case "mysql":
+		if noTransaction {
+			statements = append(statements, "UNLOCK TABLES;")
+		}
+		statements = append(statements, "SET foreign_key_checks = 1;", "SET unique_checks = 1;")
+	case "sqlite":
+		statements = append(statements, "PRAGMA synchronous = FULL;")
+	default:
+		return fmt.Errorf("Bulk loading has no equivalent in the %s dialect", c.dialect)
+	}
+	return writeStatements(writer, statements)
+}
+
+// Write each statement on a line of its own, one write per statement.
+func writeStatements(writer io.Writer, statements []string) error {
+	for _, statement := range statements {
+		if _, err := fmt.Fprintln(writer, statement); nil != err {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// Every table of the schema that the conversion inserts into can be locked,
+// which is all of them but the users.
+func TestLoadedTablesCoverSchema(t *testing.T) {
+	for _, table := range schemaTables(128) {
+		if "Users" != table.name && "UserStations" != table.name && !slices.Contains(loadedTables, table.name) {
+			t.Errorf("Table %s is not locked while bulk loading", table.name)
+		}
+	}
+}
+
+func TestBulkLoadLocksTables(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":     testLines,
+		"stations.csv":  "Station,Ruby,Emerald\nFoo,true,false\n",
+		"agencies.csv":  "line,agency,contact\nRuby,Ruby Transit,ops@example.com\n",
+		"complexes.csv": "Station,Ruby,Emerald,complex\nFoo,true,false,Center\nBar,false,true,Center\n",
+	})
+	tests := []struct {
+		args  []string
+		table string // Table that must be locked
+	}{
+		{[]string{"-mode", "rail"}, "Modes"},
+		{[]string{"-agencies", "agencies.csv"}, "Agencies"},
+		{[]string{"-agencies", "agencies.csv"}, "AgencyAttributes"},
+		{[]string{"-stations", "complexes.csv"}, "Complexes"},
+	}
+	for _, test := range tests {
+		stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-dialect", "mysql", "-bulk-load", "-no-transaction"}, test.args...)...)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if !strings.Contains(stdout, "INSERT INTO "+test.table+" ") {
+			t.Errorf("Nothing is inserted into %s with %v", test.table, test.args)
+		}
+		if !strings.Contains(stdout, " "+test.table+" WRITE") {
+			t.Errorf("Table %s is not locked with %v:\n%s", test.table, test.args, stdout)
+		}
+	}
+}
+
+// Without optional tables the script locks only the tables of setup.sql it
+// inserts into, as MySQL refuses to lock a table that is not there.
+func TestBulkLoadLocksSetupTables(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": basicStations})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-dialect", "mysql", "-bulk-load", "-no-transaction")
+	if nil != err {
+		t.Fatal(err)
+	}
+	var locked, inserted []string
+	for _, line := range strings.Split(stdout, "\n") {
+		if list, found := strings.CutPrefix(line, "LOCK TABLES "); found {
+			for _, entry := range strings.Split(strings.TrimSuffix(list, ";"), ", ") {
+				locked = append(locked, strings.TrimSuffix(entry, " WRITE"))
+			}
+		} else if rest, found := strings.CutPrefix(line, "INSERT INTO "); found {
+			if table, _, _ := strings.Cut(rest, " "); !slices.Contains(inserted, table) {
+				inserted = append(inserted, table)
+			}
+		}
+	}
+	if !slices.Equal([]string{"RailLines", "Stations", "LineStations"}, locked) {
+		t.Errorf("Expected only the tables inserted into to be locked, got %v:\n%s", locked, stdout)
+	}
+	for _, table := range locked {
+		if !strings.Contains(setupSql, "CREATE TABLE IF NOT EXISTS "+table+" (") {
+			t.Errorf("Locked table %s is not in setup.sql", table)
+		}
+		if !slices.Contains(inserted, table) {
+			t.Errorf("Locked table %s is not inserted into", table)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Whether rows referencing rail lines and stations find them by name, as their
+// IDs are either assigned by the database or not known to be free.
+func (c *conversion) referencesByName() bool {
+	return c.linkByName || c.dbIds
+}
+
+// Write an insert into the table of the values selected from the FROM clause,
+// if any, where the conditions hold, leaving out the row when the table already
+// has one with the same values in the key columns, so that running it again
+// changes nothing. Additional field columns are added as by
+// [emitter.writeInsert].
+func (e *emitter) writeInsertMissing(writer io.Writer, table string, columns []string, values []string, from string, conditions []string, keys []string, fieldColumns []string, fields []field) error {
+	existing := make([]string, len(keys))
+	for i, key := range keys {
+		existing[i] = key + " = " + values[slices.Index(columns, key)]
+	}
+	columns, values = appendFields(columns, values, fieldColumns, fields)
+	if "" == from && "mysql" == e.dialect {
+		from = "DUAL"
+	}
+	if "" != from {
+		from = " FROM " + from
+	}
+	conditions = append(slices.Clip(conditions), fmt.Sprintf("NOT EXISTS (SELECT 1 FROM %s WHERE %s)", table, strings.Join(existing, " AND ")))
+	_, err := fmt.Fprintf(writer, "INSERT INTO %s (%s) SELECT %s%s WHERE %s;\n", table, strings.Join(columns, ", "),
+		strings.Join(values, ", "), from, strings.Join(conditions, " AND "))
+	return err
+}
+
+// Write the insert of a rail line or station with the name into the table. With
+// -link-by-name it is left out when one with the name is already there, and
+// unless -db-ids is given a new row gets the ID after the largest in the table.
+func (e *emitter) writeNamedInsert(writer io.Writer, table string, name string, columns []string, values []string, fieldColumns []string, fields []field) error {
+	if e.dbIds {
+		columns, values = append([]string{"name"}, columns...), append([]string{e.quoteSqlString(name)}, values...)
+		if e.linkByName {
+			return e.writeInsertMissing(writer, table, columns, values, "", nil, []string{"name"}, fieldColumns, fields)
+		}
+		columns, values = appendFields(columns, values, fieldColumns, fields)
+		e.inserting(table, columns, values)
+		_, err := fmt.Fprintf(writer, "INSERT INTO %s (%s) VALUES (%s);\n", table, strings.Join(columns, ", "), strings.Join(values, ", "))
+		return err
+	}
+	return e.writeInsertMissing(writer, table, append([]string{"id", "name"}, columns...), append([]string{"free.id", e.quoteSqlString(name)}, values...),
+		fmt.Sprintf("(SELECT COALESCE(MAX(id), 0) + 1 AS id FROM %s) AS free", table), nil, []string{"name"}, fieldColumns, fields)
+}
+
+// Write the insert of a row referencing the station with the name, unless the
+// row is already there. The values use s.id for the ID of the station.
+func (e *emitter) writeStationRowByName(writer io.Writer, table string, stationName string, columns []string, values []string, keys []string) error {
+	return e.writeInsertMissing(writer, table, columns, values, "Stations AS s",
+		[]string{"s.name = " + e.quoteSqlString(stationName)}, keys, nil, nil)
+}
+
+// Write the insert of the link between the rail line and the station with the
+// names, unless they are already linked.
+func (e *emitter) writeLinkByName(writer io.Writer, lineName string, stationName string, linkColumns []string, linkFields []field) error {
+	return e.writeInsertMissing(writer, "LineStations", []string{"line_id", "station_id"}, []string{"l.id", "s.id"}, "RailLines AS l, Stations AS s",
+		[]string{"l.name = " + e.quoteSqlString(lineName), "s.name = " + e.quoteSqlString(stationName)}, []string{"line_id", "station_id"}, linkColumns, linkFields)
+}
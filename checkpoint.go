@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+)
+
+// Version of the checkpoint format, raised whenever its meaning changes so that
+// older checkpoints are refused instead of resumed wrongly.
+const checkpointVersion = 2
+
+// Number of bytes at the start of every input file hashed to tell whether it
+// changed since the checkpoint.
+const fingerprintBytes = 1 << 20
+
+// How far the emission of the stations has got, recorded in checkpoints. The
+// offsets are of the stations CSV as it is read, after any decompression and
+// decoding.
+type emissionProgress struct {
+	HeaderOffset  int64       `json:"header_offset"`   // Byte offset after the header
+	InputOffset   int64       `json:"input_offset"`    // Byte offset after the record of the last station written
+	Row           int         `json:"row"`             // Line the last station written was read from
+	NextStationId int         `json:"next_station_id"` // ID after that of the last station written
+	LineLinks     map[int]int `json:"line_links"`      // Number of links inserted by rail line ID
+
+	current int // ID of the station being written, or 0 before the first
+}
+
+// Record that the statements for the station are being written.
+func (p *emissionProgress) begin(stationId int) {
+	p.current = stationId
+}
+
+// Record that every statement for the station was written.
+func (p *emissionProgress) station(current station) {
+	p.InputOffset, p.Row, p.NextStationId = current.offset, current.row, current.id+1
+}
+
+// Record that the link of a station to the rail line was written.
+func (p *emissionProgress) link(lineId int) {
+	if nil == p.LineLinks {
+		p.LineLinks = make(map[int]int)
+	}
+	p.LineLinks[lineId]++
+}
+
+// Size and hash of the start of an input file.
+type inputFingerprint struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Prefix string `json:"prefix_sha256"`
+}
+
+// State file of -checkpoint, from which -resume continues.
+type checkpointState struct {
+	Version     int                `json:"version"`
+	Flags       string             `json:"flags"` // Hash of the flags the conversion was started with
+	Inputs      []inputFingerprint `json:"inputs"`
+	Statements  int                `json:"statements"`   // Number of statements in the output
+	OutputBytes int64              `json:"output_bytes"` // Size of the output after them
+	Preamble    int                `json:"preamble"`     // Number of statements before those of the first station, or -1 before it
+	Progress    emissionProgress   `json:"progress"`
+}
+
+// Fingerprint the input file, which must be a regular file.
+func fingerprintInput(path string) (inputFingerprint, error) {
+	file, err := os.Open(path)
+	if nil != err {
+		return inputFingerprint{}, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if nil != err {
+		return inputFingerprint{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return inputFingerprint{}, fmt.Errorf("%s is not a regular file", path)
+	}
+	hash := sha256.New()
+	if _, err := io.CopyN(hash, file, fingerprintBytes); nil != err && !errors.Is(err, io.EOF) {
+		return inputFingerprint{}, err
+	}
+	return inputFingerprint{path, info.Size(), hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+// Hash every flag that was set apart from -resume, so that a conversion is only
+// resumed with the options it was started with.
+func hashFlags(flags *flag.FlagSet) string {
+	hash := sha256.New()
+	flags.Visit(func(f *flag.Flag) {
+		if "resume" != f.Name {
+			fmt.Fprintf(hash, "%s=%q\n", f.Name, f.Value.String())
+		}
+	})
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Read the checkpoint and check that it is for the same inputs and flags.
+func readCheckpoint(path string, inputs []inputFingerprint, flagsHash string) (checkpointState, error) {
+	text, err := os.ReadFile(path)
+	if nil != err {
+		return checkpointState{}, fmt.Errorf("Failed to read checkpoint %s: %w", path, err)
+	}
+	var state checkpointState
+	if err := json.Unmarshal(text, &state); nil != err {
+		return checkpointState{}, fmt.Errorf("Failed to parse checkpoint %s: %w", path, err)
+	}
+	if checkpointVersion != state.Version {
+		return checkpointState{}, fmt.Errorf("Checkpoint %s has version %d but only version %d can be resumed, start the conversion again", path, state.Version, checkpointVersion)
+	}
+	if flagsHash != state.Flags {
+		return checkpointState{}, fmt.Errorf("Checkpoint %s was made with different flags", path)
+	}
+	if len(inputs) != len(state.Inputs) {
+		return checkpointState{}, fmt.Errorf("Checkpoint %s was made with different input files", path)
+	}
+	for i, input := range inputs {
+		if input != state.Inputs[i] {
+			return checkpointState{}, fmt.Errorf("Input %s changed since checkpoint %s", input.Path, path)
+		}
+	}
+	return state, nil
+}
+
+// Writer between the [emitter] and the output file that saves a checkpoint once
+// at least interval statements were written since the last, when it gets to the
+// statements of the next station or is still before the first. When resuming
+// from a checkpoint after some stations, those stations are not parsed again, so
+// only the statements before the first station are discarded, having been
+// generated again. Every write is expected to be exactly one statement.
+type checkpointer struct {
+	path     string
+	interval int
+	state    checkpointState
+	saved    int               // Number of statements in the output at the last checkpoint
+	station  int               // ID of the station the last statement was for, or 0 before the first
+	skip     int               // Number of statements still to discard
+	verify   bool              // Whether the progress is still to be checked once they are discarded
+	progress *emissionProgress // Progress of the conversion, as its statements are written
+	writer   io.Writer         // Where the statements are written on their way to the file
+	flush    func() error      // Flushes the statements written to writer to the file
+	file     *os.File
+}
+
+// Create a checkpointer for the output file, which when resuming is cut back to
+// the length it had at the checkpoint, recording the progress of the
+// conversion. Its writer and flush are set by [conversion.newEmitter].
+func newCheckpointer(path string, interval int, file *os.File, state checkpointState, resume bool, progress *emissionProgress) (*checkpointer, error) {
+	c := &checkpointer{path: path, interval: interval, state: state, file: file, progress: progress}
+	if !resume {
+		// A checkpoint left by another conversion must not be resumed into this output
+		c.state.Statements, c.state.OutputBytes, c.state.Preamble, c.state.Progress = 0, 0, -1, emissionProgress{}
+		return c, c.finish()
+	}
+	if info, err := file.Stat(); nil != err {
+		return nil, fmt.Errorf("Failed to read the length of the output: %w", err)
+	} else if info.Size() < state.OutputBytes {
+		return nil, fmt.Errorf("Output is %d bytes but was %d at the checkpoint", info.Size(), state.OutputBytes)
+	}
+	if err := file.Truncate(state.OutputBytes); nil != err {
+		return nil, fmt.Errorf("Failed to cut the output back to the checkpoint: %w", err)
+	}
+	if _, err := file.Seek(state.OutputBytes, io.SeekStart); nil != err {
+		return nil, fmt.Errorf("Failed to seek the output to the checkpoint: %w", err)
+	}
+	c.saved, c.skip, c.verify = state.Statements, state.Statements, true
+	if 0 < state.Progress.NextStationId {
+		c.skip = state.Preamble
+	}
+	return c, nil
+}
+
+func (c *checkpointer) Write(statement []byte) (int, error) {
+	if 0 < c.skip {
+		c.skip--
+		return len(statement), nil
+	}
+	if c.verify {
+		c.verify = false
+		// After the preamble the next statement must be the first of the station
+		// after the checkpoint
+		if !c.progress.equal(c.state.Progress) || (0 < c.state.Progress.NextStationId && c.progress.current != c.state.Progress.NextStationId) {
+			return 0, fmt.Errorf("The statements no longer line up with checkpoint %s, which was at %s but is now at %s", c.path, c.state.Progress, c.progress)
+		}
+	}
+	if 0 != c.progress.current && 0 > c.state.Preamble {
+		c.state.Preamble = c.state.Statements
+	}
+	// Checkpoints are only made between stations, where the next one can be
+	// parsed from the input offset
+	between := 0 > c.state.Preamble || c.progress.current != c.station
+	c.station = c.progress.current
+	if between && c.interval <= c.state.Statements-c.saved {
+		if err := c.save(); nil != err {
+			return 0, err
+		}
+	}
+	n, err := c.writer.Write(statement)
+	if nil == err {
+		c.state.Statements++
+	}
+	return n, err
+}
+
+// Flush the output to disk and replace the checkpoint with one for everything
+// written so far.
+func (c *checkpointer) save() error {
+	if err := c.flush(); nil != err {
+		return err
+	}
+	if err := c.file.Sync(); nil != err {
+		return fmt.Errorf("Failed to sync output: %w", err)
+	}
+	offset, err := c.file.Seek(0, io.SeekCurrent)
+	if nil != err {
+		return fmt.Errorf("Failed to find the length of the output: %w", err)
+	}
+	c.state.OutputBytes, c.state.Progress, c.saved = offset, c.progress.clone(), c.state.Statements
+	text, err := json.MarshalIndent(c.state, "", "\t")
+	if nil != err {
+		return fmt.Errorf("Failed to encode checkpoint: %w", err)
+	}
+	// Renaming keeps the old checkpoint whole if writing the new one fails
+	if err := os.WriteFile(c.path+".tmp", text, 0o644); nil != err {
+		return fmt.Errorf("Failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(c.path+".tmp", c.path); nil != err {
+		return fmt.Errorf("Failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Remove the checkpoint, once the conversion has finished or before a new one
+// starts, so that it cannot be resumed.
+func (c *checkpointer) finish() error {
+	if err := os.Remove(c.path); nil != err && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("Failed to remove checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Copy the progress, with the links of its own.
+func (p emissionProgress) clone() emissionProgress {
+	p.LineLinks = maps.Clone(p.LineLinks)
+	return p
+}
+
+// Whether the progress is the same as the other.
+func (p emissionProgress) equal(other emissionProgress) bool {
+	return p.InputOffset == other.InputOffset && p.NextStationId == other.NextStationId && maps.Equal(p.LineLinks, other.LineLinks)
+}
+
+// Describe the progress for the log.
+func (p emissionProgress) String() string {
+	links := 0
+	for _, count := range p.LineLinks {
+		links += count
+	}
+	if 0 == p.NextStationId {
+		return "the start of the stations"
+	}
+	return fmt.Sprintf("next station ID %d after row %d (byte %d) and %d links", p.NextStationId, p.Row, p.InputOffset, links)
+}
+
+// Reader of the stations CSV when resuming from a checkpoint, passing over the
+// bytes from the end of the header to the end of the record of the last station
+// written without them being parsed, and counting their lines so that the rows
+// of the later stations stay the same.
+type inputSkip struct {
+	reader   io.Reader
+	from, to int64 // Byte offsets the bytes passed over start and end at
+	position int64 // Byte offset of the next byte read from reader
+	lines    int   // Number of lines passed over
+}
+
+func (s *inputSkip) Read(p []byte) (int, error) {
+	if s.position == s.from && s.from < s.to {
+		buffer := make([]byte, 32*1024)
+		for s.position < s.to {
+			n, err := s.reader.Read(buffer[:min(int64(len(buffer)), s.to-s.position)])
+			s.lines += bytes.Count(buffer[:n], []byte("\n"))
+			s.position += int64(n)
+			if errors.Is(err, io.EOF) && s.position < s.to {
+				return 0, fmt.Errorf("The input ends at byte %d, before the checkpoint at byte %d", s.position, s.to)
+			} else if nil != err && !errors.Is(err, io.EOF) {
+				return 0, err
+			}
+		}
+	}
+	if s.position < s.from && s.from-s.position < int64(len(p)) {
+		p = p[:s.from-s.position]
+	}
+	n, err := s.reader.Read(p)
+	s.position += int64(n)
+	return n, err
+}
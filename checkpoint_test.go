@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Resuming after a failure carries on from the checkpoint without parsing the
+// stations before it again, and ends with the same output as a conversion that
+// never failed.
+func TestCheckpointResume(t *testing.T) {
+	// Over the 1 MiB prefix that is hashed, so that records after it can be
+	// changed in place without the checkpoint noticing
+	var stations strings.Builder
+	stations.WriteString("Station,Ruby,Emerald\n")
+	for i := 0; stations.Len() < fingerprintBytes+64*1024; i++ {
+		fmt.Fprintf(&stations, "Station %06d,true,false\n", i)
+	}
+	quoted := "Station " + strings.Repeat("'", 20)
+	fmt.Fprintf(&stations, "%s,false,true\n", quoted)
+	text := stations.String()
+	fixed := strings.Replace(text, quoted, "Station "+strings.Repeat("x", 20), 1)
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": text, "fixed.csv": fixed})
+
+	args := []string{"-lines", "lines.csv", "-stations", "stations.csv", "-stations-format", "matrix", "-max-statement-bytes", "80",
+		"-output", "output.sql", "-checkpoint", "output.checkpoint", "-checkpoint-interval", "1000"}
+	if _, _, err := runArgs(t, args...); nil == err || !strings.Contains(err.Error(), "over the maximum of 80") {
+		t.Fatalf("Expected the quoted station to be too long, got %v", err)
+	}
+	if _, err := os.Stat("output.checkpoint"); nil != err {
+		t.Fatalf("Expected a checkpoint to be left behind: %v", err)
+	}
+
+	// A record before the checkpoint but after the hashed prefix that no longer
+	// parses shows that it is passed over
+	late := strings.Index(text[fingerprintBytes:], "true,false\n") + fingerprintBytes
+	broken := text[:late] + "xxxx" + text[late+4:]
+	broken = strings.Replace(broken, quoted, "Station "+strings.Repeat("x", 20), 1)
+	if err := os.WriteFile("stations.csv", []byte(broken), 0o644); nil != err {
+		t.Fatal(err)
+	}
+	_, stderr, err := runArgs(t, append(args, "-resume")...)
+	if nil != err {
+		t.Fatalf("Resuming failed: %v", err)
+	}
+	if !strings.Contains(stderr, "Resuming after") {
+		t.Errorf("Expected the resume to be reported, got %q", stderr)
+	}
+	if _, err := os.Stat("output.checkpoint"); !os.IsNotExist(err) {
+		t.Errorf("Expected the checkpoint to be removed once finished, got %v", err)
+	}
+
+	want, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "fixed.csv", "-stations-format", "matrix", "-max-statement-bytes", "80")
+	if nil != err {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("output.sql")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !bytes.Equal([]byte(want), got) {
+		t.Errorf("Resumed output of %d bytes differs from the %d bytes of a conversion without failing", len(got), len(want))
+	}
+}
+
+func TestCheckpointConflicts(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald,zone\nFoo,true,false,North\n"})
+	base := []string{"-lines", "lines.csv", "-stations", "stations.csv", "-output", "output.sql", "-checkpoint", "output.checkpoint"}
+	tests := []struct {
+		args []string
+		err  string
+	}{
+		{nil, "Checkpoints require -stations-format matrix"},
+		{[]string{"-stations-format", "matrix", "-sort-stations", "name"}, "Checkpoints cannot be used with -sort-stations name, which needs every station"},
+		{[]string{"-stations-format", "matrix"}, "Checkpoints cannot be used with alarm zones"},
+	}
+	for _, test := range tests {
+		if _, _, err := runArgs(t, append(base, test.args...)...); nil == err || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected an error containing %q with %v, got %v", test.err, test.args, err)
+		}
+	}
+}
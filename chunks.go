@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// Prefix of the statements starting a station, before which a chunk may end
+// even inside a transaction, as the station's links all follow it.
+const stationInsertPrefix = "INSERT INTO Stations "
+
+// A chunk file written so far, for the manifest.
+type chunkFile struct {
+	path       string
+	statements int
+}
+
+// Writer splitting the statements into numbered files of at most a number of
+// statements or bytes. Statements are held back in a group until the next place
+// a chunk may end, which is before a station or between transactions, and the
+// group goes to the next file if it does not fit in the current one. A
+// transaction cut in two is committed at the end of one file and begun again at
+// the start of the next, so every file stands on its own. Every write is
+// expected to be one complete statement.
+type chunkWriter struct {
+	prefix        string
+	maxStatements int // Most statements in a file, or 0 for any number
+	maxBytes      int // Most bytes in a file, or 0 for any number
+	newline       string
+	beginKeyword  string // Keyword the transactions begin with, or "" without any
+	logger        *log.Logger
+
+	group           bytes.Buffer // Statements since the last place a chunk may end
+	groupStatements int
+	groupBegun      bool // Whether the group starts inside a transaction
+
+	file          *os.File
+	fileBytes     int
+	chunks        []chunkFile
+	inTransaction bool
+}
+
+func newChunkWriter(prefix string, maxStatements int, maxBytes int, crlf bool, beginKeyword string, logger *log.Logger) *chunkWriter {
+	newline := "\n"
+	if crlf {
+		newline = "\r\n"
+	}
+	return &chunkWriter{prefix: prefix, maxStatements: maxStatements, maxBytes: maxBytes, newline: newline, beginKeyword: beginKeyword, logger: logger}
+}
+
+func (w *chunkWriter) Write(statement []byte) (int, error) {
+	text := strings.TrimSpace(string(statement))
+	begins := "" != w.beginKeyword && strings.EqualFold(w.beginKeyword+";", text)
+	station := len(stationInsertPrefix) <= len(text) && strings.EqualFold(stationInsertPrefix, text[:len(stationInsertPrefix)])
+	if !w.inTransaction || begins || station {
+		if err := w.endGroup(); nil != err {
+			return 0, err
+		}
+		w.groupBegun = w.inTransaction && !begins
+	}
+	if begins {
+		w.inTransaction = true
+	} else if "COMMIT;" == text || "ROLLBACK;" == text {
+		w.inTransaction = false
+	}
+	if err := w.add(&w.group, string(bytes.TrimSuffix(statement, []byte("\n")))); nil != err {
+		return 0, err
+	}
+	w.groupStatements++
+	return len(statement), nil
+}
+
+// Write the statement to the buffer with the configured newline.
+func (w *chunkWriter) add(buffer *bytes.Buffer, statement string) error {
+	_, err := buffer.WriteString(strings.TrimSuffix(statement, "\r") + w.newline)
+	return err
+}
+
+// Write the group of statements held back to the current file, or else to a
+// new one if it would not fit.
+func (w *chunkWriter) endGroup() error {
+	if 0 == w.groupStatements {
+		return nil
+	}
+	if nil == w.file || !w.fits() {
+		if err := w.next(); nil != err {
+			return err
+		}
+		if w.groupBegun {
+			if err := w.write(w.beginKeyword+";"+w.newline, 1); nil != err {
+				return err
+			}
+		}
+		if !w.fits() {
+			return fmt.Errorf("%d statements of %d bytes that cannot be split, like a station and its links, are more than fit in a chunk", w.groupStatements, w.group.Len())
+		}
+	}
+	if err := w.write(w.group.String(), w.groupStatements); nil != err {
+		return err
+	}
+	w.group.Reset()
+	w.groupStatements = 0
+	return nil
+}
+
+// Whether the group fits in the current file, along with the COMMIT the file
+// needs room for if it could be cut inside a transaction.
+func (w *chunkWriter) fits() bool {
+	statements, bytes := w.chunks[len(w.chunks)-1].statements+w.groupStatements, w.fileBytes+w.group.Len()
+	if w.inTransaction {
+		statements, bytes = statements+1, bytes+len("COMMIT;"+w.newline)
+	}
+	return (0 >= w.maxStatements || statements <= w.maxStatements) && (0 >= w.maxBytes || bytes <= w.maxBytes)
+}
+
+// Commit any transaction the current file ends in, close it, and start the
+// next one.
+func (w *chunkWriter) next() error {
+	if nil != w.file {
+		if w.groupBegun {
+			if err := w.write("COMMIT;"+w.newline, 1); nil != err {
+				return err
+			}
+		}
+		if err := w.file.Close(); nil != err {
+			return fmt.Errorf("Failed to close chunk %s: %w", w.file.Name(), err)
+		}
+	}
+	path := fmt.Sprintf("%s_%03d.sql", w.prefix, len(w.chunks)+1)
+	file, err := os.Create(path)
+	if nil != err {
+		return fmt.Errorf("Failed to create chunk: %w", err)
+	}
+	w.file, w.fileBytes = file, 0
+	w.chunks = append(w.chunks, chunkFile{path, 0})
+	return nil
+}
+
+// Write text of the given number of statements to the current file.
+func (w *chunkWriter) write(text string, statements int) error {
+	written, err := io.WriteString(w.file, text)
+	w.fileBytes += written
+	w.chunks[len(w.chunks)-1].statements += statements
+	if nil != err {
+		return fmt.Errorf("Failed to write chunk %s: %w", w.file.Name(), err)
+	}
+	return nil
+}
+
+// Write out the last group, close the last file, and write the manifest listing
+// every file in order with its number of statements.
+func (w *chunkWriter) finish() error {
+	if err := w.endGroup(); nil != err {
+		return err
+	}
+	if nil != w.file {
+		if err := w.file.Close(); nil != err {
+			return fmt.Errorf("Failed to close chunk %s: %w", w.file.Name(), err)
+		}
+		w.file = nil
+	}
+	var manifest strings.Builder
+	for _, chunk := range w.chunks {
+		fmt.Fprintf(&manifest, "%s\t%d\n", chunk.path, chunk.statements)
+	}
+	if err := os.WriteFile(w.prefix+"_manifest.txt", []byte(manifest.String()), 0o644); nil != err {
+		return fmt.Errorf("Failed to write chunk manifest: %w", err)
+	}
+	return nil
+}
+
+// Write out what was generated, whatever its size, and close the last file when
+// the conversion stopped early, without a manifest.
+func (w *chunkWriter) abort() {
+	if nil == w.file {
+		return
+	}
+	if 0 < w.groupStatements {
+		if err := w.write(w.group.String(), w.groupStatements); nil != err {
+			w.logger.Println(err)
+		}
+		w.group.Reset()
+		w.groupStatements = 0
+	}
+	if err := w.file.Close(); nil != err {
+		w.logger.Printf("Failed to close chunk %s: %v\n", w.file.Name(), err)
+	}
+	w.file = nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestChunksBulkLoad(t *testing.T) {
+	writeFiles(t, nil)
+	if _, _, err := runArgs(t, "-builtin", "wmata", "-dialect", "mysql", "-bulk-load", "-output", "network", "-chunk-statements", "60"); nil != err {
+		t.Fatal(err)
+	}
+	manifest, err := os.ReadFile("network_manifest.txt")
+	if nil != err {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(manifest)), "\n")
+	if 2 > len(lines) {
+		t.Fatalf("Expected several chunks, got:\n%s", manifest)
+	}
+	for i, line := range lines {
+		path, _, _ := strings.Cut(line, "\t")
+		text, err := os.ReadFile(path)
+		if nil != err {
+			t.Fatal(err)
+		}
+		chunk := string(text)
+		if first := 0 == i; first != strings.HasPrefix(chunk, "-- Bulk load: unsafe while anyone else uses the database\nSET unique_checks = 0;\nSET foreign_key_checks = 0;\nBEGIN;\n") {
+			t.Errorf("Chunk %s starting with the bulk load prologue is %t, want %t", path, !first, first)
+		}
+		if last := len(lines)-1 == i; last != strings.HasSuffix(chunk, "COMMIT;\n-- End of bulk load\nSET foreign_key_checks = 1;\nSET unique_checks = 1;\n") {
+			t.Errorf("Chunk %s ending with the bulk load epilogue is %t, want %t", path, !last, last)
+		}
+		if 0 < i && !strings.HasPrefix(chunk, "BEGIN;\n") {
+			t.Errorf("Chunk %s does not begin a transaction", path)
+		}
+	}
+}
+
+func TestChunksRejectNoTransaction(t *testing.T) {
+	writeFiles(t, nil)
+	_, _, err := runArgs(t, "-builtin", "wmata", "-no-transaction", "-output", "network", "-chunk-statements", "60")
+	if nil == err || !strings.Contains(err.Error(), "Chunks cannot be used with -no-transaction") {
+		t.Errorf("Expected chunks to be rejected with -no-transaction, got %v", err)
+	}
+	if _, err := os.Stat("network_001.sql"); !os.IsNotExist(err) {
+		t.Errorf("Expected no chunk to be written, got %v", err)
+	}
+}
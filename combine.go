@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Rail line combining the stations of several columns of the stations CSV, such
+// as the branches of a line, from a -combine-lines value.
+type lineCombination struct {
+	name    string   // Name of the combined rail line
+	sources []string // Names of the rail lines combined into it
+}
+
+// Parse a -combine-lines value of the form "name=source,source,...".
+func parseLineCombination(spec string) (lineCombination, error) {
+	name, sources, found := strings.Cut(spec, "=")
+	name = strings.TrimSpace(name)
+	if !found || 0 >= len(name) {
+		return lineCombination{}, fmt.Errorf("Missing rail line name in %s", spec)
+	}
+	combination := lineCombination{name, splitList(sources)}
+	if 0 == len(combination.sources) {
+		return lineCombination{}, fmt.Errorf("Missing rail lines to combine into %s", name)
+	}
+	return combination, nil
+}
+
+// Replace the source rail lines of each combination with a single rail line,
+// putting every station on any of the sources on it. The combined rail lines
+// get the first IDs in the order of the combinations, followed by the other
+// rail lines in their current order. A combined rail line keeps the colors of
+// the source with the same name, or else of its first source. The stations
+// remember which source each of their combined rail lines came from, the first
+// one listed if they were on several.
+func combineLines(lines []railLine, stations []station, combinations []lineCombination) ([]railLine, error) {
+	combined := make([]railLine, 0, len(lines))
+	newIds := make([]int, len(lines)+1)
+	sourceNames := make([]string, len(lines)+1)
+	for _, combination := range combinations {
+		line := railLine{}
+		lineId := len(combined) + 1
+		for i, source := range combination.sources {
+			index, err := lineIndex(lines, source)
+			if nil != err {
+				return nil, fmt.Errorf("Failed to combine %s: %w", combination.name, err)
+			}
+			if 0 != newIds[index+1] {
+				return nil, fmt.Errorf("Rail line %s is combined more than once", source)
+			}
+			if 0 == i || combination.name == source {
+				line = lines[index]
+			}
+			newIds[index+1] = lineId
+			sourceNames[index+1] = source
+		}
+		line.name = combination.name
+		combined = append(combined, line)
+	}
+	for i, line := range lines {
+		if 0 == newIds[i+1] {
+			combined = append(combined, line)
+			newIds[i+1] = len(combined)
+		}
+	}
+
+	for i := range stations {
+		current := &stations[i]
+		lineIds := make([]int, 0, len(current.lines))
+		positions := make(map[int]int)
+		for _, lineId := range current.lines {
+			if len(lines) < lineId {
+				return nil, fmt.Errorf("Station %s is on rail line %d which is not in the lines CSV", current.name, lineId)
+			}
+			newId := newIds[lineId]
+			if position, found := current.positions[lineId]; found && 0 == positions[newId] {
+				positions[newId] = position
+			}
+			if "" != sourceNames[lineId] {
+				if nil == current.branches {
+					current.branches = make(map[int]string)
+				}
+				if _, found := current.branches[newId]; !found || firstSource(combinations, sourceNames[lineId], current.branches[newId]) {
+					current.branches[newId] = sourceNames[lineId]
+				}
+			}
+			lineIds = append(lineIds, newId)
+		}
+		slices.Sort(lineIds)
+		current.lines = slices.Compact(lineIds)
+		if nil != current.positions {
+			current.positions = positions
+		}
+	}
+	return combined, nil
+}
+
+// Whether source a is listed before source b in the combinations.
+func firstSource(combinations []lineCombination, a string, b string) bool {
+	for _, combination := range combinations {
+		for _, source := range combination.sources {
+			if a == source {
+				return true
+			}
+			if b == source {
+				return false
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// Header name of the stations CSV column with the name of the station complex
+// each station belongs to, like the platforms of one station counted as one
+// for evacuations.
+const complexColumn = "complex"
+
+// List the distinct station complexes in the order they are first seen, along
+// with the findings for the complexes with a single station, whose name is
+// likely a typo of another. The ID of each complex is its position in the list
+// plus one.
+func collectComplexes(stations []station) ([]string, []string) {
+	var complexes []string
+	members := make(map[string][]station)
+	for _, current := range stations {
+		if "" == current.complex {
+			continue
+		}
+		if !slices.Contains(complexes, current.complex) {
+			complexes = append(complexes, current.complex)
+		}
+		members[current.complex] = append(members[current.complex], current)
+	}
+	var findings []string
+	for _, complex := range complexes {
+		if only := members[complex]; 1 == len(only) {
+			findings = append(findings, fmt.Sprintf("Station complex %s has only the station %s in row %d, the complex name may be a typo", complex, only[0].name, only[0].row))
+		}
+	}
+	return complexes, findings
+}
+
+// Column of the 'Stations' table with the Complexes row of the station complex
+// the station belongs to.
+var complexIdColumn = columnDefinition{"complex_id", "INTEGER", ""}
+
+// Fill the complex_id column of every station with the ID of its complex.
+func assignComplexFields(stations []station, complexes []string) {
+	if 0 == len(complexes) {
+		return
+	}
+	for i := range stations {
+		literal := "NULL"
+		if complexId := slices.Index(complexes, stations[i].complex); 0 <= complexId {
+			literal = strconv.Itoa(complexId + 1)
+		}
+		stations[i].fields = append(stations[i].fields, field{complexIdColumn.name, literal})
+	}
+}
+
+// Generate the SQL statements for populating the 'Complexes' table.
+func (e *emitter) complexStatements(complexes []string, writer io.Writer) error {
+	for i, complex := range complexes {
+		if err := e.writeInsert(writer, "Complexes", []string{"id", "name"}, []string{strconv.Itoa(i + 1), e.quoteSqlString(complex)}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write complex insert statement: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// First bytes of every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Wrap the reader of the CSV file at path in a gzip reader if it is compressed
+// according to the compression of the conversion. When detecting it
+// automatically, the file is compressed if its name ends in ".gz" or it starts
+// with the gzip magic bytes.
+func (c *conversion) decompress(reader io.Reader, path string) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	gzipped := "gzip" == c.compression
+	if "auto" == c.compression {
+		magic, err := buffered.Peek(len(gzipMagic))
+		if nil != err && io.EOF != err {
+			return nil, fmt.Errorf("Failed to read %s: %w", path, err)
+		}
+		gzipped = strings.HasSuffix(path, ".gz") || bytes.Equal(gzipMagic, magic)
+	}
+	if !gzipped {
+		return buffered, nil
+	}
+
+	decompressed, err := gzip.NewReader(buffered)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to read gzip header of %s: %w", path, err)
+	}
+	return gzipReader{decompressed, path}, nil
+}
+
+// Reader of a gzip stream that marks its errors as coming from the compression,
+// so that a corrupt file is not mistaken for a malformed CSV record.
+type gzipReader struct {
+	reader *gzip.Reader
+	path   string
+}
+
+func (r gzipReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if nil != err && io.EOF != err {
+		err = fmt.Errorf("Corrupt gzip stream in %s: %w", r.path, err)
+	}
+	return n, err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Compress the text with gzip.
+func gzipText(t *testing.T, text string) string {
+	t.Helper()
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	if _, err := writer.Write([]byte(text)); nil != err {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); nil != err {
+		t.Fatal(err)
+	}
+	return compressed.String()
+}
+
+// Compressed CSV files are detected by their name or their magic bytes and
+// converted like the plain ones.
+func TestGzipFile(t *testing.T) {
+	stations := gzipText(t, basicStations)
+	writeFiles(t, map[string]string{"lines.csv.gz": gzipText(t, testLines), "stations.csv.gz": stations, "stations.dat": stations, "plain.csv": basicStations})
+	for _, args := range [][]string{
+		{"-lines", "lines.csv.gz", "-stations", "stations.csv.gz"},
+		{"-lines", "lines.csv.gz", "-stations", "stations.dat"},
+		{"-lines", "lines.csv.gz", "-stations", "stations.dat", "-compression", "gzip"},
+	} {
+		stdout, _, err := runArgs(t, args...)
+		if nil != err {
+			t.Fatalf("Conversion failed with %v: %v", args, err)
+		}
+		checkGolden(t, "basic.sql", stdout)
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv.gz", "-stations", "plain.csv", "-compression", "gzip"); nil == err || !strings.Contains(err.Error(), "Failed to read gzip header of plain.csv") {
+		t.Errorf("Expected a gzip header error, got %v", err)
+	}
+}
+
+// A compressed CSV file piped to Standard In is detected by its magic bytes.
+func TestGzipStdin(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv.gz": gzipText(t, basicStations)})
+	stdin, err := os.Open("stations.csv.gz")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+	original := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = original }()
+
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "-")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "basic.sql", stdout)
+}
+
+// A gzip stream cut short is reported as corrupt rather than as a malformed
+// record.
+func TestGzipCorrupt(t *testing.T) {
+	stations := gzipText(t, basicStations)
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv.gz": stations[:len(stations)-10]})
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv.gz"); nil == err || !strings.Contains(err.Error(), "Corrupt gzip stream in stations.csv.gz: unexpected EOF") {
+		t.Errorf("Expected a corrupt stream error, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Edge between two consecutive stations of a rail line.
+type connection struct {
+	lineId        int
+	fromStationId int
+	toStationId   int
+	fields        []field
+}
+
+// Record the position of the station along the rail line with the given ID.
+func (s *station) setPosition(lineId int, position int) {
+	if nil == s.positions {
+		s.positions = make(map[int]int)
+	}
+	s.positions[lineId] = position
+}
+
+// Check that the positions of the stations along each rail line are 1 to the
+// number of stations on it, each used once.
+func validatePositions(lines []railLine, stations []station) error {
+	for i, line := range lines {
+		lineId := i + 1
+		var positions []int
+		for _, current := range stations {
+			if position, found := current.positions[lineId]; found {
+				positions = append(positions, position)
+			}
+		}
+		slices.Sort(positions)
+		for j, position := range positions {
+			if j+1 != position {
+				return fmt.Errorf("Positions along rail line %s are not 1 to %d, found %d where %d was expected", line.name, len(positions), position, j+1)
+			}
+		}
+	}
+	return nil
+}
+
+// Connect each pair of consecutive stations along every rail line, in order of
+// their positions, in both directions when both is set and otherwise only in
+// the direction of increasing position.
+func buildConnections(lines []railLine, stations []station, both bool) []connection {
+	var connections []connection
+	for i := range lines {
+		lineId := i + 1
+		var onLine []station
+		for _, current := range stations {
+			if _, found := current.positions[lineId]; found {
+				onLine = append(onLine, current)
+			}
+		}
+		slices.SortFunc(onLine, func(a, b station) int { return cmp.Compare(a.positions[lineId], b.positions[lineId]) })
+
+		for j := 1; j < len(onLine); j++ {
+			from, to := onLine[j-1].id, onLine[j].id
+			connections = append(connections, connection{lineId: lineId, fromStationId: from, toStationId: to})
+			if both {
+				connections = append(connections, connection{lineId: lineId, fromStationId: to, toStationId: from})
+			}
+		}
+	}
+	return connections
+}
+
+// Generate the SQL statements for populating the 'Connections' table.
+func (e *emitter) connectionStatements(connections []connection, writer io.Writer) error {
+	fieldColumns := collectFieldColumns(connections, func(c connection) []field { return c.fields })
+	for _, current := range connections {
+		if err := e.writeInsert(writer, "Connections", []string{"line_id", "from_station_id", "to_station_id"}, []string{strconv.Itoa(current.lineId),
+			strconv.Itoa(current.fromStationId), strconv.Itoa(current.toStationId)}, fieldColumns, current.fields); nil != err {
+			return fmt.Errorf("Failed to write connection statement: %w", err)
+		}
+	}
+	return nil
+}
+
+// Travel time between two stations of a rail line, read from the distances CSV.
+type distance struct {
+	line, from, to string
+	seconds        int
+	row            int // Line of the distances CSV the distance was read from
+}
+
+// Parse the distances CSV, with the rail line, the station travelled from, the
+// station travelled to, and the travel time in seconds on each row.
+func (c *conversion) parseDistances(reader *csv.Reader) ([]distance, error) {
+	reader.FieldsPerRecord = 4
+	if _, err := reader.Read(); nil != err {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+
+	var distances []distance
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read record for distance %d: %w", len(distances)+1, err)
+		}
+		if err := c.nul.apply(reader, record); nil != err {
+			return nil, err
+		}
+
+		row, _ := reader.FieldPos(0)
+		seconds, err := strconv.Atoi(strings.TrimSpace(record[3]))
+		if nil != err || 0 > seconds {
+			return nil, fmt.Errorf("Invalid number of seconds %q in row %d", record[3], row)
+		}
+		distances = append(distances, distance{
+			line:    strings.TrimSpace(record[0]),
+			from:    strings.TrimSpace(record[1]),
+			to:      strings.TrimSpace(record[2]),
+			seconds: seconds,
+			row:     row,
+		})
+	}
+	return distances, nil
+}
+
+// Fill the seconds column of each connection with the travel time between its
+// stations, adding the prefixes given to the rail line and station names to the
+// names in the distances CSV. A distance applies to the reverse connection too
+// unless that has its own. Connections without a distance are NULL and listed as
+// findings, while a distance between stations that are not consecutive on the
+// rail line is an error as the names are likely misspelled.
+func applyDistances(connections []connection, lines []railLine, stations []station, distances []distance, linePrefix string, stationPrefix string) ([]string, error) {
+	stationNames := make(map[int]string, len(stations))
+	for _, current := range stations {
+		stationNames[current.id] = current.name
+	}
+	key := func(line, from, to string) string { return line + "\x00" + from + "\x00" + to }
+	rows := make(map[string]int, len(distances))
+	for i, current := range distances {
+		rows[key(linePrefix+current.line, stationPrefix+current.from, stationPrefix+current.to)] = i
+	}
+
+	used := make([]bool, len(distances))
+	var findings []string
+	for i := range connections {
+		current := &connections[i]
+		line, from, to := lines[current.lineId-1].name, stationNames[current.fromStationId], stationNames[current.toStationId]
+		index, found := rows[key(line, from, to)]
+		if !found {
+			index, found = rows[key(line, to, from)]
+		}
+		literal := "NULL"
+		if found {
+			used[index] = true
+			literal = strconv.Itoa(distances[index].seconds)
+		} else {
+			findings = append(findings, fmt.Sprintf("No distance from %s to %s on rail line %s", from, to, line))
+		}
+		current.fields = append(current.fields, field{"seconds", literal})
+	}
+
+	for i, current := range distances {
+		if !used[i] {
+			return nil, fmt.Errorf("Distance in row %d from %s to %s on rail line %s is not between consecutive stations", current.row, current.from, current.to, current.line)
+		}
+	}
+	return findings, nil
+}
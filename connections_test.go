@@ -0,0 +1,118 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Stations CSV of positions along the rail lines, Bar first on both.
+const sequenceStations = "Station,Ruby,Emerald\nFoo,2,\nBar,1,1\nBaz,3,2\n"
+
+// Consecutive stations of each rail line are connected in order of their
+// positions, whatever the order of the rows, in one or both directions.
+func TestEmitConnections(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": sequenceStations})
+	for _, test := range []struct {
+		direction string
+		want      []string
+	}{
+		{"forward", []string{"(1, 2, 1)", "(1, 1, 3)", "(2, 2, 3)"}},
+		{"both", []string{"(1, 2, 1)", "(1, 1, 2)", "(1, 1, 3)", "(1, 3, 1)", "(2, 2, 3)", "(2, 3, 2)"}},
+	} {
+		t.Run(test.direction, func(t *testing.T) {
+			stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-bool-style", "sequence", "-emit-connections", "-connection-direction", test.direction)
+			if nil != err {
+				t.Fatal(err)
+			}
+			var want strings.Builder
+			for _, values := range test.want {
+				want.WriteString("INSERT INTO Connections VALUES " + values + ";\n")
+			}
+			var got strings.Builder
+			for _, line := range strings.SplitAfter(stdout, "\n") {
+				if strings.HasPrefix(line, "INSERT INTO Connections") {
+					got.WriteString(line)
+				}
+			}
+			if want.String() != got.String() {
+				t.Errorf("Connections:\n%s\nwant:\n%s", got.String(), want.String())
+			}
+		})
+	}
+}
+
+// The positions along each rail line must be 1 to the number of its stations,
+// and only positions can give the order.
+func TestEmitConnectionsErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		stations string
+		args     []string
+		err      string
+	}{
+		{"gap", "Station,Ruby,Emerald\nFoo,1,\nBar,3,1\n", nil, "Positions along rail line Ruby are not 1 to 2, found 3 where 2 was expected"},
+		{"repeated", "Station,Ruby,Emerald\nFoo,1,1\nBar,2,1\n", nil, "Positions along rail line Emerald are not 1 to 2, found 1 where 2 was expected"},
+		{"not from 1", "Station,Ruby,Emerald\nFoo,2,\nBar,3,1\n", nil, "Positions along rail line Ruby are not 1 to 2, found 2 where 1 was expected"},
+		{"negative", "Station,Ruby,Emerald\nFoo,-1,\nBar,1,1\n", nil, `Invalid position "-1" for Foo, line Ruby`},
+		{"booleans", basicStations, []string{"-bool-style", "boolean"}, "Emitting connections requires -bool-style sequence"},
+		{"direction", sequenceStations, []string{"-connection-direction", "backward"}, "Invalid connection direction: backward"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": test.stations})
+			args := append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-bool-style", "sequence", "-emit-connections"}, test.args...)
+			if _, _, err := runArgs(t, args...); nil == err || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+// Distances fill the seconds of the connections in both directions, matched by
+// the names as written, with a warning for each connection left NULL.
+func TestDistances(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":     testLines,
+		"stations.csv":  sequenceStations,
+		"distances.csv": "Line,From,To,Seconds\nRuby,Bar,Foo,90\nRuby, Baz ,Foo,60\n",
+	})
+	stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-bool-style", "sequence", "-emit-connections", "-distances", "distances.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"(1, 2, 1, 90)", "(1, 1, 2, 90)", "(1, 1, 3, 60)", "(1, 3, 1, 60)", "(2, 2, 3, NULL)", "(2, 3, 2, NULL)"} {
+		if !strings.Contains(stdout, "INSERT INTO Connections (line_id, from_station_id, to_station_id, seconds) VALUES "+want+";\n") {
+			t.Errorf("Expected the connection %s in:\n%s", want, stdout)
+		}
+	}
+	for _, want := range []string{"No distance from Bar to Baz on rail line Emerald", "No distance from Baz to Bar on rail line Emerald"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("Expected %q to be warned about:\n%s", want, stderr)
+		}
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-bool-style", "sequence", "-emit-connections", "-distances", "distances.csv", "-strict"); nil == err || !strings.Contains(err.Error(), "No distance from Bar to Baz on rail line Emerald") {
+		t.Errorf("Expected the missing distance to be an error with -strict, got %v", err)
+	}
+}
+
+func TestDistancesErrors(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		distances string
+		args      []string
+		err       string
+	}{
+		{"not consecutive", "Line,From,To,Seconds\nRuby,Bar,Baz,90\n", nil, "Distance in row 2 from Bar to Baz on rail line Ruby is not between consecutive stations"},
+		{"misspelled", "Line,From,To,Seconds\nRuby,Bar,Fooo,90\n", nil, "Distance in row 2 from Bar to Fooo on rail line Ruby is not between consecutive stations"},
+		{"seconds", "Line,From,To,Seconds\nRuby,Bar,Foo,1.5\n", nil, `Invalid number of seconds "1.5" in row 2`},
+		{"negative", "Line,From,To,Seconds\nRuby,Bar,Foo,-1\n", nil, `Invalid number of seconds "-1" in row 2`},
+		{"without connections", "Line,From,To,Seconds\nRuby,Bar,Foo,90\n", []string{"-emit-connections=false"}, "Distances require -emit-connections"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": sequenceStations, "distances.csv": test.distances})
+			args := append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-bool-style", "sequence", "-emit-connections", "-distances", "distances.csv"}, test.args...)
+			if _, _, err := runArgs(t, args...); nil == err || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
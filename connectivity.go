@@ -0,0 +1,68 @@
+package main
+
+import "fmt"
+
+// Sets of rail lines joined by shared stations, as a union-find over the
+// indices of the rail lines.
+type lineSets []int
+
+func newLineSets(count int) lineSets {
+	sets := make(lineSets, count)
+	for i := range sets {
+		sets[i] = i
+	}
+	return sets
+}
+
+// Find the representative of the set the rail line at index is in.
+func (sets lineSets) find(index int) int {
+	for sets[index] != index {
+		sets[index] = sets[sets[index]]
+		index = sets[index]
+	}
+	return index
+}
+
+// Join the sets the rail lines at indices a and b are in.
+func (sets lineSets) union(a, b int) {
+	sets[sets.find(a)] = sets.find(b)
+}
+
+// List every rail line that cannot be reached from the largest group of rail
+// lines connected through transfer stations, along with its number of stations.
+// Such a line usually means its column of the stations CSV is misaligned. Rail
+// lines without any stations are left out, as they were either warned about when
+// parsing the stations or emptied on purpose by filtering.
+func findDisconnectedLines(lines []railLine, stations []station) []string {
+	sets := newLineSets(len(lines))
+	stationCounts := make([]int, len(lines))
+	for _, current := range stations {
+		for _, lineId := range current.lines {
+			if lineId <= len(lines) {
+				stationCounts[lineId-1]++
+				sets.union(current.lines[0]-1, lineId-1)
+			}
+		}
+	}
+
+	sizes := make([]int, len(lines))
+	largest := -1
+	for i := range lines {
+		if 0 == stationCounts[i] {
+			continue
+		}
+		root := sets.find(i)
+		sizes[root]++
+		if -1 == largest || sizes[largest] < sizes[root] {
+			largest = root
+		}
+	}
+
+	var findings []string
+	for i, line := range lines {
+		if 0 < stationCounts[i] && sets.find(i) != largest {
+			findings = append(findings, fmt.Sprintf("Rail line %s with %d stations is not connected to the rest of the network", line.name, stationCounts[i]))
+		}
+	}
+	return findings
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// A rail line sharing no station with the others is a warning naming it and its
+// number of stations, an error with -strict, and skipped with
+// -allow-disconnected.
+func TestDisconnectedLineWarning(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv": testLines + "Sapphire,0,0,255\n",
+		"stations.csv": "Station,Ruby,Emerald,Sapphire\n" +
+			"Foo,true,true,false\n" +
+			"Bar,false,true,false\n" +
+			"Baz,false,false,true\n" +
+			"Qux,false,false,true\n",
+	})
+	const want = "Rail line Sapphire with 2 stations is not connected to the rest of the network"
+	args := []string{"-lines", "lines.csv", "-stations", "stations.csv"}
+	_, warnings, err := runArgs(t, args...)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(warnings, "Warning: "+want+"\n") || 1 != strings.Count(warnings, "not connected") {
+		t.Errorf("Expected only Sapphire to be warned about:\n%s", warnings)
+	}
+	if _, _, err := runArgs(t, append(args, "-strict")...); nil == err || "Found disconnected rail lines: "+want != err.Error() {
+		t.Errorf("Expected the disconnected rail line to be an error, got %v", err)
+	}
+	if _, warnings, err := runArgs(t, append(args, "-allow-disconnected")...); nil != err || strings.Contains(warnings, "not connected") {
+		t.Errorf("Expected the check to be skipped, got %v:\n%s", err, warnings)
+	}
+}
+
+// Rail lines joined only through another rail line are connected.
+func TestConnectedThroughTransfers(t *testing.T) {
+	lines := []railLine{{name: "Ruby"}, {name: "Emerald"}, {name: "Sapphire"}}
+	stations := []station{{name: "Foo", lines: []int{1, 2}}, {name: "Bar", lines: []int{2, 3}}}
+	if findings := findDisconnectedLines(lines, stations); 0 != len(findings) {
+		t.Errorf("Unexpected findings: %v", findings)
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Prefix of the paths of CSV files given as readers to [Statements] rather
+// than read from the disk.
+const inlinePrefix = "inline:"
+
+// Options of [Statements], also read as a JSON object by [convertText]. Lines
+// is the text of the rail lines CSV file, and the others are the flags of the
+// same names.
+type Options struct {
+	Lines        string `json:"lines"`
+	Dialect      string `json:"dialect,omitempty"`
+	NetworkId    int    `json:"network-id,omitempty"`
+	SortStations string `json:"sort-stations,omitempty"`
+	SkipRows     int    `json:"skip-rows,omitempty"`
+	PreserveIds  bool   `json:"preserve-ids,omitempty"`
+	BoolStyle    string `json:"bool-style,omitempty"`
+	Strict       bool   `json:"strict,omitempty"`
+}
+
+// Command-line arguments of the options, reading the CSV files from the
+// inline inputs of the conversion.
+func (options Options) args() []string {
+	args := []string{"-lines", inlinePrefix + "lines", "-stations", inlinePrefix + "stations"}
+	if "" != options.Dialect {
+		args = append(args, "-dialect", options.Dialect)
+	}
+	if 0 != options.NetworkId {
+		args = append(args, "-network-id", strconv.Itoa(options.NetworkId))
+	}
+	if "" != options.SortStations {
+		args = append(args, "-sort-stations", options.SortStations)
+	}
+	if 0 != options.SkipRows {
+		args = append(args, "-skip-rows", strconv.Itoa(options.SkipRows))
+	}
+	if options.PreserveIds {
+		args = append(args, "-preserve-ids")
+	}
+	if "" != options.BoolStyle {
+		args = append(args, "-bool-style", options.BoolStyle)
+	}
+	if options.Strict {
+		args = append(args, "-strict")
+	}
+	return args
+}
+
+// Convert the text of a stations CSV file with the options as JSON, for the
+// convert function of the WebAssembly build, without touching any file. The
+// statements are those of [Statements] joined together.
+func convertText(stations string, optionsJson string) (string, error) {
+	var options Options
+	decoder := json.NewDecoder(strings.NewReader(optionsJson))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&options); nil != err {
+		return "", fmt.Errorf("Invalid options: %w", err)
+	}
+	var script strings.Builder
+	for statement, err := range Statements(strings.NewReader(stations), options) {
+		if nil != err {
+			return "", err
+		}
+		script.WriteString(statement.Sql)
+	}
+	return script.String(), nil
+}
+
+// Result of a call of the convert function of the WebAssembly build with its
+// arguments as strings: the statements of [convertText], or else its error as
+// an object with the fields of a [convertError].
+func convertCall(args []string) any {
+	if 2 != len(args) {
+		return map[string]any{"error": "convert takes the stations CSV text and the options as JSON"}
+	}
+	statements, err := convertText(args[0], args[1])
+	if nil != err {
+		document := newConvertError(err)
+		return map[string]any{"error": document.Error, "row": document.Row, "column": document.Column}
+	}
+	return statements
+}
+
+// Reader of the CSV file at the path, if it is one given to [Statements].
+func (c *conversion) inlineInput(path string) (io.Reader, bool) {
+	if !strings.HasPrefix(path, inlinePrefix) {
+		return nil, false
+	}
+	reader, ok := c.inlineInputs[strings.TrimPrefix(path, inlinePrefix)]
+	return reader, ok
+}
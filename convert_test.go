@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The WebAssembly build still compiles, as nothing else builds wasm.go.
+func TestWasmBuild(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Building for WebAssembly is slow")
+	}
+	if _, err := exec.LookPath("go"); nil != err {
+		t.Skip("No go command to build with")
+	}
+	command := exec.Command("go", "build", "-o", os.DevNull, ".")
+	command.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if output, err := command.CombinedOutput(); nil != err {
+		t.Fatalf("WebAssembly build failed: %v\n%s", err, output)
+	}
+}
+
+// Options as the JSON given to convert from JavaScript.
+func convertOptions(t *testing.T, options map[string]any) string {
+	t.Helper()
+	text, err := json.Marshal(options)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return string(text)
+}
+
+func TestConvertCall(t *testing.T) {
+	statements, ok := convertCall([]string{basicStations, convertOptions(t, map[string]any{"lines": testLines})}).(string)
+	if !ok {
+		t.Fatal("Expected the statements as a string")
+	}
+	checkGolden(t, "basic.sql", statements)
+
+	sorted, ok := convertCall([]string{basicStations, convertOptions(t, map[string]any{
+		"lines":         testLines,
+		"dialect":       "mysql",
+		"sort-stations": "name",
+		"network-id":    1,
+	})}).(string)
+	if !ok {
+		t.Fatal("Expected the statements as a string")
+	}
+	if bar, foo := strings.Index(sorted, "(1, 'Bar''s')"), strings.Index(sorted, "(2, 'Foo')"); 0 > bar || bar > foo {
+		t.Errorf("Expected the stations sorted by name:\n%s", sorted)
+	}
+}
+
+func TestConvertCallErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want map[string]any
+	}{
+		{"missing options", []string{basicStations}, map[string]any{"error": "convert takes the stations CSV text and the options as JSON"}},
+		{"malformed options", []string{basicStations, "{"}, map[string]any{"error": "Invalid options: unexpected EOF", "row": 0, "column": 0}},
+		{"unknown option", []string{basicStations, `{"lines": "", "color": "red"}`},
+			map[string]any{"error": `Invalid options: json: unknown field "color"`, "row": 0, "column": 0}},
+		{"short record", []string{"Station,Ruby,Emerald\nFoo,true,false\nBar,true\n", convertOptions(t, map[string]any{"lines": testLines})},
+			map[string]any{"error": "Failed to parse stations: Failed to read record for station 2: record on line 3: wrong number of fields", "row": 3, "column": 1}},
+		{"invalid dialect", []string{basicStations, convertOptions(t, map[string]any{"lines": testLines, "dialect": "db2"})},
+			map[string]any{"error": "Invalid dialect: db2", "row": 0, "column": 0}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := convertCall(test.args); !reflect.DeepEqual(test.want, got) {
+				t.Errorf("Expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
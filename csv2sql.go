@@ -16,7 +16,7 @@ BEEN WARNED!
 
 # Usage
 
-	csv2sql -lines lines.csv -stations stations.csv > output.sql
+	csv2sql -lines lines.csv -stations stations.csv [-devices devices.csv] > output.sql
 
 # Example
 
@@ -49,6 +49,539 @@ BEEN WARNED!
 		INSERT INTO LineStations VALUES (3, 3);
 		COMMIT;
 
+To try it out without any CSV files of your own, -builtin converts one of the
+example networks embedded in the program, as if its files had been given to
+-lines and -stations, so every other flag still applies. -list-builtins lists
+them, which for now is only the WMATA Metrorail of the wmata directory.
+
+	csv2sql -builtin wmata -dialect postgres -sort-stations name
+
+# Ordering
+
+By default rail lines and stations are assigned IDs in the order they appear in
+their CSV files. Two spreadsheets describing the same network but with their
+rows in a different order will therefore produce very different output. To get
+deterministic output regardless of row order, the stations can be sorted by name
+before IDs are assigned:
+
+	csv2sql -lines lines.csv -stations stations.csv -sort-stations name
+
+Rail lines can be sorted the same way with -sort-lines name, or pinned to an
+explicit order with -line-order. Any lines missing from the explicit order are
+placed after the listed ones, sorted by name. Naming a line that is not in the
+lines CSV is an error. The rail line IDs used by the LineStations statements are
+remapped to match, so the stations CSV columns keep lining up with the rows of
+the lines CSV.
+
+	csv2sql -lines lines.csv -stations stations.csv -line-order "Red,Blue,Green"
+
+Sorting compares the raw bytes of the names. Adding -fold-case makes the
+comparison case-insensitive, with ties still broken byte-wise so the result is
+stable. Comparing bytes puts accented names like "Éole" after "Zebra", so the
+names can instead be sorted by the rules of a locale given as a BCP 47 language
+tag with -collate, such as "en" or "de" (see [golang.org/x/text/collate]).
+
+	csv2sql -lines lines.csv -stations stations.csv -sort-stations name -collate de
+
+By default the LineStations and StationAttributes rows of each station follow
+its Stations row. Some loaders are much faster when all of the rows of one table
+are contiguous, so with -group-by-table all of the Stations rows come first,
+then all of the LineStations rows, and then all of the StationAttributes rows,
+each in the order of the station IDs.
+
+	csv2sql -lines lines.csv -stations stations.csv -group-by-table
+
+Branched rail lines are often split into several columns of the stations table,
+like "Yellow" and "Yellow (Rush+)", while the database should have one rail line
+with every station of its branches. Each -combine-lines 'NAME=LINE,LINE,...'
+replaces the listed rail lines with a single one named NAME, on which every
+station on any of them is. Combining is done after the filtering and ordering
+above, giving the combined rail lines the first IDs in the order of the
+-combine-lines flags, followed by the other rail lines. A combined rail line
+keeps the colors of the listed rail line of the same name, or else of the first
+one listed. With -branch-column the LineStations rows of combined rail lines
+fill the given column with the name of the listed rail line the station is on
+(the first one listed if it is on several), and NULL for other rail lines.
+
+	csv2sql -lines lines.csv -stations stations.csv -combine-lines "Yellow=Yellow,Yellow (Rush+)" -branch-column branch
+
+Instead of booleans, the cells of the rail line columns can give the position of
+each station along the rail line with -bool-style sequence: 1 for the first
+station, 2 for the next, and so on, while a blank cell or 0 means the station is
+not on it. The positions along each rail line must be 1 to its number of
+stations, each used once. With -emit-connections every pair of consecutive
+stations along each rail line then gets a row in the Connections table with the
+rail line and the two station IDs, in both directions unless
+-connection-direction forward is given. Stations dropped by filtering are
+skipped over, connecting the stations on either side of them.
+
+	Input (stations.csv)
+		Station,Ruby
+		Foo,2
+		Bar,1
+
+	Output
+		INSERT INTO Connections VALUES (1, 2, 1);
+		INSERT INTO Connections VALUES (1, 1, 2);
+
+For weighting the connections, -distances names a CSV file with the rail line,
+the station travelled from, the station travelled to, and the travel time in
+seconds on each row. It fills the seconds column of each connection between the
+stations, in either direction unless the reverse direction has a row of its own.
+Names are matched like those of the devices table. A connection without a
+distance gets NULL and a warning (or an error with -strict), while a distance
+between stations that are not consecutive on the rail line is an error, as it
+usually means a station name is misspelled.
+
+	Input (distances.csv)
+		Line,From,To,Seconds
+		Ruby,Bar,Foo,90
+
+	Output
+		INSERT INTO Connections (line_id, from_station_id, to_station_id, seconds) VALUES (1, 2, 1, 90);
+		INSERT INTO Connections (line_id, from_station_id, to_station_id, seconds) VALUES (1, 1, 2, 90);
+
+# Canonical output
+
+Generated scripts are often committed alongside the CSV files, so regenerating
+them from unchanged data should produce no diff. With -canonical the output is
+guaranteed to be byte-for-byte identical for the same set of rows and columns,
+regardless of their order in the CSV files, who runs the tool, or where:
+
+  - rail lines and stations are sorted by name (-sort-lines name and
+    -sort-stations name), so -line-order cannot be used
+  - every line ends with a single line feed
+  - there are no timestamps or other comments that depend on the environment,
+    so -timestamp-column requires an explicit time
+  - every statement has the fixed format shown in the example above
+
+Any option that would break these guarantees must be opt-in and is rejected in
+combination with -canonical.
+
+# Timestamps
+
+For tables with audit columns like created_at, -timestamp-column adds a column
+with the same timestamp to every rail line and station insert. By default it is
+the time the run started, captured once so that every row agrees. An explicit
+time can be given in RFC 3339 format, or now() to emit the SQL for the current
+time instead of a literal. Canonical output requires an explicit time.
+
+	csv2sql -lines lines.csv -stations stations.csv -timestamp-column created_at=2026-01-01T00:00:00Z
+
+# Filtering
+
+A subset of the rail lines can be selected with -only-lines and/or
+-exclude-lines, each taking a comma separated list of line names. Unselected
+lines get no RailLines row and no LineStations links, and the remaining lines
+are renumbered without gaps. Stations left on no rail line are dropped as well
+unless -keep-orphans is given. Naming a line that is not in the lines CSV is an
+error to catch typos.
+
+	csv2sql -lines lines.csv -stations stations.csv -exclude-lines "BusLoop"
+
+Networks covering more than rail can give each rail line a transit mode, in
+square brackets after its name in the header of the stations CSV, like
+"Red[rail]" or "70[bus]", while -mode gives the mode of every rail line whose
+header names none. The modes are emitted in lower case to a Modes table, with
+IDs in the order they are first seen, and every RailLines insert gets a mode_id
+column, which is NULL for a rail line without a mode. -only-modes keeps the rail
+lines of the comma separated modes and drops all others like -only-lines, along
+with the stations left on no rail line unless -keep-orphans is given.
+
+	csv2sql -lines lines.csv -stations stations.csv -mode rail -only-modes rail,streetcar
+
+A rail line that shares no station with the others usually means its column of
+the stations table is misaligned, so each rail line that is not connected to the
+largest group of rail lines joined by transfer stations gets a warning (or an
+error with -strict) giving its number of stations. For networks that really are
+disconnected, the check can be skipped with -allow-disconnected. The check is
+done after filtering, so dropping lines or stations can disconnect the rest.
+
+Stations can be selected by name with -station-filter and -station-exclude,
+which take regular expressions (see [regexp/syntax]) matched against the trimmed
+station name. A station is kept if it matches the filter and does not match the
+exclude pattern. Filtered stations are not assigned an ID and have no links. The
+number of stations filtered out is logged to Standard Error so that a typo in a
+pattern does not go unnoticed.
+
+	csv2sql -lines lines.csv -stations stations.csv -station-filter "^(Fort|Union)"
+
+Stations can be dropped by the status column of the stations table too, with
+-exclude-status listing the statuses to drop, like "closed,planned". Like the
+filtered stations they are not assigned an ID.
+
+	csv2sql -lines lines.csv -stations stations.csv -exclude-status closed,planned
+
+The records of the stations dropped by -station-filter, -station-exclude, or
+-exclude-status can be written to a file with -rejects, for repairing them
+rather than cross-referencing the log. The file starts with the header of the
+stations CSV, and every record follows a comment line starting with '#' that
+gives its row and why it was dropped. It is only created when a station was
+dropped. Once repaired it can be converted with -comment '#', which skips the
+lines starting with the given character in every CSV file.
+
+	csv2sql -lines lines.csv -stations stations.csv -exclude-status closed -rejects rejects.csv
+	csv2sql -lines lines.csv -stations rejects.csv -comment '#'
+
+# GTFS feeds
+
+Instead of the lines and stations CSV files, the network can be read from a
+GTFS feed with -gtfs, given either as a directory or as the ZIP archive agencies
+distribute, which is read in place without extracting it. Its routes.txt,
+stops.txt, trips.txt, and stop_times.txt are found whatever their case and
+whichever folder of the archive they are in, and a feed missing any of them is
+an error listing the files it does have. Every route running on rails (trams,
+subways, trains, cable trams, funiculars, and monorails) becomes a rail line,
+named by its short name or else its long name, with the color of its
+route_color or white. Every stop the trips of those routes call at becomes a
+station linked to their rail lines, where platforms inside a parent station
+count as that station. stop_times.txt, usually by far the largest file, is read
+a record at a time rather than all at once.
+
+	csv2sql -gtfs google_transit.zip > output.sql
+
+# Merging networks
+
+Several networks, each with its own lines and stations CSV files, can be
+converted in one run by giving -merge once per network instead of -lines and
+-stations. The IDs of each network follow on from those of the networks before
+it. With -merge-prefix every station name is prefixed with the label of its
+network (e.g. "region2:Penn Station") to avoid collisions. Rail lines sharing a
+name across networks are kept apart by default (and prefixed as well under
+-merge-prefix), while -merge-lines merge combines them into one rail line that
+keeps the colors of the first network it appears in.
+
+	csv2sql -merge "dc=dc/lines.csv,dc/stations.csv" -merge "baltimore=baltimore/lines.csv,baltimore/stations.csv" -merge-prefix
+
+# Migrating
+
+When the stations CSV changes after a database was loaded from it, the migrate
+subcommand emits only the statements that bring the database up to date instead
+of reloading everything. It compares the old stations CSV given by -from with
+the new one given by -to (and the old lines CSV given by -from-lines with the
+new -lines, if the rail lines changed too), matching rail lines and stations by
+name. Removed links, stations, and rail lines are deleted, along with the rows
+of the optional tables referencing them, every row before the rows it
+references, and then new rail lines, stations, and links are inserted with IDs
+following on from the old ones, all in one transaction. The optional tables of
+the database are given by -tables like for the schema subcommand, none of them
+by default, as in setup.sql. Users subscribed to a removed station in
+UserStations are a conflict rather than rows to delete, so the script first
+stops if there are any, in the -dialect sqlite and postgres, or selects them
+after a comment in the others. The old IDs are assumed to be those of a
+conversion without options. A summary of the changes is printed to Standard
+Error before the SQL. As there are no IDs in the CSV files, a renamed station is
+a removal and an addition.
+
+	csv2sql migrate -lines lines.csv -from old/stations.csv -to stations.csv > migration.sql
+
+To keep a live database up to date rather than an old CSV file, -sync reads the
+rail lines, stations, and links of the SQLite database given by -dsn and emits
+the statements that bring it in line with the CSV files, keeping the IDs it
+already has. Names are matched after trimming spaces, and ignoring case with
+-fold-case. Rail lines, stations, and links in the database but not in the CSV
+files are only reported, unless -prune is given to delete them, which deletes
+the rows of the optional tables in the database referencing them like the
+migrate subcommand, and stops on subscriptions to a removed station. With
+-execute the changes are applied to the database directly, in one transaction,
+instead of being written out.
+
+	csv2sql -lines lines.csv -stations stations.csv -sync -dsn db.sqlite -prune -execute
+
+When the database cannot be read, or is not SQLite, -link-by-name emits a
+script that tops it up whatever it already holds. Rail lines and stations are
+only inserted when none with the name exists, with the ID after the largest in
+the table, and the links, aliases, translated names, and attributes find their
+rail line and station by name, each only inserted when missing too. Running
+the script again changes nothing. It cannot be used with anything else that is
+referenced by ID, like alarm zones, devices, connections, networks, transit
+modes, and agencies.
+
+	csv2sql -lines lines.csv -stations stations.csv -link-by-name > topup.sql
+
+Some databases, or their administrators, refuse explicit values for identity
+columns. With -db-ids the rail lines and stations are inserted without their id
+column for the database to assign, and the links, aliases, translated names, and
+attributes find them by name in the same way, so no sequence needs resetting
+afterwards. Together with -link-by-name the inserts are also only made when
+missing. The same features are unavailable as for -link-by-name, and so is
+-report, since the IDs it lists would not be the ones the database assigns.
+
+	csv2sql -lines lines.csv -stations stations.csv -db-ids > output.sql
+
+# Generating networks
+
+For fixtures and benchmarks, the gen subcommand writes a synthetic network of
+any size as lines and stations CSV files, given by -lines-out and -stations-out.
+With -stations and -lines setting the size, each station is on each rail line
+with the chance given by -density (0.12 by default), and on at least one, while
+the first stations link each rail line to the next so that the network stays
+connected as long as there are enough of them. The memberships and colors are
+pseudo-random but the same for the same -seed, and the names include quotes,
+commas, and non-ASCII characters to exercise the escaping. With -report the
+statistics the network is expected to have are written as JSON in the format of
+the -report of a conversion, so that a benchmark can compare the two.
+
+	csv2sql gen -stations 100000 -lines 40 -seed 7 -report expected.json
+
+# Creating the tables
+
+The tables of setup.sql are described in the program itself, and the schema
+subcommand writes their CREATE TABLE statements. The same statements are what
+-self-test loads the inserts into, and setup.sql is simply their output with the
+default flags, so the three cannot drift apart. The rail lines, stations, their
+links, and the users with their subscriptions are always created, while -tables
+picks which of the optional tables to create along with them as a
+comma-separated list of agencies, attributes, aliases, names, complexes, panels,
+zones, devices, entrances, and connections, or all or none of them (the
+default). The columns holding station names, aliases, and translations are
+sized by -max-name-length, 128 characters by default. The tables are the same in
+every -dialect, except that MySQL cannot index names longer than 768 characters.
+The optional columns of the rail lines, stations, and their links, like
+network_id or those filled from the columns of the stations CSV, are picked the
+same way with -columns as a comma-separated list of their names, 'all', or
+'none' (the default), and the Networks and Modes tables are created only for a
+column referencing them. Without optional columns the tables take the inserts of
+a conversion without options as they are, while inserts filling optional columns
+need them added, or -schema-file setup.sql to list their columns.
+
+	csv2sql schema -tables aliases,connections -max-name-length 200 > setup.sql
+	csv2sql schema -columns network_id,latitude,longitude > setup.sql
+
+# Serving conversions
+
+For tools that would rather not run the program themselves, the serve
+subcommand listens on -listen (:8080 by default) for POST /convert requests. A
+request uploads the rail lines and stations CSV files as the lines and stations
+fields of a multipart form, or else sends the stations CSV file as its body
+with the text of the rail lines CSV file as the lines query parameter. It may
+give the dialect, network-id, sort-stations, skip-rows, preserve-ids,
+bool-style, and strict flags as query parameters. The statements are returned
+as application/sql, while a failure is a JSON document with the error message
+and, when known, the row and column of the CSV file it is in. Requests larger
+than -max-bytes (10 MiB by default) are refused with status 413, and any taking
+longer than -timeout (30 seconds) to read and convert with status 503. Every
+request is a conversion of its own, so the server runs any number at the same
+time.
+
+	csv2sql serve -listen :8080 -max-bytes 1048576
+	curl -F lines=@lines.csv -F stations=@stations.csv 'localhost:8080/convert?dialect=postgres&strict=true'
+	curl --data-binary @stations.csv --url-query lines@lines.csv 'localhost:8080/convert?dialect=postgres'
+
+# WebAssembly
+
+So that station data never has to leave a browser, the program also builds for
+GOOS=js GOARCH=wasm. Instead of a command line it then defines a global
+convert(csvText, optionsJSON) function for JavaScript, loaded with the
+wasm_exec.js of the Go distribution. The first argument is the text of the
+stations CSV file, and the second a JSON object with the text of the rail lines
+CSV file as lines and any of the flags dialect, network-id, sort-stations,
+skip-rows, preserve-ids, bool-style, and strict. It returns the statements as a
+string, or an object with the error message and, when known, its row and
+column. No file is read or written, and as SQLite cannot be built for the
+browser -self-test and -sync are not available there.
+
+	GOOS=js GOARCH=wasm go build -o csv2sql.wasm
+	convert(stationsText, JSON.stringify({lines: linesText, dialect: "sqlite"}))
+
+# Ranging over the statements
+
+The convert function is built on Statements, which converts a stations CSV file
+read from an io.Reader with the same Options, lines and flags, and yields the
+statements one at a time as they are generated instead of building the whole
+script. Each comes with its text and, for the insert of a row, the table and the
+SQL literal of every column. Breaking out of the loop stops the conversion, and
+a failed conversion yields its error once as the last value. Every range is a
+conversion of its own, so any number can run at the same time. StatementsContext
+stops the conversion once its context is done, as the serve subcommand does with
+a request that times out. EscapeSQL and QuoteLiteral escape and quote a string
+literal of a Dialect the way the conversion does, for other tools writing SQL of
+their own.
+
+	for statement, err := range Statements(strings.NewReader(stationsText), Options{Lines: linesText, Dialect: "postgres"}) {
+		if nil != err {
+			return err
+		}
+		if "stations" == statement.Table {
+			fmt.Println(statement.Values["name"])
+		}
+	}
+
+# Statistics
+
+After generating the statements, -summary prints statistics about the network
+to Standard Error: the number of rail lines, stations, transfer stations (those
+on two or more lines) and links, the largest line, and a histogram of how many
+stations are on each line. The same statistics can be written as JSON to a file
+with -report. These are handy for a quick sanity check such as "Red should have
+27 stations".
+
+A station linked to the same rail line more than once would make the load fail
+on the primary key of LineStations, so such duplicate links are always dropped
+and their number is included in the statistics. So are the numbers of stations
+filtered out by name and by status, when those filters are given.
+
+	csv2sql -lines lines.csv -stations stations.csv -summary -report report.json > output.sql
+
+The statistics end with how long the conversion took, the number of CSV rows
+parsed per second while parsing, the number of statements generated per second
+while emitting them, and the bytes written out. With -metrics the time is also
+broken down into the setup, parse, prepare, and emit phases, and without
+-summary these timings alone are printed to Standard Error.
+
+	csv2sql -lines lines.csv -stations stations.csv -metrics > output.sql
+
+To see where the time of a slow conversion goes, -cpuprofile and -memprofile
+write pprof profiles of the CPU time and of the memory still in use at the end,
+and -trace writes an execution trace. They cover everything after the flags are
+parsed and are written even when the conversion fails. Look at them with go tool
+pprof or go tool trace:
+
+	csv2sql -lines lines.csv -stations big.csv -cpuprofile cpu.pprof -trace trace.out > output.sql
+	go tool pprof -top csv2sql cpu.pprof
+	go tool trace trace.out
+
+To eyeball what the tool thinks the network looks like before trusting the SQL,
+-preview prints a table of the stations against the rail lines they are on to
+Standard Error, with the number of stations on each line at the bottom. Only the
+first 12 rail lines get a column, or as many as given with -preview-lines. With
+-dry-run the statements are still generated, so that any errors are reported,
+but nothing is written to Standard Out.
+
+	csv2sql -lines lines.csv -stations stations.csv -preview -dry-run
+
+# Warnings
+
+Every data quality check belongs to a class: agencies (rail lines without one),
+bool-styles (see below), complexes (station complexes of a single station),
+coordinates, disconnected, distances, duplicates (repeated device serials, panel
+tags, and entrance names), empty-columns (columns without a name or any values,
+which are dropped, while a value under a column without a name is always an
+error), inconsistent (station fields that do not add up), names (see below),
+near-duplicates (see below), panels (active stations without an alarm panel),
+renames, shifted (a stations table shifted by one column), stale-columns,
+suspicious, sync, and unknown-stations (aliases and devices in stations that
+are not emitted). For CI, -fail-on-warning fails the conversion when any
+warning was raised, after all of them are listed but before anything is
+committed. Under time pressure -force takes a comma separated list of classes
+whose errors are raised as warnings instead, whether they are errors by default
+or with -strict. A device with a forced duplicate serial, or an alias or device
+of an unknown station, is then left out. Naming a class is more specific than
+either global switch, so -force wins over -strict and -fail-on-warning for the
+classes it names. The report lists every finding with its class and effective
+severity, "warning" or "forced".
+
+	csv2sql -lines lines.csv -stations stations.csv -devices devices.csv -strict -force duplicates
+
+Naming conventions can be checked with -name-pattern for the stations and
+-line-name-pattern for the rail lines, regular expressions that every name has
+to match once any prefixes, renames, and aliases have been applied. Either flag
+can be repeated, in which case a name has to match all of the patterns. Every
+name that fails is a warning of the names class, or an error with -strict,
+giving its row and the pattern it failed, which the report lists too.
+
+	csv2sql -lines lines.csv -stations stations.csv -name-pattern '^[A-Z][^ ]*( [A-Z][^ ]*)*$' -name-pattern '^[ -~]+$'
+
+Station names that are the same but for a typo or the kind of dash, like
+"Franconia-Springfield" and "Franconia–Springfield", are found with
+-fuzzy-duplicates, the most edits two names can be apart to be reported. Names
+are compared without accents, case, or differences in dashes and spacing,
+and only to names of a similar length, but the check still costs more the more
+stations there are, so it is off unless given. Every pair is a warning of the
+near-duplicates class giving both names and their rows, which is never merged.
+It runs along with the other checks with -dry-run.
+
+	csv2sql -lines lines.csv -stations stations.csv -fuzzy-duplicates 2 -dry-run
+
+A rail line column mixing spellings of its booleans, like "TRUE" and "FALSE"
+in some rows and "1" and "0" in others, often means that two people edited
+different rows and one of them may have inverted the meaning. Every such column
+is a warning of the bool-styles class, listing each style seen with the first
+row it was seen in. With -bool-consistency file the styles are compared across
+all rail line columns instead, and with -bool-consistency off not at all. The
+booleans are parsed the same either way.
+
+# Networks
+
+For a database holding several transit systems, -network emits a row in the
+Networks table with the given name (and the ID given by -network-id, 1 by
+default) before the rail lines, and adds a network_id column to every RailLines
+and Stations insert. With -network-links the LineStations inserts get one too.
+When merging networks, -merge-networks instead emits one Networks row for each
+merged network, named after its label and numbered on from -network-id.
+
+	csv2sql -lines lines.csv -stations stations.csv -network WMATA
+
+The agencies running the rail lines can be kept in a CSV file of their own
+given with -agencies, with the name of a rail line, its agency, and optionally
+a contact for the agency on each row. Every agency is emitted to an Agencies
+table with IDs in the order they are first seen, along with its contact as a
+"contact" row of AgencyAttributes, which is the first one given for it, and
+every RailLines insert gets an agency_id column. A rail line missing from the
+file gets a NULL agency and a warning of the agencies class (or an error with
+-strict), while a row naming a rail line that is not in the lines CSV is an
+error, as it usually means the rail line was renamed. Rail line names in the
+file get the prefix of -prefix-lines like those of the lines CSV.
+
+	Input (agencies.csv)
+		Line,Agency,Contact
+		Red,WMATA,ops@wmata.example
+
+Names drift over time ("Largo Town Center" became "Downtown Largo"), so rather
+than editing CSV files owned by someone else, -rename-map names a CSV file of
+renames, with the kind of name ("line" or "station"), the old name, and the new
+name on each row. The renames are applied right after the names are read and
+before the prefixes below, so every other option, such as the filters and the
+devices table, sees the new names. A rename that matches nothing gets a warning
+(or an error with -strict) as the map is likely stale, and one that gives a rail
+line or station the name of another one is an error.
+
+	Input (renames.csv)
+		Kind,Old,New
+		station,Largo Town Center,Downtown Largo
+
+Without a Networks table, the names can be disambiguated instead by prefixing
+every station name with -prefix-stations and every rail line name with
+-prefix-lines. The prefixes are added right after the names are read, so they
+are part of the names seen by every other option, such as the filters, except
+that the station names in the devices table are prefixed the same way.
+
+	csv2sql -lines lines.csv -stations stations.csv -prefix-stations "DC: "
+
+# Anonymizing
+
+To share a dataset with the same structure but without the real names, such as
+with a vendor, -anonymize replaces the name of every rail line with a letter
+("Line A", "Line B", ...), every station with its ID ("Station 001", ...), and
+every alarm zone with its ID ("Zone 1", ...). The IDs and which stations are on
+which rail lines are exactly the same as without it, and since the labels only
+depend on the IDs they are the same on every run. The real names and their
+labels can be written to a CSV file with -anonymize-map, which should of course
+not be shared along with the output.
+
+The attributes and additional Stations columns (like exit_count) are dropped by
+default. With -anonymize-columns hash the values of the attributes and of the
+text columns (like status) are replaced by the start of their SHA-256 hash
+instead, so that equal values stay equal without revealing what they are, while
+the numeric and boolean columns are still dropped since a hash is not a valid
+value for them. The hash is not salted though, so short values like a status
+are easily recovered.
+
+	csv2sql -lines lines.csv -stations stations.csv -anonymize -anonymize-map names.csv > shared.sql
+
+# GeoJSON
+
+For web maps, -format geojson writes the network as a GeoJSON FeatureCollection
+instead of SQL statements, which requires latitude and longitude columns in the
+stations table. Every station is a Point feature, in ID order, with its ID,
+name, and the names of its rail lines as properties. With -bool-style sequence
+every rail line is also a LineString feature through its stations in order,
+with its ID, name, and color as properties. Positions are longitude first, as
+the GeoJSON specification requires. A station without coordinates is left out
+with a warning, or is an error with -strict.
+
+	csv2sql -lines lines.csv -stations stations.csv -format geojson -output network.geojson
+
 # CSV Format
 
 The "lines" table should list all of the lines in the train network followed
@@ -59,167 +592,3219 @@ a boolean. The first columns for each table is assumed to be the name of the
 line or station, the actual value in the header for the first column is
 ignored. See wmata/ for an actual example.
 
-The boolean literal must be a valid option that can be parsed by
-[strconv.ParseBool]. As of this writing that is false: 0, f, F, false, False,
-FALSE and true: 1, t, T, true, True, TRUE.
+A stations CSV with a header but no station records is almost always a broken
+export, so it is an error unless -allow-empty is given, in which case only the
+rail lines are emitted. A file without even a header is always an error.
+
+Spreadsheets often pad the header with empty cells at its end without padding
+the records. Such cells are trimmed from the header of either table, logging
+how many, and each record may then be as wide as the header either with or
+without them, as long as the cells past the trimmed header are empty. An empty
+header cell before the last named one is not trimmed.
+
+A data record mistaken for the header, such as a blob with thousands of commas,
+would otherwise become thousands of rail lines. A stations header with more
+rail line columns than -max-lines (500 by default) is therefore an error before
+anything is written, showing the first few of the supposed rail line names.
+Give a higher -max-lines for a network that really is that big, or 0 for no
+limit.
+
+Files that are separated by tabs, semicolons, or pipes instead of commas are
+detected from their header and first few records: the delimiter splitting the
+header into the most columns wins, provided the records have as many, and is
+logged when it is not a comma. When two delimiters split the header equally the
+file is an error listing them rather than a guess. -delimiter gives the
+delimiter of every file instead, as a single character or "tab".
+
+	csv2sql -lines lines.tsv -stations stations.tsv -delimiter tab
+
+The character encoding of each file is detected too. A byte order mark picks
+UTF-8 or UTF-16, as do the zero bytes of ASCII characters in UTF-16 without one.
+Otherwise text that is valid UTF-8 is read as it is, and any other is decoded as
+Windows-1252 if it has bytes from 0x80 to 0x9F, where Windows-1252 puts curly
+quotes and dashes, or as ISO-8859-1 if not. Any encoding other than plain UTF-8
+is logged along with why it was picked, and -encoding gives the encoding of
+every file instead: utf-8, utf-16le, utf-16be, windows-1252, or iso-8859-1. A
+file read as UTF-8 that turns out to be invalid further on is an error giving
+the byte offset, so that no invalid UTF-8 ends up in the statements.
+
+	csv2sql -lines lines.csv -stations export.csv -encoding windows-1252
+
+Any of the CSV files can be read from Standard In by naming it "-", and can be
+compressed with gzip. Rather than silently waiting for input when Standard In is
+a terminal, the usage is printed and the exit code is 2, unless -stdin is given
+to type the CSV file in by hand. Compression is detected from a name ending in
+".gz" or the gzip magic bytes at the start of the file, which -compression gzip
+or -compression none overrides. A corrupt gzip stream is reported as such rather
+than as a malformed CSV record.
+
+	csv2sql -lines lines.csv -stations stations.csv.gz
+	curl -s https://example.com/stations.csv.gz | csv2sql -lines lines.csv -stations -
+
+The CSV files can also be fetched directly from an http:// or https:// URL,
+with any extra request headers given by repeating -header. A response other than
+200 OK is an error showing the status and the start of the body. At most five
+redirects are followed, fetching each file must finish within -http-timeout (30
+seconds by default), and a file longer than -max-download-bytes (100 MiB by
+default) is an error.
+
+	csv2sql -lines lines.csv -stations https://example.com/stations.csv -header "Authorization: Bearer $TOKEN"
+
+To convert just a window of the stations table, such as when debugging a
+failure deep into a large file, -skip-rows skips the given number of station
+records after the header and -limit-rows stops after converting the given number
+of records. The skipped records are not checked for errors unless
+-validate-skipped is given. By default the first converted station gets the ID
+1, while with -preserve-ids every station keeps the ID it would have had without
+skipping. Negative numbers of rows are an error. When merging networks, each
+stations table is windowed the same way.
+
+For small test fixtures, -sample picks the given number of stations at random
+(after any filtering) while keeping every rail line. The stations keep their
+original order and get IDs without gaps. The same -seed always picks the same
+stations, which are listed on Standard Error and in the summary.
+
+	csv2sql -lines lines.csv -stations stations.csv -sample 10 -seed 42
+
+Since a misaligned stations table is otherwise silently converted, the name of
+its first header cell can be checked with -require-header. Even without it, a
+warning is given when the first header cell is the name of a rail line and the
+first station is named like a boolean, a telltale sign of the columns being
+shifted by one. A rail line column that is false for every station gets a
+warning too (or an error with -strict), as the column is either stale or its
+booleans are inverted.
+
+For a small network a wide table of booleans is harder to keep up to date than
+a list, so -stations-format list takes a stations table whose second column
+lists the rail lines of each station separated by semicolons instead. Whitespace
+around the names is trimmed and a rail line named twice in a cell counts once.
+The rail lines are numbered in the order they are first mentioned, which must be
+the order of the lines table, and a station with an empty list is on no rail
+lines. Any further columns, like attributes, follow the rules below. With
+-stations-format pairs the table has just two columns and every row gives a
+station and one of its rail lines, with a station on several rail lines taking
+several rows. Either way the output is exactly that of the equivalent table of
+booleans.
+
+	Input (stations.csv)
+		Station,Lines
+		Foo,Ruby
+		Bar,Ruby; Emerald
+
+By default the shape is detected from the header and first few records. Rail
+lines separated by semicolons make a list, and otherwise more than two columns
+make a table of booleans and two columns make pairs, with any shape other than a
+table of booleans logged. Two columns whose second column could be the booleans
+or positions of a single rail line are an error asking for -stations-format
+rather than a guess.
+
+Exports from data platforms can give the stations as Parquet instead, read
+with -input-format parquet. The file must have either the wide schema of a
+string station column followed by a BOOLEAN column for every rail line (or INT32
+or INT64 positions with -bool-style sequence), or the long schema of two string
+columns giving a station and one of its rail lines. These become the table of
+booleans and the pairs above, with any other column type an error naming the
+column and its physical type. The rows are read a few hundred at a time, one
+row group after another, so memory stays bounded however big the file is. The
+rail lines are still read from a CSV file, and as Parquet is read from the end
+the file must be a local one.
+
+	csv2sql -lines lines.csv -stations stations.parquet -input-format parquet
+
+Columns of the stations table whose header starts with '@' are not rail lines
+but attributes of the station, like "@district" or "@hydrant". Every non-empty
+cell in such a column becomes a row in the StationAttributes table holding the
+station ID, the header without the '@' as the key, and the cell as the value.
+Attribute columns are skipped over when numbering the rail lines.
+
+Columns whose header starts with '!', like "!notes" or "!Silver", are ignored
+entirely and skipped over as well, so that a stations table can carry columns
+for people without any flags. The '!' wins over everything else: "!zone" is
+ignored even when -zone-column names it, and "!@district" is not an attribute.
+Apart from that, columns named by a flag or by a fixed name (the alarm zone
+column, "aliases", "complex", "panels", "name:<lang>", and the additional
+Stations columns below) are what their name says, and only then does '@' make
+an attribute, whose key never includes the '@'.
+
+	Input (stations.csv)
+		Station,Ruby,@district,Emerald
+		Foo,1,North,0
+
+	Output
+		INSERT INTO Stations VALUES (1, 'Foo');
+		INSERT INTO LineStations VALUES (1, 1);
+		INSERT INTO StationAttributes VALUES (1, 'district', 'North');
+
+Some column names of the stations table are not rail lines but fill additional
+columns of the Stations table, all of which are nullable columns of setup.sql.
+When any are present, each station insert lists its columns explicitly and blank
+cells become NULL.
+
+  - exits: positive integer number of exits, stored as exit_count
+  - evac_capacity: positive integer evacuation capacity
+  - capacity: positive integer rated occupant capacity, which must not exceed
+    -max-capacity (1,000,000 by default) so that an extra zero is caught
+  - elevators: non-negative integer number of elevators
+  - escalators: non-negative integer number of escalators
+  - accessible: boolean, emitted as TRUE or FALSE
+  - underground: boolean, read like the rail line columns and emitted as TRUE or
+    FALSE
+  - status: one of open, closed, planned, or construction whatever its case,
+    emitted in lower case, with the allowed statuses given by -status-values
+    instead. A blank status is -status-default ("open" by default), or NULL
+    when that is empty. An invalid status is an error listing those allowed.
+  - latitude (or lat): decimal degrees from -90 to 90
+  - longitude (or lon): decimal degrees from -180 to 180
+
+A transfer station (one on two or more rail lines) with only a single exit gets
+a warning on Standard Error since that is exactly what evacuation planning needs
+to know about. An accessible station with no elevators gets a warning too, as
+does an underground station without a "@depth" attribute, which fire response
+pre-plans for underground stations need.
+
+Other names a station is known by can be given in a column named "aliases",
+separated by semicolons. Each becomes a row in the StationAliases table with the
+station ID and the alias. Aliases maintained separately can be given with
+-aliases naming a CSV file with the name of the station and an alias on each
+row, where station names are matched like those of the devices table below and
+naming a station that is not being emitted is an error. Both can be used
+together, with the aliases file winning when it gives an alias to a different
+station than the column does. Repeated aliases of a station are dropped.
+
+	Input (stations.csv)
+		Station,Ruby,Aliases
+		Foo,1,Foo Street;Old Foo
+
+	Output
+		INSERT INTO Stations VALUES (1, 'Foo');
+		INSERT INTO LineStations VALUES (1, 1);
+		INSERT INTO StationAliases VALUES (1, 'Foo Street');
+		INSERT INTO StationAliases VALUES (1, 'Old Foo');
+
+Names of a station in other languages, such as for bilingual signage, can be
+given in columns named "name:" followed by a BCP 47 language tag, like
+"name:es" or "name:fr". Each non-empty cell becomes a row in the StationNames
+table with the station ID, the language tag, and the translated name, while the
+first column stays the name in the Stations table. A language tag that is not
+valid BCP 47 is an error.
+
+	Input (stations.csv)
+		Station,Ruby,name:es
+		Foo,1,Calle Foo
+
+	Output
+		INSERT INTO Stations VALUES (1, 'Foo');
+		INSERT INTO LineStations VALUES (1, 1);
+		INSERT INTO StationNames VALUES (1, 'es', 'Calle Foo');
+
+The optional devices table, given with -devices, lists the alarm devices in the
+stations with one row per device: the name of the station it is in, its type,
+and its serial number. Each row becomes a row in the Devices table after all of
+the stations, with IDs starting at 1. Station names are matched after trimming
+whitespace, and naming a station that is not being emitted or repeating a serial
+number is an error.
+
+	Input (devices.csv)
+		Station,Type,Serial
+		Foo,Smoke Detector,SD-0001
+
+	Output
+		INSERT INTO Devices VALUES (1, 1, 'Smoke Detector', 'SD-0001');
+
+The optional entrances table, given with -entrances, lists the street entrances
+of the stations with one row per entrance: the name of the station it leads to,
+its name, its latitude and longitude in decimal degrees, and whether it is only
+for emergencies (blank for no). Each row becomes a row in the Entrances table
+after all of the stations, with IDs starting at 1. Station names are matched
+like those of the devices table, and invalid coordinates are an error. Two
+entrances of the same station with the same name are a warning of the
+duplicates class (or an error with -strict).
+
+	Input (entrances.csv)
+		Station,Entrance,Latitude,Longitude,Emergency Only
+		Foo,North,38.8977,-77.0365,false
+		Foo,Tunnel,38.8971,-77.0360,true
+
+	Output
+		INSERT INTO Entrances VALUES (1, 1, 'North', 38.8977, -77.0365, FALSE);
+		INSERT INTO Entrances VALUES (2, 1, 'Tunnel', 38.8971, -77.036, TRUE);
+
+The alarm zone each station belongs to can be given in a column named "zone",
+or any other name given with -zone-column to avoid clashing with a fare zone
+column. Each distinct zone becomes a row in the AlarmZones table, numbered in
+the order the zones are first seen, and each station insert gets a zone_id
+column (NULL for a blank cell). With -zone-links the stations are linked to
+their zones through rows in the StationZones table instead.
+
+	Input (stations.csv)
+		Station,Ruby,Zone
+		Foo,1,North
+
+	Output
+		INSERT INTO AlarmZones VALUES (1, 'North');
+		INSERT INTO Stations (id, name, zone_id) VALUES (1, 'Foo', 1);
+		INSERT INTO LineStations VALUES (1, 1);
+
+Stations that are physically connected, like separate platforms counted as one
+station for evacuations, can be grouped into a station complex named in a
+column named "complex". Each distinct complex becomes a row in the Complexes
+table, numbered in the order the complexes are first seen, and each station
+insert gets a complex_id column (NULL for a blank cell). A complex of a single
+station is a warning of the complexes class (or an error with -strict), since
+its name is likely a typo of another, and the summary lists every complex with
+its number of stations.
+
+	Input (stations.csv)
+		Station,Ruby,Complex
+		Foo North,1,Foo
+		Foo South,1,Foo
+
+	Output
+		INSERT INTO Complexes VALUES (1, 'Foo');
+		INSERT INTO Stations (id, name, complex_id) VALUES (1, 'Foo North', 1);
+		INSERT INTO Stations (id, name, complex_id) VALUES (2, 'Foo South', 1);
+
+The asset tags of the fire alarm panels of each station can be given in a
+column named "panels", separated by semicolons. Each becomes a row in the
+Panels table with an ID counting up from 1 across all of the stations, the
+station ID, and the tag, and the summary gives the total number of panels. A
+tag given to two stations is an error naming both, while an active station (one
+without a status or with the status open) without a panel is a warning of the
+panels class (or an error with -strict).
+
+	Input (stations.csv)
+		Station,Ruby,Panels
+		Foo,1,FACP-01;FACP-02
+
+	Output
+		INSERT INTO Stations VALUES (1, 'Foo');
+		INSERT INTO LineStations VALUES (1, 1);
+		INSERT INTO Panels VALUES (1, 1, 'FACP-01');
+		INSERT INTO Panels VALUES (2, 1, 'FACP-02');
+
+The boolean literal must be a valid option that can be parsed by
+[strconv.ParseBool]. As of this writing that is false: 0, f, F, false, False,
+FALSE and true: 1, t, T, true, True, TRUE.
+
+When setup.sql has drifted from the tables the inserts are written for, such as
+by adding columns or changing their order, the schema can be given with
+-schema-file. Its CREATE TABLE statements are read for the names and order of
+the columns of each table, and for which of them are NOT NULL or have a DEFAULT.
+Every insert then lists its columns explicitly in the order of the schema, with
+NULL for columns without a value unless they have a DEFAULT. Before anything is
+emitted it is an error for an insert to be into a table or column that is not
+in the schema, or to leave out a NOT NULL column without a DEFAULT.
+
+	csv2sql -lines lines.csv -stations stations.csv -schema-file setup.sql
+
+How the columns without a value are left out can be chosen with -pad-nulls.
+With -pad-nulls columns each insert lists only the columns it has a value for,
+leaving every other column to the database, while with -pad-nulls positional it
+lists no columns at all and gives every column of the table a value in the order
+of the schema, NULL or DEFAULT for those it has none for, for tools that only
+take positional values. SQLite does not allow DEFAULT in VALUES, so there, and
+with -self-test, a column with a DEFAULT but no value is an error.
+
+	Schema (schema.sql)
+		CREATE TABLE Stations (id INTEGER PRIMARY KEY, name TEXT NOT NULL, opened DATE, status TEXT DEFAULT 'open');
+
+	Output with -pad-nulls columns
+		INSERT INTO Stations (id, name) VALUES (1, 'Foo');
+
+	Output with -pad-nulls positional
+		INSERT INTO Stations VALUES (1, 'Foo', NULL, DEFAULT);
+
+To catch the inserts and setup.sql drifting apart in the first place, such as in
+CI on every change to the data, -check-schema reads the CREATE TABLE statements
+of the given file like -schema-file but only checks the inserts against them
+instead of adapting the inserts. Every table and column the inserts use must be
+in it and every NOT NULL column without a DEFAULT filled, and a table filled
+without a column list must have exactly the columns given values, in the same
+order. Any difference fails before anything is written, describing it.
+
+	csv2sql -lines lines.csv -stations stations.csv -check-schema setup.sql > output.sql
+
+# Escaping dangerous character for SQL injection
+
+Csv2sql will escape NULL and single quote for string literals inside of SQL
+statements. Other characters like backslash can also be dangerous for certain
+databases like MySQL or MariaDB. By default this implementation is kept minimal
+to standard SQL to maximize compatibility, and with -dialect mysql backslashes
+are doubled as well. The sqlite dialect escapes the same as standard SQL.
+
+Extra escaping rules can be added without changing the source with -escape
+'FROM=TO', which can be repeated, or with -escape-file naming a file with one
+FROM=TO rule per line (blank lines and lines starting with '#' are skipped).
+FROM and TO may use \xNN and \uNNNN for characters that are awkward to type,
+and \\ for a backslash. The rules are applied after the built-in ones, first
+those from the file in order and then those from the flags in order, and are
+logged by -verbose. For example, to double backslashes and strip DEL:
+
+	csv2sql -lines lines.csv -stations stations.csv -escape '\\=\\\\' -escape '\x7F='
+
+Since the rules are applied to the already escaped string, a rule that puts a
+single quote back in can break the quoting.
+
+Rather than reading the whole script to review the escaping, -audit-escapes
+lists every value that escaping changed at the end, on Standard Error and in the
+report given by -report, with the row it came from, what it is, and the value
+before and after. Cells that had NUL characters stripped or replaced while
+parsing are listed first and called out, since information was lost.
+
+	csv2sql -lines lines.csv -stations stations.csv -audit-escapes > output.sql
+
+# Templates
+
+When the statements need a slightly different shape, such as another table, an
+extra constant column, or REPLACE INTO, the inserts of the rail lines, stations,
+and links can be replaced with Go text/template templates given by
+-template-line, -template-station, and -template-link. Rail line templates get
+{{.ID}}, {{.Name}}, {{.Red}}, {{.Green}}, and {{.Blue}}, station templates
+{{.ID}} and {{.Name}}, and link templates {{.LineID}} and {{.StationID}}. Names
+are already escaped for a string literal of the -dialect and -string-style, but
+not quoted, so with -string-style estring the template quotes them as
+E'{{.Name}}'. The dollar string style cannot be used with templates, as its
+quotes depend on the name. Longer templates can be kept in a file given by
+-template-file that defines any of the templates "line", "station", and "link"
+with {{define}}, where those given directly win. A template that does not parse
+is an error before anything is read, and one that fails to execute is an error
+naming the row.
+
+Apart from escaping the names, templates bypass the dialect, string style,
+schema, and additional columns entirely, so the user owns the correctness of
+the statements they produce.
+
+	csv2sql -lines lines.csv -stations stations.csv -template-station "REPLACE INTO Stations VALUES ({{.ID}}, '{{.Name}}');"
+
+# PostgreSQL
+
+With -dialect postgres the string literals can be written in a style that does
+not depend on the standard_conforming_strings setting with -string-style. The
+"dollar" style quotes them with a tag instead ($csv$O'Brien$csv$), numbering the
+tag ($csv1$, $csv2$, ...) when the string would otherwise contain it. The
+"estring" style uses E'...' strings with backslashes escaped. The default
+"standard" style is the same as without a dialect. Any extra escaping rules are
+still applied and NUL characters are still stripped in every style.
+
+With -postgis the latitude and longitude columns of the stations table become a
+single PostGIS point in the column given by -geometry-column ("geom" by
+default) instead of two numbers, in WGS 84 (SRID 4326). Note that a point takes
+the longitude first. A station with both blank gets a NULL point, and a station
+with only one of them is an error. The coordinates are checked to be in range
+either way.
+
+	Input (stations.csv)
+		Station,Ruby,latitude,longitude
+		Foo,1,38.8977,-77.0365
+
+	Output
+		INSERT INTO Stations (id, name, geom) VALUES (1, 'Foo', ST_SetSRID(ST_MakePoint(-77.0365, 38.8977), 4326));
+
+Before anything is emitted, the IDs about to be emitted are checked so that no
+primary key is used twice and every reference, like the rail line and station of
+each LineStations row, is to a row that is emitted too. With -self-check the
+same is checked again on the text of the statements themselves, parsing them
+back before each COMMIT, so that a bug in generating them aborts the transaction
+instead of surfacing at load time.
+
+A bug in generating the statements, like an escaping mistake leaving a quote
+open, would otherwise only show when loading them. With -check-syntax, which is
+on by default with -strict, every statement is checked before it is written: its
+string literals, quoted identifiers, dollar quotes, and comments must be closed,
+its parentheses balanced, and it must end with a semicolon. A statement failing
+the check is an error giving the statement and the row it came from.
+
+The strongest check is actually running the statements. With -self-test they are
+executed against an in-memory SQLite database with the tables of setup.sql,
+which is built in, with only the optional columns the conversion fills, or with
+the tables of the -schema-file, and every table is then checked to hold as many
+rows as planned, all before anything is written out. A statement SQLite rejects
+is an error giving its message, the statement, and the row it came from. The
+postgres and mysql dialects are not understood by SQLite, so the self-test is
+skipped with a notice for them, and the row counts are not checked when
+templates are in use.
+
+Some databases and executors reject statements over a certain size, like MySQL
+beyond its max_allowed_packet. With -max-statement-bytes every statement is
+checked against the given limit and one that is too long, such as for a station
+with a pathologically long name, is an error naming the row it came from.
+
+Line feeds end every emitted line by default. For tooling that expects Windows
+line endings, -newline crlf ends them with a carriage return and line feed
+instead, including BEGIN and COMMIT. Line feeds inside string literals are part
+of the values and are kept as they are. Canonical output always uses line feeds.
+
+Some executors, like golang-migrate, already run the script in a transaction of
+their own and break on a nested BEGIN. With -no-transaction the statements are
+emitted without BEGIN, COMMIT, or ROLLBACK. Databases that prefer another
+keyword to start a transaction can be given it with -begin-keyword, such as
+-begin-keyword "START TRANSACTION".
+
+Because a script of thousands of statements is not really reviewable, values
+that look like an injection attempt are listed as warnings on Standard Error
+(and in the summary and report) with the row they came from: those containing a
+semicolon, a comment marker ("--" or "/*"), or DROP, DELETE, or UPDATE followed
+by a space. This is only a lint to point a human at the rows worth looking at,
+the values are never modified. It can be turned off with -warn-suspicious=false,
+or the warnings made errors with -strict.
+
+NUL characters are silently stripped by default, which can hide the fact that a
+CSV file is corrupt. With -nul error any NUL character is an error giving the
+row and column of the cell it is in, while -nul replace substitutes a visible
+U+FFFD replacement character (or -nul 'replace=<char>' for any other, using the
+same escapes as the escaping rules).
+
+# Bulk loading
+
+With -bulk-load and -dialect mysql or sqlite, the checks that slow down loading
+a large network are turned off before the data and back on after it, even if
+generating the data failed part way. For MySQL that is unique_checks and
+foreign_key_checks, with the tables also locked for writing under
+-no-transaction since starting a transaction would release the locks. For SQLite
+synchronous is turned off. Both are unsafe while anyone else is using the
+database, which a comment in the output repeats. The flag is an error with the
+other dialects.
+
+	csv2sql -lines lines.csv -stations stations.csv -dialect mysql -begin-keyword 'START TRANSACTION' -bulk-load
+
+With -assert-counts the script checks itself: after the data it counts the rows
+of every table it inserted into and fails when the count differs from the number
+of rows generated. SQLite has no RAISE outside triggers, so its checks fail on a
+JSON path made of the message, while PostgreSQL raises an exception from a DO
+block. MySQL and the standard dialect get a SELECT of each count after a comment
+with the expected number. The counts are minimums when the tables may already
+hold other rows, as with -link-by-name, -db-ids, -preserve-ids after skipped
+records, or a -network-id other than 1. The flag cannot be used with templates
+or -sync.
+
+	-- Row counts the statements were expected to load
+	SELECT CASE WHEN (SELECT COUNT(*) FROM LineStations) <> 3 THEN json_extract('{}', 'Expected 3 rows in LineStations') END;
+
+Nothing stops the same script from being run twice, so -mark-import guards
+against it. Before the data the script creates an ImportLog table if it is
+missing and checks it for a SHA-256 hash of the rows being loaded, which is the
+same for the same data however its CSV files are laid out, and after the data it
+records the hash with the time and the numbers of rail lines, stations, and
+links. The check is a hard guard in SQLite and PostgreSQL, failing the same way
+as -assert-counts before any data is written (run sqlite3 with -bail so that it
+stops there). MySQL could only fail with SIGNAL from a stored program, so there
+and in the standard dialect it is only a SELECT of the earlier import after a
+comment, and a second run is only stopped by the primary key of the hash once
+its data is already in. The flag cannot be used with -sync.
+
+	csv2sql -lines lines.csv -stations stations.csv -dialect postgres -mark-import
+
+Since nearly every consumer of the tables joins them the same way, -with-views
+creates two views after the data: StationLines, with a row of the station and
+rail line IDs and names for every station on every rail line, and
+LineStationCounts, with the number of stations on each rail line. SQLite keeps
+an existing view of the same name, PostgreSQL and MySQL replace it, and the
+standard dialect has neither, so its CREATE VIEW fails if the view is already
+there. With -self-test both views are queried and checked for a row for every
+link and every rail line. The flag cannot be used with -sync.
+
+	csv2sql -lines lines.csv -stations stations.csv -dialect sqlite -with-views
+
+Maintaining indexes row by row is also slow, so -indexes-after-data drops the
+indexes created by the CREATE INDEX statements of the schema, -schema-file or
+else setup.sql, before the data and creates them again after it, even if
+generating the data failed part way. A comment in the output names the indexes
+moved. Primary keys and other constraints of the tables stay in place.
+
+	csv2sql -lines lines.csv -stations stations.csv -schema-file schema.sql -indexes-after-data
+
+# Checkpoints
+
+The statements can be written to a file given by -output instead of Standard
+Out. For a conversion large enough that a crash would waste real time,
+-checkpoint names a state file that is brought up to date between two stations
+once another -checkpoint-interval statements have been synced to the output. It
+records the number of statements and bytes written, the byte offsets in the
+stations CSV of the end of its header and of the record of the last station
+written, with that record's row, the ID of the next station, and the number of
+links of each rail line so far, along with the size and a hash of the start of
+every input file and a hash of the flags. After a crash, giving the same flags
+again with -resume cuts the output back to the length it had at the checkpoint
+and continues from there, so that no station is written twice or skipped. The
+rail lines and the statements before the first station are generated again, but
+the stations CSV is read past the recorded offset without parsing the records
+before it, and the stations after it get IDs from the next station ID on. The
+checks of the whole network, which passed before the checkpoint, are not made
+again. The input files are an error if they changed, as are different flags, a
+checkpoint of another format version, or statements no longer lining up with the
+recorded progress. The checkpoint is removed once the conversion finishes.
+Inputs must be local files, the stations CSV must be given -stations-format
+matrix, and checkpoints cannot be used with -self-test, -dry-run, or -sync, nor
+with anything that needs every station: -gtfs, -merge, -input-format parquet,
+-sort-stations name, -sample, -limit-rows, -rejects, -group-by-table, -devices,
+-entrances, -aliases, -emit-connections, -anonymize, -self-check,
+-assert-counts, -mark-import, -summary, -report, or a stations CSV with alarm
+zones, station complexes, or alarm panels.
+
+	csv2sql -lines lines.csv -stations stations.csv -output output.sql -checkpoint output.checkpoint
+	csv2sql -lines lines.csv -stations stations.csv -output output.sql -checkpoint output.checkpoint -resume
+
+# Upserts
+
+To load into tables that may already hold some of the rows, -upsert merge writes
+every row as a MERGE on the primary key of its table instead of an insert,
+updating the other columns of a row that is already there and inserting it
+otherwise. Links, whose columns are all in the key, are only inserted when
+missing, matching on both of them. The rows are in a VALUES table in the
+standard, postgres (PostgreSQL 15 and later), and mssql (SQL Server) dialects,
+and selected FROM dual in the oracle dialect. It cannot be used with
+-schema-file, -link-by-name, -db-ids, -sync, -self-test, -self-check, or
+-sqlite-out.
+
+	MERGE INTO Stations AS target USING (VALUES (1, 'O''Brien')) AS src (id, name) ON target.id = src.id WHEN MATCHED THEN UPDATE SET name = src.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (src.id, src.name);
+	MERGE INTO Stations target USING (SELECT 1 AS id, 'O''Brien' AS name FROM dual) src ON (target.id = src.id) WHEN MATCHED THEN UPDATE SET name = src.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (src.id, src.name);
+
+Each row is a MERGE of its own by default. With -batch-size up to that many
+consecutive rows of the same table go in one MERGE, so batches are largest with
+-group-by-table, and a batch is cut short where the next row would make it
+longer than -max-statement-bytes. It cannot be used with -checkpoint. The
+transactions of the mssql and oracle dialects begin with BEGIN TRANSACTION and
+SET TRANSACTION READ WRITE unless -begin-keyword is given, as BEGIN starts a
+block in both. Their inserts are otherwise those of the standard dialect, with
+booleans as TRUE and FALSE.
+
+	csv2sql -lines lines.csv -stations stations.csv -dialect mssql -upsert merge -batch-size 100 -group-by-table
+
+# SQLite databases
+
+For demos and tests, -sqlite-out creates a SQLite database file ready to open
+instead of writing a script. The tables of setup.sql, or of -schema-file, are
+created first, and then every statement is executed in a single transaction, so
+the BEGIN and COMMIT of each group are left out. An existing file is an error
+unless -overwrite is given, and when anything fails the transaction is rolled
+back and the partial file removed. Only the standard and sqlite dialects can be
+used, and not -output, -sync, -self-test, -dry-run, -filter-cmd, -bulk-load, or
+-format geojson.
+
+	csv2sql -lines lines.csv -stations stations.csv -sqlite-out network.db -with-views
+
+# Chunks
+
+For tooling that limits the size of a SQL file, -chunk-statements and
+-chunk-bytes split the statements into files of at most that many statements or
+bytes, named after -output with a number: prefix_001.sql, prefix_002.sql, and so
+on. A file only ends before a station or between transactions, so a station is
+never split from its links and the rail lines all go in the first file. A
+transaction cut in two is committed at the end of one file and begun again at
+the start of the next, so every file can be run on its own, in order. Anything
+that cannot be split and does not fit in a file of its own, like a station with
+more links than -chunk-statements, is an error. Once everything is written,
+prefix_manifest.txt lists the files in order, each with its number of statements
+after a tab. With -bulk-load the statements turning the checks off start the
+first file and those turning them back on end the last, so the files are meant
+to be run in one session. Chunks cannot be used with -checkpoint, -sync,
+-dry-run, -filter-cmd, -sqlite-out, -no-transaction, -group-by-table, or -format
+geojson.
+
+	csv2sql -lines lines.csv -stations stations.csv -output network -chunk-bytes 5000000
+
+# Filter commands
+
+For rewriting the statements in ways no flag covers, -filter-cmd pipes them
+through a shell command, started once for the whole conversion. Every
+statement is written to its Standard In on a line of its own, and whatever it
+writes to Standard Out goes to -output or Standard Out in place of the
+statements. The command sees the final text, after escaping and templates, so
+a rewrite has to match the values as they are quoted in the SQL. Its output is
+copied while the statements are still being written, so a command is free to
+write as it reads. The conversion fails when the command exits with a non-zero
+status. Checks like -self-test and -check-syntax see the statements before the
+filter. It cannot be used with -checkpoint, -sync, or -format geojson.
+
+	csv2sql -lines lines.csv -stations stations.csv -filter-cmd "sed 's/^INSERT INTO/INSERT OR IGNORE INTO/'"
+*/
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Returned by [run] once the usage has been printed for an invalid command line.
+var errUsage = errors.New("Invalid usage")
+
+// Error of a record of a CSV file, giving the row of the file the record starts
+// on and the column of the cell at fault, so callers need not read them from
+// the message.
+type rowError struct {
+	row    int
+	column int // Column counted from 1, or 0 when the error is of the whole record
+	err    error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rowError) Unwrap() error {
+	return e.err
+}
+
+// Settings and state of a single conversion, set from the flags by
+// [conversion.run], so that nothing carries over from one conversion to the next
+// and conversions can run at the same time.
+type conversion struct {
+	dialect     string       // SQL dialect: "standard", "postgres", "mysql", "sqlite", "mssql", or "oracle"
+	stringStyle string       // How string literals are written: "standard", or for postgres "dollar" or "estring"
+	escapeRules []escapeRule // Extra escaping rules from the command line, applied in order
+	nul         nulPolicy    // Policy for the NUL characters in every CSV file, with the cells it changed
+
+	delimiter        rune                 // Delimiter of the CSV files, or 0 to detect it in each file
+	comment          rune                 // Character starting the comment lines of the CSV files, or 0 for none
+	compression      string               // How the CSV files are compressed: "auto", "gzip", or "none"
+	inputEncoding    string               // Character encoding of the CSV files: "auto", or one of [encodings]
+	httpTimeout      time.Duration        // Time limit for fetching each CSV file from a URL
+	httpHeaders      []string             // Extra request headers as "Name: value"
+	maxDownloadBytes int64                // Largest CSV file in bytes to fetch from a URL
+	inlineInputs     map[string]io.Reader // Readers of the CSV files given to [Statements], keyed by their paths without the [inlinePrefix]
+
+	logger        *log.Logger // Where warnings and notices go, the Standard Error of the run
+	warnings      []string    // Every warning raised so far, in the order they were raised
+	findings      []finding   // Every finding raised so far as a warning, with its effective severity
+	forcedClasses []string    // Classes of findings that are errors, by default or with -strict, turned into warnings by -force
+	failOnWarning bool        // Whether any warning not forced by -force fails the conversion before the data is committed
+
+	duplicateLinks int // Rail lines given more than once for a station in the list or pairs formats, dropped while reading them
+
+	schema       map[string]schemaTable // Tables of the schema the inserts are adapted to by lower case name, or nil for setup.sql as is
+	padNulls     string                 // How inserts adapted to the schema deal with the columns without a value, from -pad-nulls
+	templates    statementTemplates     // Templates given with the -template flags, if any
+	upsert       string                 // How rows that may already be in the tables are written: "" for plain inserts, or "merge"
+	batchSize    int                    // Rows written in each MERGE with -upsert merge
+	linkByName   bool                   // Whether rail lines and stations are found by name and only inserted when missing, from -link-by-name
+	dbIds        bool                   // Whether rail lines and stations are inserted without IDs for the database to assign, from -db-ids
+	beginKeyword string                 // Keyword starting each SQL transaction, or empty to emit the statements without transactions
+	progress     emissionProgress       // How far the emission of the stations has got
+
+	hook func(Statement) error // Called with every statement as it is generated, if set
+}
+
+// Create a conversion with the defaults of the flags.
+func newConversion() *conversion {
+	return &conversion{
+		dialect:          "standard",
+		stringStyle:      "standard",
+		compression:      "auto",
+		inputEncoding:    "auto",
+		httpTimeout:      30 * time.Second,
+		maxDownloadBytes: 100 << 20,
+		beginKeyword:     "BEGIN",
+		logger:           log.Default(),
+	}
+}
+
+// Convert the CSV files named by the command-line arguments, writing the SQL
+// statements to stdout and any preview or summary to stderr. Every failure is
+// returned rather than exiting, after writing out any statements generated so
+// far.
+func run(args []string, stdout io.Writer, stderr io.Writer) error {
+	return newConversion().run(args, stdout, stderr)
+}
+
+// Convert like [run], with the settings of the flags added to those of the
+// conversion.
+func (c *conversion) run(args []string, stdout io.Writer, stderr io.Writer) error {
+	c.logger = log.New(stderr, "", log.LstdFlags)
+	if 0 < len(args) && "migrate" == args[0] {
+		return c.runMigrate(args[1:], stdout, stderr)
+	}
+	if 0 < len(args) && "gen" == args[0] {
+		return c.runGen(args[1:], stdout, stderr)
+	}
+	if 0 < len(args) && "schema" == args[0] {
+		return runSchema(args[1:], stdout, stderr)
+	}
+	if 0 < len(args) && "serve" == args[0] {
+		return runServe(args[1:], stdout, stderr)
+	}
+	flags := flag.NewFlagSet("csv2sql", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage:\n\n\tcsv2sql -lines lines.csv -stations stations.csv [-devices devices.csv] > output.sql\n\tcsv2sql migrate -lines lines.csv -from old.csv -to stations.csv > migration.sql\n\tcsv2sql gen -stations 1000 -lines 10 -lines-out lines.csv -stations-out stations.csv\n\tcsv2sql schema -dialect postgres > setup.sql\n\tcsv2sql serve -listen :8080\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+	linesPath := flags.String("lines", "lines.csv", "CSV file for the rail lines")
+	stationsPath := flags.String("stations", "stations.csv", "CSV file for the stations")
+	builtin := flags.String("builtin", "", "Example network embedded in the program to read instead of -lines and -stations, see -list-builtins")
+	listBuiltins := flags.Bool("list-builtins", false, "List the example networks available to -builtin and exit")
+	gtfsPath := flags.String("gtfs", "", "GTFS feed, as a directory or ZIP archive, to read the rail lines and stations from instead of -lines and -stations")
+	sortStations := flags.String("sort-stations", "input", "Order to assign station IDs in: 'input' or 'name'")
+	sortLines := flags.String("sort-lines", "input", "Order to assign rail line IDs in: 'input' or 'name'")
+	lineOrder := flags.String("line-order", "", "Comma separated rail line names to assign IDs in, before any unlisted lines")
+	foldCase := flags.Bool("fold-case", false, "Ignore case when sorting by name")
+	collation := flags.String("collate", "", "BCP 47 language tag of the locale to sort names by, like 'en' or 'de', instead of byte-wise")
+	onlyLines := flags.String("only-lines", "", "Comma separated rail line names to keep, dropping all others")
+	excludeLines := flags.String("exclude-lines", "", "Comma separated rail line names to drop")
+	modeFlag := flags.String("mode", "", "Transit mode, like 'rail' or 'bus', of the rail lines whose column header names none")
+	onlyModes := flags.String("only-modes", "", "Comma separated transit modes of the rail lines to keep, dropping all others")
+	allowDisconnected := flags.Bool("allow-disconnected", false, "Skip checking that every rail line shares a station with the rest of the network")
+	allowEmpty := flags.Bool("allow-empty", false, "Convert a stations CSV with a header but no station records instead of failing")
+	keepOrphans := flags.Bool("keep-orphans", false, "Keep stations left on no rail lines after filtering")
+	stationFilter := flags.String("station-filter", "", "Regular expression station names must match to be kept")
+	stationExclude := flags.String("station-exclude", "", "Regular expression for station names to drop")
+	rejectsPath := flags.String("rejects", "", "File to write the station records dropped by the filters to, along with why, if any are")
+	var merges repeatedFlag
+	flags.Var(&merges, "merge", "Network to merge in as label=lines.csv,stations.csv (repeatable)")
+	var combinations repeatedFlag
+	flags.Var(&combinations, "combine-lines", "Rail line to combine the columns of other rail lines into as NAME=LINE,LINE,... (repeatable)")
+	branchColumn := flags.String("branch-column", "", "Column of LineStations to fill with the rail line each combined link came from")
+	mergePrefix := flags.Bool("merge-prefix", false, "Prefix the names from each merged network with its label")
+	mergeLines := flags.String("merge-lines", "separate", "How merged networks sharing a rail line name are handled: 'separate' or 'merge'")
+	skipRows := flags.Int("skip-rows", 0, "Number of station records after the header to skip")
+	limitRows := flags.Int("limit-rows", 0, "Maximum number of station records to convert after any skipped, or 0 for all")
+	preserveIds := flags.Bool("preserve-ids", false, "Number the stations as if the skipped records were converted too")
+	validateSkipped := flags.Bool("validate-skipped", false, "Report errors in the skipped station records")
+	sampleSize := flags.Int("sample", 0, "Number of stations to randomly sample, or 0 to keep them all")
+	seed := flags.Uint64("seed", 1, "Seed for the random sampling of stations")
+	inputFormat := flags.String("input-format", "csv", "Format of the stations file: 'csv', or 'parquet' with a string station column and a boolean column per rail line or a string rail line column")
+	maxLines := flags.Int("max-lines", 500, "Most rail line columns accepted in the stations CSV header, or 0 for any number")
+	stationsFormat := flags.String("stations-format", "auto", "Shape of the stations CSV: 'matrix' with a column per rail line, 'list' of the rail lines of each station in one column, 'pairs' of a station and a rail line per row, or 'auto' to detect it")
+	boolConsistency := flags.String("bool-consistency", "column", "Where mixed boolean spellings like 'TRUE' and '1' are warned about: 'column', 'file', or 'off'")
+	boolStyle := flags.String("bool-style", "boolean", "How the stations CSV marks stations as on a rail line: 'boolean' or 'sequence' for their position along it")
+	emitConnections := flags.Bool("emit-connections", false, "Emit a Connections row between consecutive stations of each rail line, requires -bool-style sequence")
+	connectionDirection := flags.String("connection-direction", "both", "Which Connections rows to emit: 'both' directions or only 'forward' along the rail line")
+	distancesPath := flags.String("distances", "", "CSV file of the travel time in seconds between consecutive stations, requires -emit-connections")
+	requireHeader := flags.String("require-header", "", "Required name of the first header cell of the stations CSV, like 'Station'")
+	zoneColumn := flags.String("zone-column", "zone", "Header name of the stations CSV column with each station's alarm zone")
+	zoneLinks := flags.Bool("zone-links", false, "Link stations to alarm zones through StationZones rows instead of a zone_id column")
+	maxCapacity := flags.Uint64("max-capacity", 1_000_000, "Largest occupant capacity accepted for a station")
+	statusValues := flags.String("status-values", defaultStatusValues, "Comma separated statuses allowed in the status column of the stations CSV")
+	statusDefault := flags.String("status-default", "open", "Status of stations with a blank status, or '' to leave it NULL")
+	excludeStatus := flags.String("exclude-status", "", "Comma separated statuses of stations to drop, like 'closed,planned'")
+	timestampColumn := flags.String("timestamp-column", "", "Column to fill with a timestamp on every rail line and station as NAME[=<RFC3339 time>|now()]")
+	renameMap := flags.String("rename-map", "", "CSV file of rail lines and stations to rename, with rows of kind (line or station), old name, and new name")
+	prefixStations := flags.String("prefix-stations", "", "Prefix for every station name")
+	prefixLines := flags.String("prefix-lines", "", "Prefix for every rail line name")
+	networkName := flags.String("network", "", "Name of the Networks row to emit and link every rail line and station to")
+	networkId := flags.Int("network-id", 1, "ID of the first Networks row")
+	groupByTable := flags.Bool("group-by-table", false, "Emit all of the rows of each table together instead of the rows of each station together")
+	networkLinks := flags.Bool("network-links", false, "Also give LineStations rows a network_id column")
+	networkPerMerge := flags.Bool("merge-networks", false, "Emit a Networks row for each merged network, named after its label")
+	anonymizeNames := flags.Bool("anonymize", false, "Replace every rail line, station, and alarm zone name with a generic label")
+	anonymizeMap := flags.String("anonymize-map", "", "CSV file to write the real names and their anonymous labels to")
+	anonymizeColumns := flags.String("anonymize-columns", "drop", "What anonymizing does to attributes and additional station columns: 'drop' or 'hash'")
+	aliasesPath := flags.String("aliases", "", "CSV file of station aliases, with rows of station name and alias")
+	agenciesPath := flags.String("agencies", "", "CSV file of the agencies running the rail lines, with rows of rail line name, agency, and contact")
+	devicesPath := flags.String("devices", "", "CSV file of alarm devices in each station")
+	entrancesPath := flags.String("entrances", "", "CSV file of the street entrances of each station")
+	var escapes repeatedFlag
+	flags.Var(&escapes, "escape", "Extra escaping rule as FROM=TO, applied after the built-in ones (repeatable)")
+	escapeFile := flags.String("escape-file", "", "File of extra escaping rules, one FROM=TO per line")
+	templateLine := flags.String("template-line", "", "text/template for the statement of each rail line instead of the insert, with {{.ID}}, {{.Name}}, {{.Red}}, {{.Green}}, and {{.Blue}}")
+	templateStation := flags.String("template-station", "", "text/template for the statement of each station instead of the insert, with {{.ID}} and {{.Name}}")
+	templateLink := flags.String("template-link", "", "text/template for the statement of each link instead of the insert, with {{.LineID}} and {{.StationID}}")
+	templateFile := flags.String("template-file", "", "File of text/templates defining any of the templates line, station, and link")
+	padNullsFlag := flags.String("pad-nulls", "", "With -schema-file, insert with a column list of only the filled 'columns', or 'positional' with NULL or DEFAULT for every other column")
+	checkSchemaPath := flags.String("check-schema", "", "File of CREATE TABLE statements, like setup.sql, to check the inserts against before anything is written")
+	schemaFile := flags.String("schema-file", "", "File of CREATE TABLE statements, like setup.sql, to adapt the inserts to")
+	dialectFlag := flags.String("dialect", "standard", "SQL dialect to generate: 'standard', 'postgres', 'mysql', 'sqlite', 'mssql', or 'oracle'")
+	format := flags.String("format", "sql", "What to write: 'sql' statements or a 'geojson' FeatureCollection of the stations and rail lines")
+	postgis := flags.Bool("postgis", false, "Emit the latitude and longitude of the stations as a PostGIS point, requires -dialect postgres")
+	geometryColumn := flags.String("geometry-column", "geom", "Column of the Stations table for the PostGIS point of each station")
+	stringStyleFlag := flags.String("string-style", "standard", "How string literals are written: 'standard', or for postgres 'dollar' or 'estring'")
+	var headers repeatedFlag
+	flags.Var(&headers, "header", "Extra HTTP header as 'Name: value' when fetching CSV files from URLs (repeatable)")
+	timeout := flags.Duration("http-timeout", 30*time.Second, "Time limit for fetching each CSV file from a URL")
+	maxDownload := flags.Int64("max-download-bytes", 100<<20, "Largest CSV file in bytes to fetch from a URL")
+	compressionFlag := flags.String("compression", "auto", "How the CSV files are compressed: 'auto', 'gzip', or 'none'")
+	encodingFlag := flags.String("encoding", "auto", "Character encoding of the CSV files: 'auto', 'utf-8', 'utf-16le', 'utf-16be', 'windows-1252', or 'iso-8859-1'")
+	nulFlag := flags.String("nul", "strip", "How NUL characters in the CSV files are handled: 'strip', 'error', or 'replace[=<char>]'")
+	maxStatementBytes := flags.Int("max-statement-bytes", 0, "Longest statement in bytes to generate, or 0 for no limit")
+	auditEscapesFlag := flags.Bool("audit-escapes", false, "List every value that escaping or the NUL policy changed on Standard Error and in the report")
+	var namePatterns, lineNamePatterns repeatedFlag
+	flags.Var(&namePatterns, "name-pattern", "Regular expression every station name must match (repeatable)")
+	flags.Var(&lineNamePatterns, "line-name-pattern", "Regular expression every rail line name must match (repeatable)")
+	fuzzyDuplicates := flags.Int("fuzzy-duplicates", 0, "Warn about station names within this many edits of each other once normalized, or 0 to skip the check")
+	warnSuspicious := flags.Bool("warn-suspicious", true, "Warn about values that look like SQL injection attempts")
+	strict := flags.Bool("strict", false, "Treat data quality warnings as errors")
+	failOnWarningFlag := flags.Bool("fail-on-warning", false, "Fail before anything is committed when any data quality warning was raised")
+	force := flags.String("force", "", "Comma separated classes of data quality errors to raise as warnings instead, like 'duplicates,unknown-stations'")
+	verbose := flags.Bool("verbose", false, "Log extra details about the conversion to Standard Error")
+	canonical := flags.Bool("canonical", false, "Generate byte-stable output, sorting rail lines and stations by name")
+	preview := flags.Bool("preview", false, "Print a table of the stations and the rail lines they are on to Standard Error")
+	previewLines := flags.Int("preview-lines", 12, "Maximum number of rail line columns in the preview, or 0 for all")
+	newline := flags.String("newline", "lf", "How every emitted line ends: 'lf' or 'crlf'")
+	noTransaction := flags.Bool("no-transaction", false, "Emit the statements without BEGIN and COMMIT, for executors that wrap the script in a transaction")
+	beginFlag := flags.String("begin-keyword", "BEGIN", "Keyword starting each transaction, like 'START TRANSACTION'")
+	bulkLoad := flags.Bool("bulk-load", false, "Turn off checks that slow down loading around the data, for the mysql and sqlite dialects, unsafe with concurrent use")
+	indexesAfterData := flags.Bool("indexes-after-data", false, "Drop the indexes of the schema before the data and create them again after it")
+	withViews := flags.Bool("with-views", false, "Create views of the stations of each rail line after the data")
+	markImport := flags.Bool("mark-import", false, "Record a hash of the data in an ImportLog table, stopping the script when the same data was already imported")
+	assertCounts := flags.Bool("assert-counts", false, "Append statements checking the number of rows in every table after the data")
+	checkSyntaxFlag := flags.Bool("check-syntax", false, "Check that every statement has balanced quotes and parentheses and is terminated, on by default with -strict")
+	upsertFlag := flags.String("upsert", "", "Write rows that may already be in the tables as 'merge' statements on their primary key instead of inserts, for SQL Server, Oracle, and PostgreSQL 15")
+	batchSize := flags.Int("batch-size", 1, "Rows of the same table written in each MERGE with -upsert merge")
+	chunkStatements := flags.Int("chunk-statements", 0, "Split the statements into files of at most this many, named after -output")
+	chunkBytes := flags.Int("chunk-bytes", 0, "Split the statements into files of at most this many bytes, named after -output")
+	sqliteOut := flags.String("sqlite-out", "", "SQLite database file to create and insert everything into instead of writing the statements out")
+	overwrite := flags.Bool("overwrite", false, "Replace the -sqlite-out database if it already exists")
+	selfTestFlag := flags.Bool("self-test", false, "Execute the statements against an in-memory SQLite database with setup.sql before writing them out")
+	selfCheck := flags.Bool("self-check", false, "Check the keys of the generated statements before committing each transaction")
+	dryRun := flags.Bool("dry-run", false, "Check the input and generate the statements without writing them out")
+	summary := flags.Bool("summary", false, "Print statistics about the network to Standard Error")
+	metricsFlag := flags.Bool("metrics", false, "Break the time of the conversion down by phase in the summary and report, printing it to Standard Error without -summary")
+	cpuProfile := flags.String("cpuprofile", "", "Write a pprof CPU profile of the conversion to this file")
+	memProfile := flags.String("memprofile", "", "Write a pprof heap profile to this file at the end of the conversion")
+	traceFlag := flags.String("trace", "", "Write a runtime execution trace of the conversion to this file")
+	reportPath := flags.String("report", "", "File to write statistics about the network to as JSON")
+	syncFlag := flags.Bool("sync", false, "Emit only the changes that bring the rail lines and stations of the -dsn database up to date")
+	dsn := flags.String("dsn", "", "SQLite database file to synchronize with -sync")
+	execute := flags.Bool("execute", false, "Apply the -sync changes to the database instead of writing them out")
+	linkByNameFlag := flags.Bool("link-by-name", false, "Find rail lines and stations by name and only insert those missing, for loading into a database that already has some")
+	dbIdsFlag := flags.Bool("db-ids", false, "Insert rail lines and stations without IDs for the database to assign, finding them by name for the links")
+	prune := flags.Bool("prune", false, "Delete rail lines, stations, and links in the database but not in the CSV files with -sync")
+	outputPath := flags.String("output", "", "File to write the statements to instead of Standard Out")
+	filterCmd := flags.String("filter-cmd", "", "Shell command to pipe the statements through, writing what it outputs instead")
+	checkpointPath := flags.String("checkpoint", "", "File to record the progress of writing -output to, for continuing after a crash with -resume")
+	checkpointInterval := flags.Int("checkpoint-interval", 10000, "Number of statements between checkpoints")
+	resume := flags.Bool("resume", false, "Continue the conversion recorded in the -checkpoint file, appending to -output")
+	delimiterFlag := flags.String("delimiter", "", "Delimiter of the CSV files as a single character or 'tab', detected in each file by default")
+	commentFlag := flags.String("comment", "", "Character starting comment lines in the CSV files, like '#' for a rejects file")
+	forceStdin := flags.Bool("stdin", false, "Read a CSV file named '-' from Standard In even when it is a terminal")
+	if err := flags.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if nil != err {
+		return errUsage
+	}
+	profiling, err := startProfiling(*cpuProfile, *memProfile, *traceFlag)
+	if nil != err {
+		return err
+	}
+	defer func() {
+		// Written whether or not the conversion failed, to see where it went wrong
+		if err := profiling.stop(); nil != err {
+			c.logger.Println(err)
+		}
+	}()
+
+	if *listBuiltins {
+		return writeBuiltins(stdout)
+	}
+	if "" != *builtin {
+		given := false
+		flags.Visit(func(f *flag.Flag) {
+			given = given || "lines" == f.Name || "stations" == f.Name || "gtfs" == f.Name || "merge" == f.Name
+		})
+		if given {
+			return fmt.Errorf("A built-in dataset cannot be used with -lines, -stations, -gtfs, or -merge")
+		}
+		var err error
+		if *linesPath, *stationsPath, err = builtinPaths(*builtin); nil != err {
+			return err
+		}
+	}
+
+	stdinPaths := []string{*linesPath, *stationsPath, *devicesPath, *entrancesPath}
+	for _, spec := range merges {
+		_, paths, _ := strings.Cut(spec, "=")
+		stdinPaths = append(stdinPaths, strings.Split(paths, ",")...)
+	}
+	if !*forceStdin && term.IsTerminal(int(os.Stdin.Fd())) && slices.ContainsFunc(stdinPaths, func(path string) bool {
+		return "-" == strings.TrimSpace(path)
+	}) {
+		fmt.Fprintln(stderr, "Standard In is a terminal, pipe or redirect a CSV file to it or give -stdin to type one in")
+		flags.Usage()
+		return errUsage
+	}
+
+	startTime := time.Now()
+	if *canonical {
+		if "" != *lineOrder {
+			return fmt.Errorf("An explicit rail line order cannot be used with canonical output")
+		}
+		if _, value, found := strings.Cut(*timestampColumn, "="); "" != *timestampColumn && (!found || "now()" == value) {
+			return fmt.Errorf("A timestamp column requires an explicit time with canonical output")
+		}
+		if "lf" != *newline {
+			return fmt.Errorf("Canonical output requires -newline lf")
+		}
+		*sortStations, *sortLines = "name", "name"
+	}
+	if "lf" != *newline && "crlf" != *newline {
+		return fmt.Errorf("Invalid newline style: %s", *newline)
+	}
+	if "input" != *sortStations && "name" != *sortStations {
+		return fmt.Errorf("Invalid station order: %s", *sortStations)
+	}
+	if "input" != *sortLines && "name" != *sortLines {
+		return fmt.Errorf("Invalid rail line order: %s", *sortLines)
+	}
+	if "separate" != *mergeLines && "merge" != *mergeLines {
+		return fmt.Errorf("Invalid rail line merge mode: %s", *mergeLines)
+	}
+
+	var collator *collate.Collator
+	if "" != *collation {
+		tag, err := language.Parse(*collation)
+		if nil != err {
+			return fmt.Errorf("Invalid collation: %w", err)
+		}
+		options := []collate.Option{}
+		if *foldCase {
+			options = append(options, collate.IgnoreCase)
+		}
+		collator = collate.New(tag, options...)
+	}
+	compareNames := nameComparer(*foldCase, collator)
+
+	if "drop" != *anonymizeColumns && "hash" != *anonymizeColumns {
+		return fmt.Errorf("Invalid anonymized column mode: %s", *anonymizeColumns)
+	}
+	if "" != *anonymizeMap && !*anonymizeNames {
+		return fmt.Errorf("An anonymize map requires -anonymize")
+	}
+
+	if "boolean" != *boolStyle && "sequence" != *boolStyle {
+		return fmt.Errorf("Invalid boolean style: %s", *boolStyle)
+	}
+	if !slices.Contains([]string{"column", "file", "off"}, *boolConsistency) {
+		return fmt.Errorf("Invalid boolean consistency scope: %s", *boolConsistency)
+	}
+	if "auto" != *stationsFormat && !slices.Contains(stationShapes, *stationsFormat) {
+		return fmt.Errorf("Invalid stations format: %s", *stationsFormat)
+	} else if "auto" != *stationsFormat && "matrix" != *stationsFormat && "sequence" == *boolStyle {
+		return fmt.Errorf("The %s stations format has no positions for -bool-style sequence", *stationsFormat)
+	} else if "auto" != *stationsFormat && "matrix" != *stationsFormat && "" != *gtfsPath {
+		return fmt.Errorf("The %s stations format cannot be used with a GTFS feed", *stationsFormat)
+	}
+	if *emitConnections && "sequence" != *boolStyle {
+		return fmt.Errorf("Emitting connections requires -bool-style sequence")
+	}
+	if "" != *distancesPath && !*emitConnections {
+		return fmt.Errorf("Distances require -emit-connections")
+	}
+	if "both" != *connectionDirection && "forward" != *connectionDirection {
+		return fmt.Errorf("Invalid connection direction: %s", *connectionDirection)
+	}
+	if *syncFlag && "" == *dsn {
+		return fmt.Errorf("Synchronizing requires -dsn")
+	}
+	if (*execute || *prune) && !*syncFlag {
+		return fmt.Errorf("Executing and pruning require -sync")
+	}
+	if "" != *checkpointPath && "" == *outputPath {
+		return fmt.Errorf("Checkpoints require -output")
+	}
+	if *resume && "" == *checkpointPath {
+		return fmt.Errorf("Resuming requires -checkpoint")
+	}
+	if "" != *checkpointPath && (*selfTestFlag || *dryRun || *syncFlag) {
+		return fmt.Errorf("Checkpoints cannot be used with -self-test, -dry-run, or -sync")
+	}
+	if "" != *filterCmd && ("" != *checkpointPath || *syncFlag) {
+		return fmt.Errorf("Filter commands cannot be used with -checkpoint or -sync")
+	}
+	if "" != *checkpointPath {
+		if "matrix" != *stationsFormat {
+			return fmt.Errorf("Checkpoints require -stations-format matrix, whose records are read where they are in the file")
+		}
+		// A resumed conversion only parses the stations after the checkpoint, so
+		// nothing may depend on those before it
+		for _, conflict := range []struct {
+			flag string
+			used bool
+		}{
+			{"-gtfs", "" != *gtfsPath},
+			{"-merge", 0 < len(merges)},
+			{"-input-format parquet", "parquet" == *inputFormat},
+			{"-sort-stations name", "name" == *sortStations},
+			{"-sample", 0 < *sampleSize},
+			{"-limit-rows", 0 < *limitRows},
+			{"-rejects", "" != *rejectsPath},
+			{"-group-by-table", *groupByTable},
+			{"-devices", "" != *devicesPath},
+			{"-entrances", "" != *entrancesPath},
+			{"-aliases", "" != *aliasesPath},
+			{"-emit-connections", *emitConnections},
+			{"-anonymize", *anonymizeNames},
+			{"-self-check", *selfCheck},
+			{"-assert-counts", *assertCounts},
+			{"-mark-import", *markImport},
+			{"-summary", *summary},
+			{"-report", "" != *reportPath},
+		} {
+			if conflict.used {
+				return fmt.Errorf("Checkpoints cannot be used with %s, which needs every station", conflict.flag)
+			}
+		}
+	}
+	switch *inputFormat {
+	case "csv":
+	case "parquet":
+		if "" != *gtfsPath || 0 < len(merges) {
+			return fmt.Errorf("Parquet stations cannot be used with -gtfs or -merge")
+		}
+	default:
+		return fmt.Errorf("Invalid input format: %s", *inputFormat)
+	}
+	var rejects *rejectsFile
+	if "" != *rejectsPath {
+		if "" != *gtfsPath || 0 < len(merges) {
+			return fmt.Errorf("Rejects cannot be written with -gtfs or -merge")
+		}
+		rejects = &rejectsFile{path: *rejectsPath, logger: c.logger}
+	}
+	if 0 > *maxLines {
+		return fmt.Errorf("Invalid maximum number of rail lines: %d", *maxLines)
+	}
+	if 0 > *skipRows {
+		return fmt.Errorf("Invalid number of rows to skip: %d", *skipRows)
+	}
+	if 0 > *limitRows {
+		return fmt.Errorf("Invalid number of rows to convert: %d", *limitRows)
+	}
+	if 0 >= *checkpointInterval {
+		return fmt.Errorf("Invalid checkpoint interval: %d", *checkpointInterval)
+	}
+
+	chunking := 0 < *chunkStatements || 0 < *chunkBytes
+	if 0 > *chunkStatements || 0 > *chunkBytes {
+		return fmt.Errorf("Invalid chunk size")
+	} else if chunking {
+		if "" == *outputPath {
+			return fmt.Errorf("Chunks require -output as the prefix of their file names")
+		}
+		for _, conflict := range []struct {
+			flag string
+			used bool
+		}{
+			{"-checkpoint", "" != *checkpointPath},
+			{"-sync", *syncFlag},
+			{"-dry-run", *dryRun},
+			{"-filter-cmd", "" != *filterCmd},
+			{"-sqlite-out", "" != *sqliteOut},
+			{"-no-transaction, without which a station could be split from its links", *noTransaction},
+			{"-group-by-table", *groupByTable},
+			{"-format geojson", "geojson" == *format},
+		} {
+			if conflict.used {
+				return fmt.Errorf("Chunks cannot be used with %s", conflict.flag)
+			}
+		}
+	}
+	if "" != *sqliteOut {
+		for _, conflict := range []struct {
+			flag string
+			used bool
+		}{
+			{"-output", "" != *outputPath},
+			{"-sync", *syncFlag},
+			{"-self-test", *selfTestFlag},
+			{"-dry-run", *dryRun},
+			{"-filter-cmd", "" != *filterCmd},
+			{"-bulk-load", *bulkLoad},
+			{"-format geojson", "geojson" == *format},
+		} {
+			if conflict.used {
+				return fmt.Errorf("A SQLite database cannot be written with %s", conflict.flag)
+			}
+		}
+		if "standard" != *dialectFlag && "sqlite" != *dialectFlag {
+			return fmt.Errorf("A SQLite database cannot be written in the %s dialect", *dialectFlag)
+		}
+		// The database gets one transaction of its own
+		*noTransaction = true
+	} else if *overwrite {
+		return fmt.Errorf("Overwriting requires -sqlite-out")
+	}
+	if err := c.setTransactions(flags, *noTransaction, *beginFlag); nil != err {
+		return err
+	}
+
+	if !slices.Contains([]string{"standard", "postgres", "mysql", "sqlite", "mssql", "oracle"}, *dialectFlag) {
+		return fmt.Errorf("Invalid dialect: %s", *dialectFlag)
+	}
+	c.dialect = *dialectFlag
+	// BEGIN starts a block rather than a transaction in T-SQL and PL/SQL
+	if keyword, found := map[string]string{"mssql": "BEGIN TRANSACTION", "oracle": "SET TRANSACTION READ WRITE"}[c.dialect]; found && "" != c.beginKeyword {
+		given := false
+		flags.Visit(func(f *flag.Flag) { given = given || "begin-keyword" == f.Name })
+		if !given {
+			c.beginKeyword = keyword
+		}
+	}
+	var linePatterns, stationPatterns []*regexp.Regexp
+	for _, patterns := range []struct {
+		flag     string
+		given    []string
+		compiled *[]*regexp.Regexp
+	}{
+		{"-line-name-pattern", lineNamePatterns, &linePatterns},
+		{"-name-pattern", namePatterns, &stationPatterns},
+	} {
+		for _, given := range patterns.given {
+			pattern, err := regexp.Compile(given)
+			if nil != err {
+				return fmt.Errorf("Invalid %s: %w", patterns.flag, err)
+			}
+			*patterns.compiled = append(*patterns.compiled, pattern)
+		}
+	}
+	switch *upsertFlag {
+	case "":
+	case "merge":
+		if !slices.Contains([]string{"standard", "postgres", "mssql", "oracle"}, c.dialect) {
+			return fmt.Errorf("MERGE cannot be written in the %s dialect", c.dialect)
+		}
+		for _, conflict := range []struct {
+			flag string
+			used bool
+		}{
+			{"-schema-file", "" != *schemaFile},
+			{"-link-by-name", *linkByNameFlag},
+			{"-db-ids", *dbIdsFlag},
+			{"-sync", *syncFlag},
+			{"-self-test", *selfTestFlag},
+			{"-self-check", *selfCheck},
+			{"-sqlite-out", "" != *sqliteOut},
+			// The checkpoint would count the rows held back for a batch as written
+			{"-checkpoint", 1 < *batchSize && "" != *checkpointPath},
+		} {
+			if conflict.used {
+				return fmt.Errorf("MERGE cannot be used with %s", conflict.flag)
+			}
+		}
+	default:
+		return fmt.Errorf("Invalid upsert: %s", *upsertFlag)
+	}
+	c.upsert = *upsertFlag
+	if 0 >= *batchSize {
+		return fmt.Errorf("Invalid batch size: %d", *batchSize)
+	} else if 1 < *batchSize && "merge" != c.upsert {
+		return fmt.Errorf("A batch size requires -upsert merge")
+	}
+	c.batchSize = *batchSize
+	if *bulkLoad && !slices.Contains([]string{"mysql", "sqlite"}, c.dialect) {
+		return fmt.Errorf("Bulk loading has no equivalent in the %s dialect", c.dialect)
+	}
+	switch *stringStyleFlag {
+	case "standard":
+	case "dollar", "estring":
+		if "postgres" != c.dialect {
+			return fmt.Errorf("String style %s requires the postgres dialect", *stringStyleFlag)
+		}
+	default:
+		return fmt.Errorf("Invalid string style: %s", *stringStyleFlag)
+	}
+	c.stringStyle = *stringStyleFlag
+	switch *format {
+	case "sql":
+	case "geojson":
+		for _, conflict := range []struct {
+			flag string
+			used bool
+		}{
+			{"-sync", *syncFlag},
+			{"-checkpoint", "" != *checkpointPath},
+			{"-postgis", *postgis},
+			{"-self-test", *selfTestFlag},
+			{"-assert-counts", *assertCounts},
+			{"-mark-import", *markImport},
+			{"-with-views", *withViews},
+			{"-bulk-load", *bulkLoad},
+			{"-indexes-after-data", *indexesAfterData},
+			{"-filter-cmd", "" != *filterCmd},
+		} {
+			if conflict.used {
+				return fmt.Errorf("GeoJSON output cannot be used with %s", conflict.flag)
+			}
+		}
+	default:
+		return fmt.Errorf("Invalid format: %s", *format)
+	}
+	if *postgis && "postgres" != c.dialect {
+		return fmt.Errorf("PostGIS points require the postgres dialect")
+	} else if *postgis && *anonymizeNames && "hash" == *anonymizeColumns {
+		return fmt.Errorf("PostGIS points cannot be made of hashed coordinates")
+	}
+
+	if "auto" != *compressionFlag && "gzip" != *compressionFlag && "none" != *compressionFlag {
+		return fmt.Errorf("Invalid compression: %s", *compressionFlag)
+	}
+	c.compression = *compressionFlag
+	if _, found := encodings[*encodingFlag]; !found && "auto" != *encodingFlag {
+		return fmt.Errorf("Invalid encoding: %s", *encodingFlag)
+	}
+	c.inputEncoding = *encodingFlag
+	c.httpHeaders, c.httpTimeout, c.maxDownloadBytes = headers, *timeout, *maxDownload
+
+	if "" != *schemaFile {
+		tables, err := parseSchemaFile(*schemaFile)
+		if nil != err {
+			return fmt.Errorf("Failed to parse schema: %w", err)
+		}
+		c.schema = tables
+	}
+	switch *padNullsFlag {
+	case "", "columns", "positional":
+		if "" != *padNullsFlag && nil == c.schema {
+			return fmt.Errorf("Padding NULLs requires -schema-file for the columns of the tables")
+		}
+	default:
+		return fmt.Errorf("Invalid NULL padding: %s", *padNullsFlag)
+	}
+	c.padNulls = *padNullsFlag
+	var indexes []schemaIndex
+	if *indexesAfterData {
+		text := setupSql
+		if "" != *schemaFile {
+			contents, err := os.ReadFile(*schemaFile)
+			if nil != err {
+				return fmt.Errorf("Failed to read schema for its indexes: %w", err)
+			}
+			text = string(contents)
+		}
+		if indexes = parseIndexes(text); 0 == len(indexes) {
+			fmt.Fprintln(stderr, "The schema creates no indexes to move after the data")
+		}
+	}
+
+	if parsed, err := parseTemplates(*templateFile, *templateLine, *templateStation, *templateLink); nil != err {
+		return fmt.Errorf("Invalid statement template: %w", err)
+	} else {
+		c.templates = parsed
+	}
+
+	c.linkByName, c.dbIds = *linkByNameFlag, *dbIdsFlag
+	mode := "Linking by name"
+	if c.dbIds {
+		mode = "Database-assigned IDs"
+	}
+	if c.referencesByName() {
+		for _, conflict := range []struct {
+			flag string
+			used bool
+		}{
+			{"template flags", nil != c.templates.line || nil != c.templates.station || nil != c.templates.link},
+			{"-schema-file", nil != c.schema},
+			{"-self-check", *selfCheck},
+			{"-sync", *syncFlag},
+			{"-devices", "" != *devicesPath},
+			{"-entrances", "" != *entrancesPath},
+			{"-emit-connections", *emitConnections},
+			{"-network", "" != *networkName},
+			{"-merge-networks", *networkPerMerge},
+			{"-report, whose IDs would not be those assigned", c.dbIds && "" != *reportPath},
+		} {
+			if conflict.used {
+				return fmt.Errorf("%s cannot be used with %s", mode, conflict.flag)
+			}
+		}
+	}
+
+	if "" != *checkSchemaPath && (nil != c.templates.line || nil != c.templates.station || nil != c.templates.link) {
+		return fmt.Errorf("The schema cannot be checked with template flags, whose statements are unknown")
+	}
+	if "dollar" == c.stringStyle && (nil != c.templates.line || nil != c.templates.station) {
+		return fmt.Errorf("Names cannot be given to templates in dollar quotes, whose tag depends on the name")
+	}
+	if *assertCounts && (nil != c.templates.line || nil != c.templates.station || nil != c.templates.link) {
+		return fmt.Errorf("Row counts cannot be asserted with template flags, whose statements are unknown")
+	} else if *assertCounts && *syncFlag {
+		return fmt.Errorf("Row counts cannot be asserted with -sync, which only emits changes")
+	}
+	if *markImport && *syncFlag {
+		return fmt.Errorf("Imports cannot be marked with -sync, which only emits changes")
+	}
+	if *withViews && *syncFlag {
+		return fmt.Errorf("Views cannot be created with -sync, which only emits changes")
+	}
+
+	c.forcedClasses, c.failOnWarning = splitList(*force), *failOnWarningFlag
+	for _, class := range c.forcedClasses {
+		if !slices.Contains(findingClasses, class) {
+			return fmt.Errorf("Invalid class %q for -force, must be one of %s", class, strings.Join(findingClasses, ", "))
+		}
+	}
+
+	if parsed, err := parseDelimiter(*delimiterFlag); nil != err {
+		return err
+	} else {
+		c.delimiter = parsed
+	}
+	if runes := []rune(*commentFlag); 1 < len(runes) || (1 == len(runes) && (runes[0] == c.delimiter || '"' == runes[0] || '\r' == runes[0] || '\n' == runes[0])) {
+		return fmt.Errorf("Invalid comment character: %q", *commentFlag)
+	} else if 1 == len(runes) {
+		c.comment = runes[0]
+	}
+
+	if policy, err := parseNulPolicy(*nulFlag); nil != err {
+		return fmt.Errorf("Invalid NUL policy: %w", err)
+	} else {
+		c.nul = policy
+	}
+	if "" != *escapeFile {
+		rules, err := parseEscapeFile(*escapeFile, c.logger)
+		if nil != err {
+			return fmt.Errorf("Failed to read escaping rules: %w", err)
+		}
+		c.escapeRules = append(c.escapeRules, rules...)
+	}
+	for _, spec := range escapes {
+		rule, err := parseEscapeRule(spec)
+		if nil != err {
+			return fmt.Errorf("Invalid escaping rule: %w", err)
+		}
+		c.escapeRules = append(c.escapeRules, rule)
+	}
+	if *verbose {
+		for i, rule := range c.escapeRules {
+			c.logger.Printf("Escaping rule %d: %+q -> %+q\n", i+1, rule.from, rule.to)
+		}
+	}
+
+	var includePattern, excludePattern *regexp.Regexp
+	if "" != *stationFilter {
+		var err error
+		if includePattern, err = regexp.Compile(*stationFilter); nil != err {
+			return fmt.Errorf("Invalid station filter: %w", err)
+		}
+	}
+	if "" != *stationExclude {
+		var err error
+		if excludePattern, err = regexp.Compile(*stationExclude); nil != err {
+			return fmt.Errorf("Invalid station exclude pattern: %w", err)
+		}
+	}
+
+	allowedStatuses := splitList(strings.ToLower(*statusValues))
+	if 0 == len(allowedStatuses) || slices.Contains(allowedStatuses, "") {
+		return fmt.Errorf("Invalid status values: %q", *statusValues)
+	}
+	blankStatus, excludedStatuses := strings.ToLower(strings.TrimSpace(*statusDefault)), splitList(strings.ToLower(*excludeStatus))
+	for _, status := range append(excludedStatuses, blankStatus) {
+		if "" != status && !slices.Contains(allowedStatuses, status) {
+			return fmt.Errorf("Invalid status %q, must be one of %s", status, strings.Join(allowedStatuses, ", "))
+		}
+	}
+
+	columnOptions := stationOptions{
+		zoneColumn:      strings.TrimSpace(*zoneColumn),
+		maxCapacity:     *maxCapacity,
+		statusValues:    allowedStatuses,
+		requireHeader:   strings.TrimSpace(*requireHeader),
+		skipRows:        *skipRows,
+		limitRows:       *limitRows,
+		validateSkipped: *validateSkipped,
+		strict:          *strict,
+		boolStyle:       *boolStyle,
+		boolScope:       *boolConsistency,
+		shape:           *stationsFormat,
+		maxLines:        *maxLines,
+		rejects:         rejects,
+	}
+	var state checkpointState
+	if "" != *checkpointPath {
+		var inputs []inputFingerprint
+		for _, path := range append(stdinPaths, *aliasesPath, *agenciesPath, *distancesPath, *renameMap, *escapeFile, *templateFile, *schemaFile) {
+			if path = strings.TrimSpace(path); "" == path {
+				continue
+			}
+			if "-" == path || isUrl(path) || isBuiltin(path) {
+				return fmt.Errorf("Checkpoints require every input to be a local file, not %s", path)
+			}
+			input, err := fingerprintInput(path)
+			if nil != err {
+				return fmt.Errorf("Failed to fingerprint input for the checkpoint: %w", err)
+			}
+			inputs = append(inputs, input)
+		}
+		state = checkpointState{Version: checkpointVersion, Flags: hashFlags(flags), Inputs: inputs, Preamble: -1}
+		if *resume {
+			if state, err = readCheckpoint(*checkpointPath, inputs, state.Flags); nil != err {
+				return err
+			}
+			fmt.Fprintf(stderr, "Resuming after %d statements, at %s\n", state.Statements, state.Progress)
+			if 0 < state.Progress.NextStationId {
+				// The stations before the checkpoint were written, so only those after it
+				// are parsed
+				columnOptions.skip = &inputSkip{from: state.Progress.HeaderOffset, to: state.Progress.InputOffset}
+				columnOptions.skipRows = 0
+			}
+		}
+	}
+	var lines []railLine
+	var stations []station
+	clock := phaseClock{start: startTime, parse: time.Now()}
+	if "" != *gtfsPath {
+		if 0 < len(merges) {
+			return fmt.Errorf("A GTFS feed cannot be merged with other networks")
+		}
+		if lines, stations, err = c.parseGtfs(*gtfsPath); nil != err {
+			return fmt.Errorf("Failed to parse GTFS feed: %w", err)
+		}
+	} else if 0 == len(merges) {
+		if lines, err = parseCsvFile(c, *linesPath, c.parseLines); nil != err {
+			return fmt.Errorf("Failed to parse rail lines: %w", err)
+		}
+		columnOptions.lines = lines
+		sniff, parse := c.stationParser(columnOptions)
+		if "parquet" == *inputFormat {
+			stations, err = parseParquetFile(*stationsPath, sniff, parse, c.logger)
+		} else {
+			stations, err = parseCsvFileSkipping(c, *stationsPath, columnOptions.skip, sniff, parse)
+		}
+		if nil != err {
+			return fmt.Errorf("Failed to parse stations: %w", err)
+		}
+	} else {
+		networks := make([]network, 0, len(merges))
+		for _, spec := range merges {
+			current, err := c.parseNetwork(spec, columnOptions)
+			if nil != err {
+				return fmt.Errorf("Failed to parse network: %w", err)
+			}
+			networks = append(networks, current)
+		}
+		if lines, stations, err = mergeNetworks(networks, *mergePrefix, "merge" == *mergeLines); nil != err {
+			return fmt.Errorf("Failed to merge networks: %w", err)
+		}
+	}
+
+	clock.prepare = time.Now()
+	rowsParsed := len(lines) + len(stations)
+
+	if 0 == len(stations) && !*allowEmpty {
+		return fmt.Errorf("No station records found, give -allow-empty if that is expected")
+	}
+
+	if "sequence" == *boolStyle {
+		if err := validatePositions(lines, stations); nil != err {
+			return fmt.Errorf("Invalid station positions: %w", err)
+		}
+	}
+
+	if "" != *renameMap {
+		renames, err := parseCsvFile(c, *renameMap, c.parseRenames)
+		if nil != err {
+			return fmt.Errorf("Failed to parse rename map: %w", err)
+		}
+		findings, err := applyRenames(lines, stations, renames)
+		if nil != err {
+			return fmt.Errorf("Failed to rename: %w", err)
+		}
+		if nil != columnOptions.skip {
+			// Renames of the stations before the checkpoint find nothing
+			findings = nil
+		}
+		if err := c.lint("renames", findings, *strict); nil != err {
+			return fmt.Errorf("Found unused renames: %w", err)
+		}
+	}
+
+	if "" != *prefixLines {
+		for i := range lines {
+			lines[i].name = *prefixLines + lines[i].name
+		}
+	}
+	if "" != *prefixStations {
+		for i := range stations {
+			stations[i].name = *prefixStations + stations[i].name
+		}
+	}
+
+	var networkNames []string
+	if "" != *timestampColumn {
+		column, value, found := strings.Cut(*timestampColumn, "=")
+		literal := c.timestampLiteral(startTime)
+		if "now()" == value {
+			literal = c.currentTimestamp()
+		} else if found {
+			timestamp, err := time.Parse(time.RFC3339, value)
+			if nil != err {
+				return fmt.Errorf("Invalid timestamp: %w", err)
+			}
+			literal = c.timestampLiteral(timestamp)
+		}
+		timestamp := field{strings.TrimSpace(column), literal}
+		for i := range lines {
+			lines[i].fields = append(lines[i].fields, timestamp)
+		}
+		for i := range stations {
+			stations[i].fields = append(stations[i].fields, timestamp)
+		}
+	}
+
+	if *networkPerMerge {
+		if "" != *networkName || 0 == len(merges) {
+			return fmt.Errorf("Emitting a network for each merged network requires -merge and no -network")
+		}
+		for _, spec := range merges {
+			label, _, _ := strings.Cut(spec, "=")
+			networkNames = append(networkNames, strings.TrimSpace(label))
+		}
+	} else if "" != *networkName {
+		networkNames = []string{*networkName}
+	}
+	if nil != networkNames {
+		assignNetworkFields(lines, stations, *networkId)
+	}
+
+	var filteredByName, filteredByStatus *int
+	if nil != includePattern || nil != excludePattern {
+		parsedCount := len(stations)
+		stations = slices.DeleteFunc(stations, func(s station) bool {
+			if nil != includePattern && !includePattern.MatchString(s.name) {
+				if nil != rejects {
+					rejects.reject(s, "Name does not match -station-filter")
+				}
+				return true
+			} else if nil != excludePattern && excludePattern.MatchString(s.name) {
+				if nil != rejects {
+					rejects.reject(s, "Name matches -station-exclude")
+				}
+				return true
+			}
+			return false
+		})
+		filtered := parsedCount - len(stations)
+		filteredByName = &filtered
+		c.logger.Printf("Filtered out %d of %d stations by name\n", filtered, parsedCount)
+	}
+	parsedCount := len(stations)
+	if stations = c.applyStatuses(stations, blankStatus, excludedStatuses, rejects); 0 < len(excludedStatuses) {
+		filtered := parsedCount - len(stations)
+		filteredByStatus = &filtered
+		c.logger.Printf("Filtered out %d of %d stations by status\n", filtered, parsedCount)
+	}
+	if nil != rejects {
+		if err := rejects.finish(); nil != err {
+			return err
+		}
+	}
+
+	assignDefaultMode(lines, *modeFlag)
+	var contacts map[string]string
+	if "" != *agenciesPath {
+		rows, err := parseCsvFile(c, *agenciesPath, c.parseAgencies)
+		if nil != err {
+			return fmt.Errorf("Failed to parse agencies: %w", err)
+		}
+		if contacts, err = applyAgencies(lines, rows, *prefixLines); nil != err {
+			return fmt.Errorf("Failed to resolve agencies: %w", err)
+		}
+	}
+	if "" != *onlyLines || "" != *excludeLines || "" != *onlyModes {
+		kept, err := filterLines(lines, splitList(*onlyLines), splitList(*excludeLines))
+		if nil != err {
+			return fmt.Errorf("Failed to filter rail lines: %w", err)
+		}
+		if "" != *onlyModes {
+			if kept, err = filterModes(lines, kept, splitList(strings.ToLower(*onlyModes))); nil != err {
+				return fmt.Errorf("Failed to filter rail lines: %w", err)
+			}
+		}
+		if lines, err = reorderLines(lines, stations, kept); nil != err {
+			return fmt.Errorf("Failed to filter rail lines: %w", err)
+		}
+		if !*keepOrphans {
+			stations = slices.DeleteFunc(stations, func(s station) bool { return 0 == len(s.lines) })
+		}
+	}
+
+	var sampled []string
+	if 0 < *sampleSize {
+		stations = sampleStations(stations, *sampleSize, *seed)
+		for _, current := range stations {
+			sampled = append(sampled, current.name)
+		}
+		c.logger.Printf("Sampled %d stations with seed %d: %s\n", len(stations), *seed, strings.Join(sampled, ", "))
+	}
+
+	var lineIndices []int
+	if "" != *lineOrder {
+		if lineIndices, err = explicitLineOrder(lines, splitList(*lineOrder), compareNames); nil != err {
+			return fmt.Errorf("Failed to order rail lines: %w", err)
+		}
+	} else if "name" == *sortLines {
+		lineIndices = make([]int, len(lines))
+		for i := range lineIndices {
+			lineIndices[i] = i
+		}
+		sortByName(lineIndices, func(i int) string { return lines[i].name }, compareNames)
+	}
+	if nil != lineIndices {
+		if lines, err = reorderLines(lines, stations, lineIndices); nil != err {
+			return fmt.Errorf("Failed to order rail lines: %w", err)
+		}
+	}
+	if 0 < len(combinations) {
+		parsed := make([]lineCombination, len(combinations))
+		for i, spec := range combinations {
+			if parsed[i], err = parseLineCombination(spec); nil != err {
+				return fmt.Errorf("Invalid rail line combination: %w", err)
+			}
+		}
+		if lines, err = combineLines(lines, stations, parsed); nil != err {
+			return fmt.Errorf("Failed to combine rail lines: %w", err)
+		}
+	}
+	if "name" == *sortStations {
+		sortByName(stations, func(s station) string { return s.name }, compareNames)
+	}
+	duplicateLinks := c.duplicateLinks + dedupeLinks(stations)
+	var checkpoint *checkpointer
+	if "" != *outputPath && !chunking {
+		mode := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if *resume {
+			mode = os.O_WRONLY
+		}
+		file, err := os.OpenFile(*outputPath, mode, 0o644)
+		if nil != err {
+			return fmt.Errorf("Failed to open output: %w", err)
+		}
+		defer func(file *os.File, path string) {
+			if err := file.Close(); nil != err {
+				c.logger.Printf("Failed to close %s: %v\n", path, err)
+			}
+		}(file, *outputPath)
+		stdout = file
+
+		if "" != *checkpointPath {
+			if checkpoint, err = newCheckpointer(*checkpointPath, *checkpointInterval, file, state, *resume, &c.progress); nil != err {
+				return err
+			}
+		}
+	}
+	if *syncFlag {
+		return c.syncDatabase(lines, stations, syncOptions{*dsn, *foldCase, *execute, *prune}, stdout, stderr)
+	}
+	firstStationId := 1
+	if *preserveIds {
+		firstStationId += *skipRows
+	}
+	if nil != columnOptions.skip {
+		firstStationId = state.Progress.NextStationId
+		c.progress = state.Progress.clone()
+	}
+	for i := range stations {
+		stations[i].id = firstStationId + i
+	}
+	var connections []connection
+	if *emitConnections {
+		connections = buildConnections(lines, stations, "both" == *connectionDirection)
+	}
+	if "" != *distancesPath {
+		distances, err := parseCsvFile(c, *distancesPath, c.parseDistances)
+		if nil != err {
+			return fmt.Errorf("Failed to parse distances: %w", err)
+		}
+		findings, err := applyDistances(connections, lines, stations, distances, *prefixLines, *prefixStations)
+		if nil != err {
+			return fmt.Errorf("Failed to match distances: %w", err)
+		}
+		if err := c.lint("distances", findings, *strict); nil != err {
+			return fmt.Errorf("Found connections without distances: %w", err)
+		}
+	}
+
+	c.warnInconsistentStations(stations)
+	// The checks of the whole network passed before the checkpoint was made, and
+	// would fail on the stations after it alone
+	resumed := nil != columnOptions.skip
+	if !*allowDisconnected && !resumed {
+		if err := c.lint("disconnected", findDisconnectedLines(lines, stations), *strict); nil != err {
+			return fmt.Errorf("Found disconnected rail lines: %w", err)
+		}
+	}
+
+	var devices []device
+	var deviceStationIds []int
+	if "" != *devicesPath {
+		if devices, err = parseCsvFile(c, *devicesPath, c.parseDevices); nil != err {
+			return fmt.Errorf("Failed to parse devices: %w", err)
+		}
+		if devices, deviceStationIds, err = c.resolveDevices(devices, stations, *prefixStations); nil != err {
+			return fmt.Errorf("Failed to resolve devices: %w", err)
+		}
+	}
+	var entrances []entrance
+	var entranceStationIds []int
+	if "" != *entrancesPath {
+		if entrances, err = parseCsvFile(c, *entrancesPath, c.parseEntrances); nil != err {
+			return fmt.Errorf("Failed to parse entrances: %w", err)
+		}
+		var findings []string
+		if entrances, entranceStationIds, findings, err = c.resolveEntrances(entrances, stations, *prefixStations); nil != err {
+			return fmt.Errorf("Failed to resolve entrances: %w", err)
+		}
+		if err := c.lint("duplicates", findings, *strict); nil != err {
+			return fmt.Errorf("Found duplicate entrances: %w", err)
+		}
+	}
+	if "" != *aliasesPath {
+		aliases, err := parseCsvFile(c, *aliasesPath, c.parseAliases)
+		if nil != err {
+			return fmt.Errorf("Failed to parse aliases: %w", err)
+		}
+		if err := c.applyAliases(stations, aliases, *prefixStations); nil != err {
+			return fmt.Errorf("Failed to resolve aliases: %w", err)
+		}
+	}
+
+	if 0 < len(namePatterns) || 0 < len(lineNamePatterns) {
+		if err := c.lint("names", findNameViolations(lines, stations, linePatterns, stationPatterns), *strict); nil != err {
+			return fmt.Errorf("Found names breaking the naming conventions: %w", err)
+		}
+	}
+	if 0 < *fuzzyDuplicates && !resumed {
+		if err := c.lint("near-duplicates", findNearDuplicates(stations, *fuzzyDuplicates), *strict); nil != err {
+			return fmt.Errorf("Found near duplicate station names: %w", err)
+		}
+	}
+	if *warnSuspicious {
+		if err := c.lint("suspicious", findSuspicious(lines, stations, devices), *strict); nil != err {
+			return fmt.Errorf("Found suspicious values: %w", err)
+		}
+	}
+
+	if *anonymizeNames {
+		names := c.anonymize(lines, stations, "hash" == *anonymizeColumns)
+		if "" != *anonymizeMap {
+			if err := writeAnonymizeMap(*anonymizeMap, names, c.logger); nil != err {
+				return fmt.Errorf("Failed to write anonymize map: %w", err)
+			}
+		}
+	}
+
+	if "geojson" == *format {
+		if err := c.writeGeoJson(stdout, lines, stations, *strict); nil != err {
+			return fmt.Errorf("Failed to write GeoJSON: %w", err)
+		}
+		return nil
+	}
+	if *postgis {
+		if err := pointFields(stations, strings.TrimSpace(*geometryColumn)); nil != err {
+			return fmt.Errorf("Failed to make PostGIS points: %w", err)
+		}
+	}
+
+	zones := collectZones(stations)
+	if c.referencesByName() && 0 < len(zones) {
+		return fmt.Errorf("%s cannot be used with alarm zones, which are referenced by ID", mode)
+	}
+	if !*zoneLinks {
+		assignZoneFields(stations, zones)
+	}
+	complexes, complexFindings := collectComplexes(stations)
+	if err := c.lint("complexes", complexFindings, *strict); nil != err {
+		return fmt.Errorf("Found station complexes of one station: %w", err)
+	}
+	if c.referencesByName() && 0 < len(complexes) {
+		return fmt.Errorf("%s cannot be used with station complexes, which are referenced by ID", mode)
+	}
+	assignComplexFields(stations, complexes)
+	if "" != *checkpointPath && (0 < len(zones) || 0 < len(complexes) || slices.ContainsFunc(stations, func(s station) bool { return nil != s.panels })) {
+		return fmt.Errorf("Checkpoints cannot be used with alarm zones, station complexes, or alarm panels, whose IDs depend on every station")
+	}
+	panelFindings, err := c.checkPanels(stations)
+	if nil != err {
+		return fmt.Errorf("Found duplicate alarm panels: %w", err)
+	}
+	if err := c.lint("panels", panelFindings, *strict); nil != err {
+		return fmt.Errorf("Found stations without alarm panels: %w", err)
+	}
+	if c.referencesByName() && slices.ContainsFunc(stations, func(s station) bool { return 0 < len(s.panels) }) {
+		return fmt.Errorf("%s cannot be used with alarm panels, which reference stations by ID", mode)
+	}
+	modes := collectModes(lines)
+	if c.referencesByName() && 0 < len(modes) {
+		return fmt.Errorf("%s cannot be used with transit modes, which are referenced by ID", mode)
+	}
+	assignModeFields(lines, modes)
+	var agencies []agency
+	if nil != contacts {
+		var findings []string
+		agencies, findings = collectAgencies(lines, contacts)
+		if err := c.lint("agencies", findings, *strict); nil != err {
+			return fmt.Errorf("Found rail lines without an agency: %w", err)
+		}
+		if c.referencesByName() && 0 < len(agencies) {
+			return fmt.Errorf("%s cannot be used with agencies, which are referenced by ID", mode)
+		}
+		assignAgencyFields(lines, agencies)
+	}
+
+	var escapeAudit []escapedValue
+	if *auditEscapesFlag {
+		escapeAudit = c.auditEscapes(lines, stations, devices)
+	}
+	if *preview {
+		if err := writePreview(stderr, lines, stations, *previewLines); nil != err {
+			return fmt.Errorf("Failed to write preview: %w", err)
+		}
+	}
+
+	// Columns of every table the inserts are into, for checking them against the
+	// schema and for creating the tables of -self-test and -sqlite-out
+	tables := map[string][]string{
+		"RailLines":    append([]string{"id", "name", "red", "green", "blue"}, collectFieldColumns(lines, func(l railLine) []field { return l.fields })...),
+		"Stations":     append([]string{"id", "name"}, collectFieldColumns(stations, func(s station) []field { return s.fields })...),
+		"LineStations": {"line_id", "station_id"},
+	}
+	if *networkLinks && nil != networkNames {
+		tables["LineStations"] = append(tables["LineStations"], networkIdColumn.name)
+	}
+	if "" != strings.TrimSpace(*branchColumn) {
+		tables["LineStations"] = append(tables["LineStations"], strings.TrimSpace(*branchColumn))
+	}
+	if nil != networkNames {
+		tables["Networks"] = []string{"id", "name"}
+	}
+	if 0 < len(modes) {
+		tables["Modes"] = []string{"id", "name"}
+	}
+	if 0 < len(agencies) {
+		tables["Agencies"] = []string{"id", "name"}
+	}
+	if slices.ContainsFunc(agencies, func(a agency) bool { return "" != a.contact }) {
+		tables["AgencyAttributes"] = []string{"agency_id", "name", "value"}
+	}
+	if slices.ContainsFunc(stations, func(s station) bool { return 0 < len(s.aliases) }) {
+		tables["StationAliases"] = []string{"station_id", "alias"}
+	}
+	if slices.ContainsFunc(stations, func(s station) bool { return 0 < len(s.names) }) {
+		tables["StationNames"] = []string{"station_id", "lang", "name"}
+	}
+	if slices.ContainsFunc(stations, func(s station) bool { return 0 < len(s.attributes) }) {
+		tables["StationAttributes"] = []string{"station_id", "name", "value"}
+	}
+	if 0 < len(complexes) {
+		tables["Complexes"] = []string{"id", "name"}
+	}
+	if 0 < len(zones) {
+		tables["AlarmZones"] = []string{"id", "name"}
+		if *zoneLinks {
+			tables["StationZones"] = []string{"station_id", "zone_id"}
+		}
+	}
+	if 0 < len(connections) {
+		tables["Connections"] = append([]string{"line_id", "from_station_id", "to_station_id"}, collectFieldColumns(connections, func(current connection) []field { return current.fields })...)
+	}
+	if 0 < len(devices) {
+		tables["Devices"] = []string{"id", "station_id", "type", "serial"}
+	}
+	if slices.ContainsFunc(stations, func(s station) bool { return 0 < len(s.panels) }) {
+		tables["Panels"] = []string{"id", "station_id", "tag"}
+	}
+	if 0 < len(entrances) {
+		tables["Entrances"] = []string{"id", "station_id", "name", "latitude", "longitude", "emergency_only"}
+	}
+	if nil != c.schema || "" != *checkSchemaPath {
+		if "" != *checkSchemaPath {
+			expected, err := parseSchemaFile(*checkSchemaPath)
+			if nil != err {
+				return fmt.Errorf("Failed to parse schema to check: %w", err)
+			}
+			if err := checkDrift(expected, tables, func(table string) ([]string, bool) {
+				if nil != c.schema {
+					var columns []string
+					for _, column := range c.schema[strings.ToLower(table)].columns {
+						columns = append(columns, column.name)
+					}
+					return columns, "positional" == c.padNulls
+				}
+				return tables[table], !c.referencesByName() && slices.Equal(tableColumns[strings.ToLower(table)], tables[table])
+			}); nil != err {
+				return fmt.Errorf("Inserts do not match %s: %w", *checkSchemaPath, err)
+			}
+		}
+		if nil != c.schema {
+			if err := checkSchema(c.schema, tables); nil != err {
+				return fmt.Errorf("Inserts do not match the schema: %w", err)
+			}
+		}
+		if "positional" == c.padNulls {
+			if err := c.checkPositional(tables, *selfTestFlag); nil != err {
+				return fmt.Errorf("Inserts cannot be positional: %w", err)
+			}
+		}
+	}
+
+	var networkIds []int
+	for i := range networkNames {
+		networkIds = append(networkIds, *networkId+i)
+	}
+	planned := plannedRows(lines, stations, modes, agencies, complexes, zones, *zoneLinks, deviceStationIds, entranceStationIds, networkIds, *networkLinks, connections)
+	if err := checkRows(planned); nil != err {
+		return fmt.Errorf("Inconsistent IDs: %w", err)
+	}
+
+	var filter *filterCommand
+	if "" != *filterCmd {
+		if filter, err = startFilter(*filterCmd, stdout, stderr, c.logger); nil != err {
+			return err
+		}
+		defer filter.abort()
+		stdout = filter
+	}
+	var test *selfTest
+	var script bytes.Buffer
+	destination := stdout
+	if *selfTestFlag && "standard" != c.dialect && "sqlite" != c.dialect {
+		fmt.Fprintf(stderr, "Skipping the self-test as SQLite does not understand the %s dialect\n", c.dialect)
+	} else if *selfTestFlag {
+		setup := generateSchema(ddlOptions{maxNameLength: 128, groups: allTableGroups(), columns: tables})
+		if "" != *schemaFile {
+			text, err := os.ReadFile(*schemaFile)
+			if nil != err {
+				return fmt.Errorf("Failed to read schema for the self-test: %w", err)
+			}
+			setup = string(text)
+		}
+		if test, err = newSelfTest(setup, c.logger); nil != err {
+			return fmt.Errorf("Failed to set up the self-test: %w", err)
+		}
+		defer test.close()
+		destination = &script
+	}
+
+	var database *sqliteOutput
+	if "" != *sqliteOut {
+		setup := generateSchema(ddlOptions{maxNameLength: 128, groups: allTableGroups(), columns: tables})
+		if "" != *schemaFile {
+			text, err := os.ReadFile(*schemaFile)
+			if nil != err {
+				return fmt.Errorf("Failed to read schema for the database: %w", err)
+			}
+			setup = string(text)
+		}
+		if database, err = newSqliteOutput(*sqliteOut, setup, *overwrite, c.logger); nil != err {
+			return err
+		}
+		defer database.abort()
+	}
+
+	var chunks *chunkWriter
+	if chunking {
+		chunks = newChunkWriter(*outputPath, *chunkStatements, *chunkBytes, "crlf" == *newline, c.beginKeyword, c.logger)
+		defer chunks.abort()
+	}
+
+	if err := c.checkWarnings(); nil != err {
+		return err
+	}
+	clock.emit = time.Now()
+	output := c.newEmitter(destination, emitterOptions{
+		dryRun:            *dryRun,
+		maxStatementBytes: *maxStatementBytes,
+		selfCheck:         *selfCheck,
+		crlf:              "crlf" == *newline,
+		checkSyntax:       *checkSyntaxFlag || *strict,
+		selfTest:          test,
+		checkpoint:        checkpoint,
+		database:          database,
+		chunks:            chunks,
+	})
+	hash := importHash(planned)
+	if *markImport {
+		if err := output.write(func(writer io.Writer) error { return c.importGuard(writer, hash) }); nil != err {
+			return fmt.Errorf("Failed to generate import log SQL statements: %w", err)
+		}
+	}
+	if *bulkLoad {
+		if err := output.write(func(writer io.Writer) error { return c.bulkLoadPrologue(writer, *noTransaction, planned) }); nil != err {
+			return fmt.Errorf("Failed to generate bulk load SQL statements: %w", err)
+		}
+	}
+	if 0 < len(indexes) {
+		if err := output.write(func(writer io.Writer) error { return c.dropIndexes(writer, indexes) }); nil != err {
+			return fmt.Errorf("Failed to generate index SQL statements: %w", err)
+		}
+	}
+	dataErr := func() error {
+		if err := output.transaction(func(writer io.Writer) error {
+			if err := output.networkStatements(networkNames, *networkId, writer); nil != err {
+				return err
+			}
+			if err := output.modeStatements(modes, writer); nil != err {
+				return err
+			}
+			if err := output.agencyStatements(agencies, writer); nil != err {
+				return err
+			}
+			return output.lineStatements(lines, writer)
+		}); nil != err {
+			return fmt.Errorf("Failed to generate rail line SQL statements: %w", err)
+		}
+
+		if err := output.transaction(func(writer io.Writer) error {
+			if err := output.zoneStatements(zones, writer); nil != err {
+				return err
+			}
+			if err := output.complexStatements(complexes, writer); nil != err {
+				return err
+			}
+			if err := output.stationStatements(stations, lines, *networkLinks, strings.TrimSpace(*branchColumn), *groupByTable, writer); nil != err {
+				return err
+			}
+			if *zoneLinks {
+				if err := output.zoneLinkStatements(stations, zones, writer); nil != err {
+					return err
+				}
+			}
+			if err := output.panelStatements(stations, writer); nil != err {
+				return err
+			}
+			if err := output.connectionStatements(connections, writer); nil != err {
+				return err
+			}
+			if err := output.deviceStatements(devices, deviceStationIds, writer); nil != err {
+				return err
+			}
+			return output.entranceStatements(entrances, entranceStationIds, writer)
+		}); nil != err {
+			return fmt.Errorf("Failed to generate station SQL statements: %w", err)
+		}
+		return nil
+	}()
+	if 0 < len(indexes) {
+		// Create the indexes again even when generating the data failed
+		if err := output.write(func(writer io.Writer) error { return createIndexes(writer, indexes) }); nil != err {
+			dataErr = errors.Join(dataErr, fmt.Errorf("Failed to generate index SQL statements: %w", err))
+		}
+	}
+	if *bulkLoad {
+		// Restore the checks even when generating the data failed
+		if err := output.write(func(writer io.Writer) error { return c.bulkLoadEpilogue(writer, *noTransaction) }); nil != err {
+			dataErr = errors.Join(dataErr, fmt.Errorf("Failed to generate bulk load SQL statements: %w", err))
+		}
+	}
+	if nil != dataErr {
+		return errors.Join(dataErr, output.flush())
+	}
+	if *withViews {
+		if err := output.write(c.viewStatements); nil != err {
+			return fmt.Errorf("Failed to generate view SQL statements: %w", err)
+		}
+	}
+	if *assertCounts {
+		// Other rows may already be in the tables when finding by name, letting the
+		// database assign IDs, or continuing from skipped records or networks
+		appending := c.referencesByName() || (*preserveIds && 0 < *skipRows) || 1 != *networkId
+		if err := output.write(func(writer io.Writer) error { return c.countAssertions(writer, planned, appending) }); nil != err {
+			return fmt.Errorf("Failed to generate row count SQL statements: %w", err)
+		}
+	}
+	if *markImport {
+		if err := output.write(func(writer io.Writer) error { return c.importRecord(writer, hash, planned) }); nil != err {
+			return fmt.Errorf("Failed to generate import log SQL statements: %w", err)
+		}
+	}
+	if err := output.flush(); nil != err {
+		return err
+	}
+	if nil != checkpoint {
+		if err := checkpoint.finish(); nil != err {
+			return err
+		}
+	}
+	if nil != database {
+		if err := database.finish(); nil != err {
+			return err
+		}
+	}
+	if nil != chunks {
+		if err := chunks.finish(); nil != err {
+			return err
+		}
+	}
+	clock.end = time.Now()
+	if nil != test {
+		if nil == c.templates.line && nil == c.templates.station && nil == c.templates.link {
+			if err := test.checkCounts(planned); nil != err {
+				return fmt.Errorf("Statements failed the self-test: %w", err)
+			}
+		}
+		if *withViews {
+			if err := test.checkViews(planned); nil != err {
+				return fmt.Errorf("Views failed the self-test: %w", err)
+			}
+		}
+		if _, err := script.WriteTo(stdout); nil != err {
+			return fmt.Errorf("Failed to write statements: %w", err)
+		}
+	}
+	if nil != filter {
+		if err := filter.finish(); nil != err {
+			return err
+		}
+	}
+
+	if *summary || "" != *reportPath {
+		stats := c.newReport(lines, stations)
+		stats.Sampled = sampled
+		stats.FilteredByName, stats.FilteredByStatus = filteredByName, filteredByStatus
+		stats.DuplicateLinks = duplicateLinks
+		stats.EscapedValues = escapeAudit
+		stats.Metrics = newMetrics(clock, rowsParsed, output.statements.writes, output.written.bytes, *metricsFlag)
+		if *summary {
+			if err := stats.writeSummary(stderr); nil != err {
+				return fmt.Errorf("Failed to write summary: %w", err)
+			}
+		}
+		if "" != *reportPath {
+			if err := stats.writeFile(*reportPath, c.logger); nil != err {
+				return fmt.Errorf("Failed to write report: %w", err)
+			}
+		}
+	}
+	if *metricsFlag && !*summary {
+		if err := newMetrics(clock, rowsParsed, output.statements.writes, output.written.bytes, true).writeSummary(stderr); nil != err {
+			return fmt.Errorf("Failed to write metrics: %w", err)
+		}
+	}
+	if *auditEscapesFlag {
+		if err := writeEscapeAudit(stderr, escapeAudit); nil != err {
+			return fmt.Errorf("Failed to write escape audit: %w", err)
+		}
+	}
+	return nil
+}
+
+// A rail line read from the lines CSV.
+type railLine struct {
+	name             string
+	red, green, blue uint8
+	row              int    // Line of the lines CSV the rail line was read from
+	network          int    // Index of the merged network the rail line is from
+	mode             string // Transit mode of the rail line in lower case, like "rail" or "bus", if any
+	agency           string // Agency running the rail line from the agencies CSV, if any
+	fields           []field
+}
+
+// A station read from the stations CSV.
+type station struct {
+	id         int   // Assigned once the stations are in their final order
+	row        int   // Line of the stations CSV the station was read from
+	offset     int64 // Byte offset of the stations CSV after the record of the station
+	network    int   // Index of the merged network the station is from
+	name       string
+	lines      []int // IDs of the rail lines the station is on, in ascending order
+	attributes []attribute
+	fields     []field
+	zone       string         // Name of the alarm zone the station is in, if any
+	complex    string         // Name of the station complex the station belongs to, if any
+	branches   map[int]string // Rail line each combined rail line came from, by rail line ID
+	positions  map[int]int    // Position of the station along each rail line, by rail line ID
+	aliases    []string       // Other names the station is known by
+	panels     []string       // Asset tags of the alarm panels of the station, nil without a panels column
+	names      []translation  // Names of the station in other languages
+	record     []string       // Fields of the stations CSV record, kept for -rejects
+}
+
+// Name of a station in another language, from a translation column.
+type translation struct {
+	language string // BCP 47 tag of the language
+	name     string
+}
+
+// Value for an additional column of the 'Stations' table.
+type field struct {
+	column  string // Name of the column in the 'Stations' table
+	literal string // SQL literal for the value, including NULL
+}
+
+// Arbitrary piece of station metadata from an attribute column.
+type attribute struct {
+	key, value string
+}
+
+// Parse the rail lines CSV into the rows for the 'RailLines' table.
+func (c *conversion) parseLines(reader *csv.Reader) ([]railLine, error) {
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("File is empty, expected a header row of Line,Red,Green,Blue")
+	} else if nil != err {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+	padded := len(header)
+	header = c.trimHeader(header, "rail lines")
+	// Line Name, Red, Green, and Blue, along with any columns without a name
+	var emptyColumns []int
+	for i, entry := range header[1:] {
+		if "" == strings.TrimSpace(entry) {
+			emptyColumns = append(emptyColumns, i+2)
+		}
+	}
+	if named := len(header) - len(emptyColumns); 4 != named {
+		return nil, fmt.Errorf("Expected a header row of Line,Red,Green,Blue, found %d named columns", named)
+	}
+	reader.FieldsPerRecord = len(header)
+	if len(header) < padded {
+		reader.FieldsPerRecord = -1
+	}
+
+	redIndex, greenIndex, blueIndex := 0, 0, 0
+	for i, entry := range header[1:] {
+		entry = strings.TrimSpace(entry)
+		if strings.EqualFold(entry, "Red") {
+			redIndex = i + 1
+		} else if strings.EqualFold(entry, "Green") {
+			greenIndex = i + 1
+		} else if strings.EqualFold(entry, "Blue") {
+			blueIndex = i + 1
+		}
+	}
+
+	if 0 == redIndex {
+		return nil, fmt.Errorf("Failed to find Red column")
+	}
+	if 0 == greenIndex {
+		return nil, fmt.Errorf("Failed to find Green column")
+	}
+	if 0 == blueIndex {
+		return nil, fmt.Errorf("Failed to find Blue column")
+	}
+
+	var lines []railLine
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		lineId := len(lines) + 1
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read record for line %d: %w", lineId, err)
+		}
+		if err := c.nul.apply(reader, record); nil != err {
+			return nil, err
+		}
+		if len(header) < padded {
+			if record, err = trimRecord(reader, record, len(header), padded); nil != err {
+				return nil, fmt.Errorf("Failed to read record for line %d: %w", lineId, err)
+			}
+		}
+
+		lineName := strings.TrimSpace(record[0])
+		if nameLen := len(lineName); 0 >= nameLen {
+			return nil, fmt.Errorf("Invalid name length for line %d: %d", lineId, nameLen)
+		}
+		for _, column := range emptyColumns {
+			if value := strings.TrimSpace(record[column-1]); "" != value {
+				return nil, misalignedColumn(column, value, lineName)
+			}
+		}
+
+		red, err := parseUint8(record[redIndex])
+		if nil != err {
+			return nil, fmt.Errorf("Failed to parse red value for %s: %w", lineName, err)
+		}
+		green, err := parseUint8(record[greenIndex])
+		if nil != err {
+			return nil, fmt.Errorf("Failed to parse green value for %s: %w", lineName, err)
+		}
+		blue, err := parseUint8(record[blueIndex])
+		if nil != err {
+			return nil, fmt.Errorf("Failed to parse blue value for %s: %w", lineName, err)
+		}
+
+		row, _ := reader.FieldPos(0)
+		lines = append(lines, railLine{name: lineName, red: red, green: green, blue: blue, row: row})
+	}
+	c.warnEmptyColumns("rail lines", emptyColumns)
+	return lines, nil
+}
+
+// Parse the stations CSV into the rows for the 'Stations' table along with the
+// rail lines each one is on and any attributes.
+func (c *conversion) parseStations(reader *csv.Reader, options stationOptions) ([]station, error) {
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("File is empty, expected a header row naming the station column and the rail lines")
+	} else if nil != err {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+	if err := c.nul.apply(reader, header); nil != err {
+		return nil, err
+	}
+	c.progress.HeaderOffset = reader.InputOffset()
+	padded := len(header)
+	header = c.trimHeader(header, "stations")
+	firstHeader := strings.TrimSpace(header[0])
+	if "" != options.requireHeader && !strings.EqualFold(options.requireHeader, firstHeader) {
+		return nil, fmt.Errorf("First header cell is %q instead of %q", firstHeader, options.requireHeader)
+	}
+
+	columns, err := parseStationColumns(header, options)
+	if nil != err {
+		return nil, err
+	}
+	reader.FieldsPerRecord = len(header)
+	if len(header) < padded {
+		reader.FieldsPerRecord = -1
+	}
+	if nil != options.rejects {
+		options.rejects.header, options.rejects.comma = header, reader.Comma
+	}
+	// Only the strings of the records are kept, so the slice holding them can be
+	// reused from one row to the next
+	reader.ReuseRecord = true
+	for _, column := range columns {
+		// The rail lines are shared with the caller, who gets the modes this way
+		if "" != column.mode && column.lineId <= len(options.lines) {
+			options.lines[column.lineId-1].mode = column.mode
+		}
+	}
+
+	var stations []station
+	trueCounts := make([]int, len(columns))
+	boolSamples := make([][]boolTokenSample, len(columns))
+	var fileBoolSamples []boolTokenSample
+	skipped := 0
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if 0 < options.limitRows && options.limitRows <= len(stations) {
+			break
+		}
+		stationId := skipped + len(stations) + 1
+		if skipped < options.skipRows && !options.validateSkipped {
+			skipped++
+			continue
+		}
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read record for station %d: %w", stationId, err)
+		}
+		if err := c.nul.apply(reader, record); nil != err {
+			return nil, err
+		}
+		if len(header) < padded {
+			if record, err = trimRecord(reader, record, len(header), padded); nil != err {
+				return nil, fmt.Errorf("Failed to read record for station %d: %w", stationId, err)
+			}
+		}
+
+		stationName := strings.TrimSpace(record[0])
+		if nameLen := len(stationName); 0 >= nameLen {
+			return nil, fmt.Errorf("Invalid name length for station %d: %d", stationId, nameLen)
+		}
+		if _, err := strconv.ParseBool(stationName); nil == err && 1 == stationId && nil == options.skip && slices.ContainsFunc(options.lines,
+			func(line railLine) bool { return strings.EqualFold(line.name, firstHeader) }) {
+			c.warn("shifted", "The first header cell is the rail line %s and the first station is named %s, the columns may be shifted by one", firstHeader, stationName)
+		}
+
+		row, _ := reader.FieldPos(0)
+		current := station{row: row, offset: reader.InputOffset(), name: stationName}
+		if nil != options.skip {
+			current.row, current.offset = row+options.skip.lines, current.offset+options.skip.to-options.skip.from
+		}
+		if nil != options.rejects {
+			// The reader reuses the record for the next row
+			current.record = slices.Clone(record)
+		}
+		for i, column := range columns {
+			value := strings.TrimSpace(record[i+1])
+			if column.ignored {
+				continue
+			} else if column.empty {
+				if "" != value {
+					return nil, &rowError{current.row, i + 2, misalignedColumn(i+2, value, stationName)}
+				}
+			} else if column.zone {
+				current.zone = value
+			} else if column.complex {
+				current.complex = value
+			} else if column.aliases {
+				for _, alias := range strings.Split(value, ";") {
+					if alias = strings.TrimSpace(alias); "" != alias && !slices.Contains(current.aliases, alias) {
+						current.aliases = append(current.aliases, alias)
+					}
+				}
+			} else if column.panels {
+				current.panels = []string{}
+				for _, tag := range strings.Split(value, ";") {
+					if tag = strings.TrimSpace(tag); "" != tag && !slices.Contains(current.panels, tag) {
+						current.panels = append(current.panels, tag)
+					}
+				}
+			} else if "" != column.language {
+				if "" != value {
+					current.names = append(current.names, translation{column.language, value})
+				}
+			} else if "" != column.attribute {
+				if "" != value {
+					current.attributes = append(current.attributes, attribute{column.attribute, value})
+				}
+			} else if nil != column.field {
+				literal := "NULL"
+				if "" != value {
+					if literal, err = column.field.parse(c, value, options); nil != err {
+						return nil, &rowError{current.row, i + 2, fmt.Errorf("Invalid %s %q for %s: %w", column.field.header, record[i+1], stationName, err)}
+					}
+				}
+				current.fields = append(current.fields, field{column.field.column, literal})
+			} else if "sequence" == options.boolStyle {
+				position := 0
+				if "" != value {
+					if position, err = strconv.Atoi(value); nil != err || 0 > position {
+						return nil, &rowError{current.row, i + 2, fmt.Errorf("Invalid position %q for %s, line %s", value, stationName, header[i+1])}
+					}
+				}
+				if 0 < position {
+					current.lines = append(current.lines, column.lineId)
+					current.setPosition(column.lineId, position)
+					trueCounts[i]++
+				}
+			} else if isOnLine, err := strconv.ParseBool(value); nil != err {
+				return nil, &rowError{current.row, i + 2, fmt.Errorf("Failed to parse boolean value for %s, line %s: %w", stationName, header[i+1], err)}
+			} else {
+				if "off" != options.boolScope {
+					sample := boolTokenSample{boolTokenStyle(value), row, strings.TrimSpace(header[i+1])}
+					hasStyle := func(seen boolTokenSample) bool { return sample.style == seen.style }
+					if !slices.ContainsFunc(boolSamples[i], hasStyle) {
+						boolSamples[i] = append(boolSamples[i], sample)
+					}
+					if !slices.ContainsFunc(fileBoolSamples, hasStyle) {
+						fileBoolSamples = append(fileBoolSamples, sample)
+					}
+				}
+				if isOnLine {
+					current.lines = append(current.lines, column.lineId)
+					trueCounts[i]++
+				}
+			}
+		}
+
+		if skipped < options.skipRows {
+			skipped++
+		} else {
+			stations = append(stations, current)
+		}
+	}
+
+	var findings []string
+	for i, column := range columns {
+		// Resuming leaves out the stations before the checkpoint, which passed the
+		// checks of the whole file
+		if 0 < column.lineId && 0 == trueCounts[i] && 0 < len(stations) && nil == options.skip {
+			findings = append(findings, fmt.Sprintf("Every station is false in column %d for rail line %s, it may be stale or inverted", i+2, strings.TrimSpace(header[i+1])))
+		}
+	}
+	if err := c.lint("stale-columns", findings, options.strict); nil != err {
+		return nil, err
+	}
+	var emptyColumns []int
+	for i, column := range columns {
+		if column.empty {
+			emptyColumns = append(emptyColumns, i+2)
+		}
+	}
+	c.warnEmptyColumns("stations", emptyColumns)
+	findings = nil
+	scope := options.boolScope
+	if nil != options.skip {
+		// Mixed spellings may be split either side of the checkpoint
+		scope = "off"
+	}
+	if "file" == scope {
+		findings = mixedBoolStyles("The stations CSV", fileBoolSamples, true)
+	} else if "column" == scope {
+		for i := range columns {
+			findings = append(findings, mixedBoolStyles(fmt.Sprintf("Column %d for rail line %s", i+2, strings.TrimSpace(header[i+1])), boolSamples[i], false)...)
+		}
+	}
+	if err := c.lint("bool-styles", findings, options.strict); nil != err {
+		return nil, err
+	}
+	return stations, nil
+}
+
+// Options for interpreting the columns of the stations CSV.
+type stationOptions struct {
+	zoneColumn    string       // Header name of the alarm zone column
+	maxCapacity   uint64       // Largest occupant capacity accepted for a station
+	statusValues  []string     // Statuses allowed in the status column, in lower case
+	requireHeader string       // Required name of the first header cell, if any
+	lines         []railLine   // Rail lines from the lines CSV, for sanity checks
+	strict        bool         // Whether failed sanity checks are errors instead of warnings
+	boolStyle     string       // How stations are marked as on a rail line: "boolean" or "sequence"
+	boolScope     string       // Where mixed boolean spellings are warned about: "column", "file", or "off"
+	shape         string       // Shape of the stations CSV: "auto" or one of [stationShapes]
+	maxLines      int          // Most rail line columns accepted in the header, or 0 for any number
+	rejects       *rejectsFile // File the records of dropped stations are written to, if any
+
+	skipRows        int  // Number of records after the header to skip
+	limitRows       int  // Maximum number of records to parse after those skipped, or 0 for all
+	validateSkipped bool // Whether the skipped records are still parsed for errors
+
+	skip *inputSkip // Bytes passed over when resuming from a checkpoint, if any
+}
+
+// Wrap [conversion.parseStations] with its options for [parseCsvFileSkipping]
+// and [parseParquetFile]: the first function works out the shape of the file
+// from the start of its text when it is "auto", for the second to parse it.
+func (c *conversion) stationParser(options stationOptions) (func(*bufio.Reader, rune) error, func(*csv.Reader) ([]station, error)) {
+	sniff := func(input *bufio.Reader, comma rune) error {
+		if "auto" != options.shape {
+			return nil
+		}
+		shape, err := c.detectShape(input, comma)
+		if nil != err {
+			return err
+		}
+		if "matrix" != shape && "sequence" == options.boolStyle {
+			return fmt.Errorf("The stations CSV looks like %s, which has no positions for -bool-style sequence, give -stations-format matrix if it is not", shape)
+		}
+		options.shape = shape
+		return nil
+	}
+	return sniff, func(reader *csv.Reader) ([]station, error) {
+		if shape := options.shape; "matrix" != shape {
+			matrix, duplicates, err := listToMatrix(reader, options.lines, "pairs" == shape)
+			if nil != err {
+				return nil, err
+			}
+			reader, c.duplicateLinks = matrix, c.duplicateLinks+duplicates
+		}
+		return c.parseStations(reader, options)
+	}
+}
+
+// How a column of the stations CSV after the station name is interpreted.
+type stationColumn struct {
+	ignored   bool         // Whether the column is left out entirely
+	mode      string       // Transit mode of the rail line given in the header, if any
+	empty     bool         // Whether the column has no name, so it must have no values either
+	zone      bool         // Whether the column is the alarm zone column
+	complex   bool         // Whether the column is the station complex column
+	aliases   bool         // Whether the column is the aliases column
+	panels    bool         // Whether the column is the alarm panels column
+	language  string       // Language tag of the names in the column, if it is a translation column
+	lineId    int          // ID of the rail line the column is for
+	attribute string       // Key of the attribute the column is for, if it is an attribute column
+	field     *fieldColumn // Column of the 'Stations' table the column is for, if any
+}
 
-# Escaping dangerous character for SQL injection
+// Column of the stations CSV that fills an additional column of the 'Stations'
+// table.
+type fieldColumn struct {
+	header     string                                                    // Name of the column in the stations CSV header
+	column     string                                                    // Name of the column in the 'Stations' table
+	definition string                                                    // Type of the column in the 'Stations' table
+	parse      func(*conversion, string, stationOptions) (string, error) // Converts a non-empty cell into a SQL literal
+}
 
-Csv2sql will escape NULL and single quote for string literals inside of SQL
-statements. Other characters like backslash can also be dangerous for certain
-databases like MySQL or MariaDB. However this implementation is kept minimal to
-standard SQL to maximize compatibility. If you are using such a database, if
-will be up to you to appropriately escape the string literals in your CSV.
-*/
-package main
+// Every column of the stations CSV that is recognized as a [fieldColumn].
+var stationFields = []fieldColumn{
+	{"exits", "exit_count", "INTEGER", (*conversion).parsePositiveInt},
+	{"evac_capacity", "evac_capacity", "INTEGER", (*conversion).parsePositiveInt},
+	{"capacity", "capacity", "INTEGER", (*conversion).parseCapacity},
+	{"elevators", "elevators", "INTEGER", (*conversion).parseNonNegativeInt},
+	{"escalators", "escalators", "INTEGER", (*conversion).parseNonNegativeInt},
+	{"accessible", "accessible", "BOOLEAN", (*conversion).parseBoolLiteral},
+	{"underground", "underground", "BOOLEAN", (*conversion).parseBoolLiteral},
+	{"status", "status", "VARCHAR(32)", (*conversion).parseStatus},
+	{"latitude", "latitude", "DOUBLE PRECISION", (*conversion).parseLatitude},
+	{"lat", "latitude", "DOUBLE PRECISION", (*conversion).parseLatitude},
+	{"longitude", "longitude", "DOUBLE PRECISION", (*conversion).parseLongitude},
+	{"lon", "longitude", "DOUBLE PRECISION", (*conversion).parseLongitude},
+}
 
-import (
-	"bufio"
-	"encoding/csv"
-	"flag"
-	"fmt"
-	"io"
-	"log"
-	"os"
-	"strconv"
-	"strings"
-)
+// Header name of the stations CSV column with each station's aliases.
+const aliasesColumn = "aliases"
 
-// Reads rail line and station data from CSV files specified via command-line flags
-// and generates SQL INSERT statements. Output is written to Standard Out.
-func main() {
-	linesPath := flag.String("lines", "lines.csv", "CSV file for the rail lines")
-	stationsPath := flag.String("stations", "stations.csv", "CSV file for the stations")
-	flag.Parse()
+// Prefix of the header names of the stations CSV columns with the names of the
+// stations in another language, followed by its language tag.
+const translationPrefix = "name:"
 
-	writer := bufio.NewWriter(os.Stdout)
-	defer func(writer *bufio.Writer) {
-		if err := writer.Flush(); nil != err {
-			log.Fatalln("Failed to flush writer:", err)
+// Work out what each column of the stations CSV header after the first is for.
+// Columns prefixed with '!' are ignored whatever else they are named, the alarm
+// zone column is named by the options, the [aliasesColumn] has the aliases, the
+// [complexColumn] has the station complexes, the [panelsColumn] has the alarm
+// panels, columns prefixed with '@' are attributes, columns named after one of
+// the [stationFields] fill that column of the 'Stations' table, and the rest
+// are rail lines, which are numbered in order skipping over the other columns.
+func parseStationColumns(header []string, options stationOptions) ([]stationColumn, error) {
+	columns := make([]stationColumn, len(header)-1)
+	lineCount := 0
+	for i, entry := range header[1:] {
+		entry = strings.TrimSpace(entry)
+		if strings.HasPrefix(entry, "!") {
+			columns[i].ignored = true
+		} else if "" == entry {
+			columns[i].empty = true
+		} else if "" != options.zoneColumn && strings.EqualFold(options.zoneColumn, entry) {
+			columns[i].zone = true
+		} else if strings.EqualFold(aliasesColumn, entry) {
+			columns[i].aliases = true
+		} else if strings.EqualFold(complexColumn, entry) {
+			columns[i].complex = true
+		} else if strings.EqualFold(panelsColumn, entry) {
+			columns[i].panels = true
+		} else if len(translationPrefix) <= len(entry) && strings.EqualFold(translationPrefix, entry[:len(translationPrefix)]) {
+			tag, err := language.Parse(strings.TrimSpace(entry[len(translationPrefix):]))
+			if nil != err {
+				return nil, fmt.Errorf("Invalid language tag in column %d: %w", i+2, err)
+			}
+			columns[i].language = tag.String()
+		} else if key, found := strings.CutPrefix(entry, "@"); found {
+			if key = strings.TrimSpace(key); 0 >= len(key) {
+				return nil, fmt.Errorf("Missing attribute name in column %d", i+2)
+			}
+			columns[i].attribute = key
+		} else if index := slices.IndexFunc(stationFields, func(f fieldColumn) bool {
+			return strings.EqualFold(f.header, entry)
+		}); 0 <= index {
+			columns[i].field = &stationFields[index]
+		} else {
+			lineCount++
+			columns[i].lineId = lineCount
+			_, columns[i].mode = splitMode(entry)
 		}
-	}(writer)
-
-	if err := execFromCsvFile(*linesPath, lineStatements, writer); nil != err {
-		log.Fatalln("Failed to generate rail line SQL statements:", err)
 	}
 
-	if err := execFromCsvFile(*stationsPath, stationStatements, writer); nil != err {
-		log.Fatalln("Failed to generate station SQL statements:", err)
+	if 0 >= lineCount {
+		return nil, fmt.Errorf("Network must have at least one rail line")
+	}
+	if 0 < options.maxLines && options.maxLines < lineCount {
+		// Show the first few so that a data row mistaken for the header is obvious
+		var names []string
+		for i, column := range columns {
+			if 0 < column.lineId && len(names) < 5 {
+				names = append(names, strconv.Quote(strings.TrimSpace(header[i+1])))
+			}
+		}
+		return nil, fmt.Errorf("The stations header has %d rail line columns, more than the %d of -max-lines, so it looks wrong. The first are %s. Give a higher -max-lines if the network really has that many rail lines",
+			lineCount, options.maxLines, strings.Join(names, ", "))
 	}
+	return columns, nil
 }
 
-// Generate the SQL statements for populating the 'RailLines' table.
-func lineStatements(reader *csv.Reader, writer io.Writer) error {
-	reader.FieldsPerRecord = 4 // Line Name, Red, Green, and Blue
-	header, err := reader.Read()
-	if nil != err {
-		return fmt.Errorf("Failed to read CSV header: %w", err)
+// Randomly pick size of the stations with reservoir sampling, keeping them in
+// their original order. The same seed always picks the same stations.
+func sampleStations(stations []station, size int, seed uint64) []station {
+	if len(stations) <= size {
+		return stations
 	}
 
-	redIndex, greenIndex, blueIndex := 0, 0, 0
-	for i, entry := range header[1:] {
-		entry = strings.TrimSpace(entry)
-		if strings.EqualFold(entry, "Red") {
-			redIndex = i + 1
-		} else if strings.EqualFold(entry, "Green") {
-			greenIndex = i + 1
-		} else if strings.EqualFold(entry, "Blue") {
-			blueIndex = i + 1
+	random := rand.New(rand.NewPCG(seed, seed))
+	reservoir := make([]int, size)
+	for i := range stations {
+		if i < size {
+			reservoir[i] = i
+		} else if j := random.IntN(i + 1); j < size {
+			reservoir[j] = i
 		}
 	}
 
-	if 0 == redIndex {
-		return fmt.Errorf("Failed to find Red column")
-	}
-	if 0 == greenIndex {
-		return fmt.Errorf("Failed to find Green column")
+	slices.Sort(reservoir)
+	sampled := make([]station, size)
+	for i, index := range reservoir {
+		sampled[i] = stations[index]
 	}
-	if 0 == blueIndex {
-		return fmt.Errorf("Failed to find Blue column")
+	return sampled
+}
+
+// Sort the records by name with compare from [nameComparer].
+func sortByName[T any](records []T, name func(T) string, compare func(string, string) int) {
+	slices.SortStableFunc(records, func(a, b T) int { return compare(name(a), name(b)) })
+}
+
+// Build the comparison of names used for sorting. Without a collator names are
+// compared byte-wise, ignoring case first when foldCase is set. Names that are
+// equal under the collator or when ignoring case are ordered byte-wise so that
+// the result does not depend on the input order.
+func nameComparer(foldCase bool, collator *collate.Collator) func(string, string) int {
+	return func(a, b string) int {
+		if nil != collator {
+			if order := collator.CompareString(a, b); 0 != order {
+				return order
+			}
+		} else if foldCase {
+			if order := strings.Compare(strings.ToLower(a), strings.ToLower(b)); 0 != order {
+				return order
+			}
+		}
+		return strings.Compare(a, b)
 	}
+}
 
-	lineId := 1
-	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+// Build the order of the rail lines (as indices into lines) with the named lines
+// first, in the given order, followed by the remaining lines sorted by name with
+// compare from [nameComparer].
+func explicitLineOrder(lines []railLine, names []string, compare func(string, string) int) ([]int, error) {
+	listed := make([]bool, len(lines))
+	order := make([]int, 0, len(lines))
+	for _, name := range names {
+		index, err := lineIndex(lines, name)
 		if nil != err {
-			return fmt.Errorf("Failed to read record for line %d: %w", lineId, err)
+			return nil, err
 		}
-
-		lineName := strings.TrimSpace(record[0])
-		if nameLen := len(lineName); 0 >= nameLen {
-			return fmt.Errorf("Invalid name length for line %d: %d", lineId, nameLen)
+		if listed[index] {
+			return nil, fmt.Errorf("Rail line %s is listed more than once", name)
 		}
+		listed[index] = true
+		order = append(order, index)
+	}
 
-		red, err := parseUint8(record[redIndex])
-		if nil != err {
-			return fmt.Errorf("Failed to parse red value for %s: %w", lineName, err)
+	var unlisted []int
+	for i := range lines {
+		if !listed[i] {
+			unlisted = append(unlisted, i)
 		}
-		green, err := parseUint8(record[greenIndex])
+	}
+	sortByName(unlisted, func(i int) string { return lines[i].name }, compare)
+	return append(order, unlisted...), nil
+}
+
+// Select the rail lines to keep (as indices into lines) in their input order.
+// When only is empty every line not in exclude is kept.
+func filterLines(lines []railLine, only []string, exclude []string) ([]int, error) {
+	keep := make([]bool, len(lines))
+	for i := range keep {
+		keep[i] = 0 == len(only)
+	}
+	for _, name := range only {
+		index, err := lineIndex(lines, name)
 		if nil != err {
-			return fmt.Errorf("Failed to parse green value for %s: %w", lineName, err)
+			return nil, err
 		}
-		blue, err := parseUint8(record[blueIndex])
+		keep[index] = true
+	}
+	for _, name := range exclude {
+		index, err := lineIndex(lines, name)
 		if nil != err {
-			return fmt.Errorf("Failed to parse blue value for %s: %w", lineName, err)
+			return nil, err
 		}
+		keep[index] = false
+	}
 
-		if _, err = fmt.Fprintf(writer, "INSERT INTO RailLines VALUES (%d, '%s', %d, %d, %d);\n",
-			lineId, escapeSqlString(lineName), red, green, blue); nil != err {
-			return fmt.Errorf("Failed to write line insert statement: %w", err)
+	var kept []int
+	for i := range lines {
+		if keep[i] {
+			kept = append(kept, i)
 		}
-		lineId++
 	}
-	return nil
+	return kept, nil
 }
 
-// Generate the SQL statements for populating the 'Stations' table.
-func stationStatements(reader *csv.Reader, writer io.Writer) error {
-	header, err := reader.Read()
-	if nil != err {
-		return fmt.Errorf("Failed to read CSV header: %w", err)
+// Find the index of the rail line with the given name.
+func lineIndex(lines []railLine, name string) (int, error) {
+	index := slices.IndexFunc(lines, func(line railLine) bool { return line.name == name })
+	if 0 > index {
+		return 0, fmt.Errorf("Rail line %s is not in the lines CSV", name)
 	}
+	return index, nil
+}
 
-	// The header should have at least 2 fields since the first column is the station name.
-	headerNumFields := len(header)
-	if 2 > headerNumFields {
-		return fmt.Errorf("Network must have at least one rail line")
+// Split a comma separated list of names, trimming the whitespace around each.
+func splitList(list string) []string {
+	if "" == strings.TrimSpace(list) {
+		return nil
 	}
-	reader.FieldsPerRecord = headerNumFields
+	names := strings.Split(list, ",")
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+	}
+	return names
+}
 
-	stationId := 1
-	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
-		if nil != err {
-			return fmt.Errorf("Failed to read record for station %d: %w", stationId, err)
+// Rearrange the rail lines into the given order (indices into lines) and remap
+// the rail line IDs of every station to match. Lines missing from the order are
+// dropped along with their stations' links to them.
+func reorderLines(lines []railLine, stations []station, order []int) ([]railLine, error) {
+	reordered := make([]railLine, len(order))
+	newIds := make([]int, len(lines)+1)
+	for i, index := range order {
+		reordered[i] = lines[index]
+		newIds[index+1] = i + 1
+	}
+
+	for i := range stations {
+		positions := stations[i].positions
+		stations[i].positions = nil
+		for j, lineId := range stations[i].lines {
+			if len(lines) < lineId {
+				return nil, fmt.Errorf("Station %s is on rail line %d which is not in the lines CSV", stations[i].name, lineId)
+			}
+			stations[i].lines[j] = newIds[lineId]
+			if position, found := positions[lineId]; found && 0 != newIds[lineId] {
+				stations[i].setPosition(newIds[lineId], position)
+			}
 		}
+		stations[i].lines = slices.DeleteFunc(stations[i].lines, func(lineId int) bool { return 0 == lineId })
+		slices.Sort(stations[i].lines)
+	}
+	return reordered, nil
+}
 
-		stationName := strings.TrimSpace(record[0])
-		if nameLen := len(stationName); 0 >= nameLen {
-			return fmt.Errorf("Invalid name length for station %d: %d", stationId, nameLen)
+// Drop any rail line a station is on more than once, so that no LineStations
+// row is emitted twice, returning how many were dropped.
+func dedupeLinks(stations []station) int {
+	dropped := 0
+	for i := range stations {
+		count := len(stations[i].lines)
+		slices.Sort(stations[i].lines)
+		stations[i].lines = slices.Compact(stations[i].lines)
+		dropped += count - len(stations[i].lines)
+	}
+	return dropped
+}
+
+// Warn about stations whose fields do not add up: transfer stations with only a
+// single exit, accessible stations without any elevators, and underground
+// stations without a depth.
+func (c *conversion) warnInconsistentStations(stations []station) {
+	for _, current := range stations {
+		if 2 <= len(current.lines) && slices.Contains(current.fields, field{"exit_count", "1"}) {
+			c.warn("inconsistent", "Transfer station %s is on %d rail lines but has only ONE exit!", current.name, len(current.lines))
+		}
+		if slices.Contains(current.fields, field{"accessible", "TRUE"}) && slices.Contains(current.fields, field{"elevators", "0"}) {
+			c.warn("inconsistent", "Station %s is marked accessible but has no elevators", current.name)
 		}
+		if slices.Contains(current.fields, field{"underground", "TRUE"}) && !slices.ContainsFunc(current.attributes, func(a attribute) bool { return "depth" == a.key }) {
+			c.warn("inconsistent", "Underground station %s has no depth attribute for its pre-plan", current.name)
+		}
+	}
+}
 
-		if _, err = fmt.Fprintf(writer, "INSERT INTO Stations VALUES (%d, '%s');\n", stationId, escapeSqlString(stationName)); nil != err {
-			return fmt.Errorf("Failed to write station insert statement: %w", err)
+// Generate the SQL statements for populating the 'RailLines' table.
+func (e *emitter) lineStatements(lines []railLine, writer io.Writer) error {
+	fieldColumns := collectFieldColumns(lines, func(line railLine) []field { return line.fields })
+	for i, line := range lines {
+		if nil != e.templates.line {
+			data := lineTemplateData{i + 1, e.escapeForStyle(line.name), line.red, line.green, line.blue}
+			if err := writeTemplate(writer, e.templates.line, data); nil != err {
+				return &rowError{line.row, 0, fmt.Errorf("Failed to write line template statement for row %d: %w", line.row, err)}
+			}
+			continue
+		}
+		if e.referencesByName() {
+			if err := e.writeNamedInsert(writer, "RailLines", line.name, []string{"red", "green", "blue"}, []string{strconv.Itoa(int(line.red)),
+				strconv.Itoa(int(line.green)), strconv.Itoa(int(line.blue))}, fieldColumns, line.fields); nil != err {
+				return &rowError{line.row, 0, fmt.Errorf("Failed to write line insert statement for row %d: %w", line.row, err)}
+			}
+			continue
+		}
+		values := []string{strconv.Itoa(i + 1), e.quoteSqlString(line.name),
+			strconv.Itoa(int(line.red)), strconv.Itoa(int(line.green)), strconv.Itoa(int(line.blue))}
+		if err := e.writeInsert(writer, "RailLines", []string{"id", "name", "red", "green", "blue"},
+			values, fieldColumns, line.fields); nil != err {
+			return &rowError{line.row, 0, fmt.Errorf("Failed to write line insert statement for row %d: %w", line.row, err)}
 		}
+	}
+	return nil
+}
+
+// Generate the SQL statements for populating the 'Stations', 'LineStations',
+// 'StationAliases', 'StationNames', and 'StationAttributes' tables. When
+// networkLinks is set the links get the network_id of their station, and when
+// branchColumn is set the links get a column of that name with the rail line
+// they were combined from, if any. By default the rows for each station follow
+// one another, while with grouped set all of the rows of one table come before
+// those of the next. With [conversion.referencesByName] the rows find their
+// rail line and station by name in the lines.
+func (e *emitter) stationStatements(stations []station, lines []railLine, networkLinks bool, branchColumn string, grouped bool, writer io.Writer) error {
+	fieldColumns := collectFieldColumns(stations, func(s station) []field { return s.fields })
+	tables := []func(station) error{
+		func(current station) error {
+			if nil != e.templates.station {
+				if err := writeTemplate(writer, e.templates.station, stationTemplateData{current.id, e.escapeForStyle(current.name)}); nil != err {
+					return &rowError{current.row, 0, fmt.Errorf("Failed to write station template statement for row %d: %w", current.row, err)}
+				}
+				return nil
+			}
+			if e.referencesByName() {
+				if err := e.writeNamedInsert(writer, "Stations", current.name, nil, nil, fieldColumns, current.fields); nil != err {
+					return &rowError{current.row, 0, fmt.Errorf("Failed to write station insert statement for row %d: %w", current.row, err)}
+				}
+				return nil
+			}
+			if err := e.stationInsert(writer, current.id, current, fieldColumns); nil != err {
+				return &rowError{current.row, 0, fmt.Errorf("Failed to write station insert statement for row %d: %w", current.row, err)}
+			}
+			return nil
+		},
+		func(current station) error {
+			var linkColumns []string
+			var linkFields []field
+			if index := slices.IndexFunc(current.fields, func(f field) bool { return networkIdColumn.name == f.column }); networkLinks && 0 <= index {
+				linkColumns, linkFields = []string{networkIdColumn.name}, current.fields[index:index+1]
+			}
+			if "" != branchColumn {
+				linkColumns = append(linkColumns, branchColumn)
+			}
+			for _, lineId := range current.lines {
+				if nil != e.templates.link {
+					if err := writeTemplate(writer, e.templates.link, linkTemplateData{lineId, current.id}); nil != err {
+						return &rowError{current.row, 0, fmt.Errorf("Failed to write link template statement for row %d: %w", current.row, err)}
+					}
+					e.progress.link(lineId)
+					continue
+				}
+				fields := linkFields
+				if branch, found := current.branches[lineId]; found && "" != branchColumn {
+					fields = append(slices.Clip(fields), field{branchColumn, e.quoteSqlString(branch)})
+				}
+				if e.referencesByName() {
+					if err := e.writeLinkByName(writer, lines[lineId-1].name, current.name, linkColumns, fields); nil != err {
+						return &rowError{current.row, 0, fmt.Errorf("Failed to write link statement for row %d: %w", current.row, err)}
+					}
+					e.progress.link(lineId)
+					continue
+				}
+				var err error
+				if 0 == len(linkColumns) {
+					err = e.linkInsert(writer, lineId, current.id)
+				} else {
+					err = e.writeInsert(writer, "LineStations", []string{"line_id", "station_id"},
+						[]string{strconv.Itoa(lineId), strconv.Itoa(current.id)}, linkColumns, fields)
+				}
+				if nil != err {
+					return &rowError{current.row, 0, fmt.Errorf("Failed to write link statement for row %d: %w", current.row, err)}
+				}
+				e.progress.link(lineId)
+			}
+			return nil
+		},
+		func(current station) error {
+			for _, alias := range current.aliases {
+				if e.referencesByName() {
+					if err := e.writeStationRowByName(writer, "StationAliases", current.name, []string{"station_id", "alias"},
+						[]string{"s.id", e.quoteSqlString(alias)}, []string{"station_id", "alias"}); nil != err {
+						return &rowError{current.row, 0, fmt.Errorf("Failed to write alias statement for row %d: %w", current.row, err)}
+					}
+					continue
+				}
+				if err := e.writeInsert(writer, "StationAliases", []string{"station_id", "alias"},
+					[]string{strconv.Itoa(current.id), e.quoteSqlString(alias)}, nil, nil); nil != err {
+					return &rowError{current.row, 0, fmt.Errorf("Failed to write alias statement for row %d: %w", current.row, err)}
+				}
+			}
+			return nil
+		},
+		func(current station) error {
+			for _, name := range current.names {
+				if e.referencesByName() {
+					if err := e.writeStationRowByName(writer, "StationNames", current.name, []string{"station_id", "lang", "name"},
+						[]string{"s.id", e.quoteSqlString(name.language), e.quoteSqlString(name.name)}, []string{"station_id", "lang"}); nil != err {
+						return &rowError{current.row, 0, fmt.Errorf("Failed to write translated name statement for row %d: %w", current.row, err)}
+					}
+					continue
+				}
+				if err := e.writeInsert(writer, "StationNames", []string{"station_id", "lang", "name"},
+					[]string{strconv.Itoa(current.id), e.quoteSqlString(name.language), e.quoteSqlString(name.name)}, nil, nil); nil != err {
+					return &rowError{current.row, 0, fmt.Errorf("Failed to write translated name statement for row %d: %w", current.row, err)}
+				}
+			}
+			return nil
+		},
+		func(current station) error {
+			for _, attr := range current.attributes {
+				if e.referencesByName() {
+					if err := e.writeStationRowByName(writer, "StationAttributes", current.name, []string{"station_id", "name", "value"},
+						[]string{"s.id", e.quoteSqlString(attr.key), e.quoteSqlString(attr.value)}, []string{"station_id", "name"}); nil != err {
+						return &rowError{current.row, 0, fmt.Errorf("Failed to write attribute statement for row %d: %w", current.row, err)}
+					}
+					continue
+				}
+				if err := e.writeInsert(writer, "StationAttributes", []string{"station_id", "name", "value"},
+					[]string{strconv.Itoa(current.id), e.quoteSqlString(attr.key), e.quoteSqlString(attr.value)}, nil, nil); nil != err {
+					return &rowError{current.row, 0, fmt.Errorf("Failed to write attribute statement for row %d: %w", current.row, err)}
+				}
+			}
+			return nil
+		},
+	}
 
-		for lineId, onLine := range record[1:] {
-			if isOnLine, err := strconv.ParseBool(strings.TrimSpace(onLine)); nil != err {
-				return fmt.Errorf("Failed to parse boolean value for %s, line %s: %w", stationName, header[lineId+1], err)
-			} else if isOnLine {
-				if _, err = fmt.Fprintf(writer, "INSERT INTO LineStations VALUES (%d, %d);\n", lineId+1, stationId); nil != err {
-					return fmt.Errorf("Failed to write link statement: %w", err)
+	if grouped {
+		for _, table := range tables {
+			for _, current := range stations {
+				if err := table(current); nil != err {
+					return err
 				}
 			}
 		}
-		stationId++
+		return nil
+	}
+	for _, current := range stations {
+		e.progress.begin(current.id)
+		for _, table := range tables {
+			if err := table(current); nil != err {
+				return err
+			}
+		}
+		e.progress.station(current)
 	}
 	return nil
 }
 
-// Escape specific characters from the statement before passing it to the SQL string.
-// Currently only "NUL -> <empty>" and "<single quote> -> <single quote><single quote>"
-// are the only pairs but more can be added by appending them to the argument for
-// [strings.NewReplacer].
-func escapeSqlString(statement string) string {
-	return strings.NewReplacer("\x00", "", "'", "''").Replace(statement)
+// List the additional columns filled by any of the rows, in the order they are
+// first seen.
+func collectFieldColumns[T any](rows []T, fields func(T) []field) []string {
+	var columns []string
+	for _, row := range rows {
+		for _, f := range fields(row) {
+			if !slices.Contains(columns, f.column) {
+				columns = append(columns, f.column)
+			}
+		}
+	}
+	return columns
+}
+
+// Write the insert statement for a single station.
+func (e *emitter) stationInsert(writer io.Writer, stationId int, current station, fieldColumns []string) error {
+	// Only the general insert records the row for the hook
+	if !e.plainInserts() || nil != e.hook {
+		return e.writeInsert(writer, "Stations", []string{"id", "name"},
+			[]string{strconv.Itoa(stationId), e.quoteSqlString(current.name)}, fieldColumns, current.fields)
+	}
+	buffer := append(e.insert[:0], "INSERT INTO Stations "...)
+	if 0 < len(fieldColumns) {
+		buffer = append(buffer, "(id, name"...)
+		for _, column := range fieldColumns {
+			buffer = append(append(buffer, ", "...), column...)
+		}
+		buffer = append(buffer, ") "...)
+	}
+	buffer = strconv.AppendInt(append(buffer, "VALUES ("...), int64(stationId), 10)
+	buffer = e.appendSqlString(append(buffer, ", "...), current.name)
+	for _, column := range fieldColumns {
+		buffer = append(buffer, ", "...)
+		if index := slices.IndexFunc(current.fields, func(f field) bool { return column == f.column }); 0 <= index {
+			buffer = append(buffer, current.fields[index].literal...)
+		} else {
+			buffer = append(buffer, "NULL"...)
+		}
+	}
+	return e.writeInsertBuffer(writer, append(buffer, ");\n"...))
+}
+
+// Write the insert linking the station to the rail line into the
+// 'LineStations' table, without any additional columns.
+func (e *emitter) linkInsert(writer io.Writer, lineId int, stationId int) error {
+	if !e.plainInserts() || nil != e.hook {
+		return e.writeInsert(writer, "LineStations", []string{"line_id", "station_id"},
+			[]string{strconv.Itoa(lineId), strconv.Itoa(stationId)}, nil, nil)
+	}
+	buffer := strconv.AppendInt(append(e.insert[:0], "INSERT INTO LineStations VALUES ("...), int64(lineId), 10)
+	buffer = strconv.AppendInt(append(buffer, ", "...), int64(stationId), 10)
+	return e.writeInsertBuffer(writer, append(buffer, ");\n"...))
+}
+
+// Whether inserts are written as they are, rather than as a MERGE or adapted to
+// a schema.
+func (c *conversion) plainInserts() bool {
+	return "merge" != c.upsert && nil == c.schema
+}
+
+// Write the insert built in the insert buffer of the emitter, keeping the buffer
+// for the next.
+func (e *emitter) writeInsertBuffer(writer io.Writer, buffer []byte) error {
+	e.insert = buffer[:0]
+	_, err := writer.Write(buffer)
+	return err
+}
+
+// Write an insert statement into the table with the given values for its main
+// columns followed by the additional field columns. When there are additional
+// columns the statement lists its columns explicitly so that it does not depend
+// on their order in the table, with NULL for any the row does not fill. With a
+// schema the statement is adapted to it by [conversion.schemaInsert], and with
+// -upsert merge it is a MERGE by [emitter.writeMerge] instead.
+func (e *emitter) writeInsert(writer io.Writer, table string, columns []string, values []string, fieldColumns []string, fields []field) error {
+	columns, values = appendFields(columns, values, fieldColumns, fields)
+	e.inserting(table, columns, values)
+	if "merge" == e.upsert {
+		return e.writeMerge(writer, table, columns, values)
+	}
+	if nil != e.schema {
+		_, err := io.WriteString(writer, e.schemaInsert(table, columns, values))
+		return err
+	}
+	buffer := append(append(e.insert[:0], "INSERT INTO "...), table...)
+	if 0 < len(fieldColumns) {
+		buffer = append(appendJoined(append(buffer, " ("...), columns), ')')
+	}
+	buffer = appendJoined(append(buffer, " VALUES ("...), values)
+	return e.writeInsertBuffer(writer, append(buffer, ");\n"...))
+}
+
+// Append the strings to the buffer separated by commas.
+func appendJoined(buffer []byte, elements []string) []byte {
+	for i, element := range elements {
+		if 0 < i {
+			buffer = append(buffer, ", "...)
+		}
+		buffer = append(buffer, element...)
+	}
+	return buffer
+}
+
+// Append the additional field columns to the main columns and their literals to
+// the values, with NULL for any the row does not fill.
+func appendFields(columns []string, values []string, fieldColumns []string, fields []field) ([]string, []string) {
+	for _, column := range fieldColumns {
+		literal := "NULL"
+		if index := slices.IndexFunc(fields, func(f field) bool { return column == f.column }); 0 <= index {
+			literal = fields[index].literal
+		}
+		columns = append(slices.Clip(columns), column)
+		values = append(slices.Clip(values), literal)
+	}
+	return columns, values
+}
+
+// Converts decimal string of a positive integer to a SQL literal.
+func (c *conversion) parsePositiveInt(s string, _ stationOptions) (string, error) {
+	number, err := strconv.ParseUint(s, 10, 32)
+	if nil != err {
+		return "", err
+	}
+	if 0 == number {
+		return "", fmt.Errorf("Must be positive: %s", s)
+	}
+	return strconv.FormatUint(number, 10), nil
+}
+
+// Converts decimal string of a non-negative integer to a SQL literal.
+func (c *conversion) parseNonNegativeInt(s string, _ stationOptions) (string, error) {
+	number, err := strconv.ParseUint(s, 10, 32)
+	if nil != err {
+		return "", err
+	}
+	return strconv.FormatUint(number, 10), nil
+}
+
+// Converts a boolean literal accepted by [strconv.ParseBool] to a SQL literal.
+func (c *conversion) parseBoolLiteral(s string, _ stationOptions) (string, error) {
+	value, err := strconv.ParseBool(s)
+	if nil != err {
+		return "", err
+	}
+	return strings.ToUpper(strconv.FormatBool(value)), nil
+}
+
+// Converts decimal string of a station's occupant capacity to a SQL literal,
+// rejecting any over the configured maximum as likely typos.
+func (c *conversion) parseCapacity(s string, options stationOptions) (string, error) {
+	literal, err := c.parsePositiveInt(s, options)
+	if nil != err {
+		return "", err
+	}
+	if capacity, _ := strconv.ParseUint(literal, 10, 64); options.maxCapacity < capacity {
+		return "", fmt.Errorf("Exceeds the maximum capacity of %d", options.maxCapacity)
+	}
+	return literal, nil
 }
 
 // Converts decimal string of a number to a unsigned byte.
@@ -228,33 +3813,128 @@ func parseUint8(s string) (uint8, error) {
 	return uint8(number), err
 }
 
-// Manages file operations for [performTransaction].
-func execFromCsvFile(path string, statements csv2sqlStatements, writer io.Writer) error {
-	file, err := os.Open(path)
+// Manages file operations for parsing a CSV file, reading Standard In when the
+// path is "-", fetching it when the path is an HTTP(S) URL, reading the embedded
+// copy when it is a built-in dataset, and decompressing it as needed.
+func parseCsvFile[T any](c *conversion, path string, parse func(*csv.Reader) (T, error)) (T, error) {
+	return parseCsvFileSkipping(c, path, nil, nil, parse)
+}
+
+// Parse the CSV file like [parseCsvFile], passing over the bytes given by skip
+// when it is not nil, and first handing the start of the text and its delimiter
+// to sniff when it is not nil.
+func parseCsvFileSkipping[T any](c *conversion, path string, skip *inputSkip, sniff func(*bufio.Reader, rune) error, parse func(*csv.Reader) (T, error)) (T, error) {
+	var zero T
+	var input io.Reader = os.Stdin
+	if isUrl(path) {
+		body, err := c.fetchUrl(path)
+		if nil != err {
+			return zero, err
+		}
+		defer func(body io.ReadCloser, path string) {
+			if err := body.Close(); nil != err {
+				c.logger.Printf("Failed to close %s: %v\n", path, err)
+			}
+		}(body, path)
+		input = body
+	} else if inline, ok := c.inlineInput(path); ok {
+		input = inline
+	} else if isBuiltin(path) {
+		file, err := openBuiltin(path)
+		if nil != err {
+			return zero, err
+		}
+		defer func(file fs.File, path string) {
+			if err := file.Close(); nil != err {
+				c.logger.Printf("Failed to close %s: %v\n", path, err)
+			}
+		}(file, path)
+		input = file
+	} else if "-" != path {
+		file, err := os.Open(path)
+		if nil != err {
+			return zero, fmt.Errorf("Failed to open %s: %w", path, err)
+		}
+		defer func(file *os.File, path string) {
+			if err := file.Close(); nil != err {
+				c.logger.Printf("Failed to close %s: %v\n", path, err)
+			}
+		}(file, path)
+		input = file
+	}
+
+	input, err := c.decompress(input, path)
 	if nil != err {
-		return fmt.Errorf("Failed to open %s: %w", path, err)
+		return zero, err
 	}
-	defer func(file *os.File, path string) {
-		if err := file.Close(); nil != err {
-			log.Printf("Failed to close %s: %v\n", path, err)
+	if input, err = c.decode(input, path); nil != err {
+		return zero, err
+	}
+	if nil != skip {
+		skip.reader = input
+		input = skip
+	}
+	buffered := bufio.NewReaderSize(input, sniffBytes)
+	comma := c.delimiter
+	if 0 == comma {
+		if comma, err = c.sniffDelimiter(buffered, path); nil != err {
+			return zero, err
 		}
-	}(file, path)
-
-	reader := csv.NewReader(file)
+	}
+	if nil != sniff {
+		if err := sniff(buffered, comma); nil != err {
+			return zero, err
+		}
+	}
+	reader := csv.NewReader(buffered)
+	reader.Comma = comma
+	reader.Comment = c.comment
 	reader.TrimLeadingSpace = true
-	return performTransaction(statements, reader, writer)
+	return parse(reader)
+}
+
+// Writer that rejects any statement longer than the limit in bytes, not counting
+// the trailing newline. Every write is expected to be exactly one statement.
+type statementLimiter struct {
+	writer io.Writer
+	limit  int
+}
+
+func (limiter statementLimiter) Write(statement []byte) (int, error) {
+	if length := len(bytes.TrimSuffix(statement, []byte("\n"))); limiter.limit < length {
+		return 0, fmt.Errorf("Statement is %d bytes, over the maximum of %d", length, limiter.limit)
+	}
+	return limiter.writer.Write(statement)
+}
+
+// Writer that rejects any statement failing [checkSyntax] in the dialect. Every
+// write is expected to be one or more complete statements.
+type syntaxChecker struct {
+	writer  io.Writer
+	dialect string
+}
+
+func (checker syntaxChecker) Write(statement []byte) (int, error) {
+	if err := checkSyntax(string(statement), checker.dialect); nil != err {
+		return 0, fmt.Errorf("Invalid syntax in %q: %w", bytes.TrimSuffix(statement, []byte("\n")), err)
+	}
+	return checker.writer.Write(statement)
 }
 
-// Function prototype for generating SQL statements from a CSV.
-type csv2sqlStatements func(*csv.Reader, io.Writer) error
+// Function prototype for generating SQL statements.
+type csv2sqlStatements func(io.Writer) error
 
-// Wraps the SQL statements generator functions in a SQL transaction and returns any error from them.
-func performTransaction(statements csv2sqlStatements, reader *csv.Reader, writer io.Writer) error {
-	if _, err := fmt.Fprintln(writer, "BEGIN;"); nil != err {
+// Wraps the SQL statements generator functions in a SQL transaction and returns
+// any error from them.
+func (c *conversion) performTransaction(statements csv2sqlStatements, writer io.Writer) error {
+	if "" == c.beginKeyword {
+		return statements(writer)
+	}
+	if _, err := fmt.Fprintf(writer, "%s;\n", c.beginKeyword); nil != err {
 		return fmt.Errorf("Failed to begin SQL transaction: %w", err)
 	}
 
-	err := statements(reader, writer)
+	err := statements(writer)
 	conclusion := "ROLLBACK"
 	if nil == err {
 		conclusion = "COMMIT"
@@ -265,3 +3945,168 @@ func performTransaction(statements csv2sqlStatements, reader *csv.Reader, writer
 	}
 	return err
 }
+
+// Set the begin keyword of the conversion from the -no-transaction and
+// -begin-keyword flags.
+func (c *conversion) setTransactions(flags *flag.FlagSet, noTransaction bool, begin string) error {
+	c.beginKeyword = strings.TrimSpace(begin)
+	if noTransaction {
+		given := false
+		flags.Visit(func(f *flag.Flag) { given = given || "begin-keyword" == f.Name })
+		if given {
+			return fmt.Errorf("A begin keyword cannot be given with -no-transaction")
+		}
+		c.beginKeyword = ""
+	} else if "" == c.beginKeyword {
+		return fmt.Errorf("Missing begin keyword")
+	}
+	return nil
+}
+
+// Destination of the generated SQL statements, buffering them on their way to
+// the output.
+type emitter struct {
+	*conversion
+	buffer     *bufio.Writer
+	output     io.Writer       // Where the statements are written, through the buffer unless discarded
+	recorded   *bytes.Buffer   // Every statement written so far when self-checking, otherwise nil
+	statements *countingWriter // Counts every statement generated
+	written    *countingWriter // Counts the bytes written out
+	insert     []byte          // Insert statement being built, kept between statements so that its memory is reused
+	row        insertedRow     // Row being written, for the hook of the conversion
+	merge      mergeBatch      // Rows held back to be written as one MERGE with -batch-size
+
+	maxStatementBytes int // Longest statement in bytes, or 0 for no limit
+}
+
+// Writer ending every statement with a carriage return and line feed instead of
+// a line feed, leaving any line feeds inside its string literals alone. Every
+// write is expected to be exactly one statement.
+type crlfWriter struct {
+	writer io.Writer
+}
+
+func (w crlfWriter) Write(statement []byte) (int, error) {
+	body, found := bytes.CutSuffix(statement, []byte("\n"))
+	if !found {
+		return w.writer.Write(statement)
+	}
+	if _, err := w.writer.Write(body); nil != err {
+		return 0, err
+	}
+	if _, err := io.WriteString(w.writer, "\r\n"); nil != err {
+		return len(body), err
+	}
+	return len(statement), nil
+}
+
+// How an [emitter] writes the statements.
+type emitterOptions struct {
+	dryRun            bool          // Whether every statement is discarded instead of written
+	maxStatementBytes int           // Longest statement in bytes, or 0 for no limit
+	selfCheck         bool          // Whether every transaction is checked with [checkRows] before it is committed
+	crlf              bool          // Whether every line ends with a carriage return and line feed
+	checkSyntax       bool          // Whether every statement is checked with [checkSyntax]
+	selfTest          *selfTest     // Database to execute every statement against, if any
+	checkpoint        *checkpointer // Checkpointer of the output file, if any
+	database          *sqliteOutput // Database every statement is executed in instead of being written, if any
+	chunks            *chunkWriter  // Files the statements are split into instead of being written, if any
+}
+
+// Create an emitter writing to writer, or discarding every statement when
+// dryRun is set. When maxStatementBytes is positive any longer statement is an
+// error. When selfCheck is set every transaction is checked with [checkRows]
+// against the statements written so far before it is committed. When crlf is
+// set every line ends with a carriage return and line feed. When checkSyntax is
+// set a statement that fails [checkSyntax] is an error. When selfTest is set
+// every statement is executed against its database before it is written. When
+// checkpoint is set it sees every statement written out. When database is set
+// every statement is executed in it instead of being written, and when chunks
+// is set every statement goes to its files instead.
+func (c *conversion) newEmitter(writer io.Writer, options emitterOptions) *emitter {
+	e := &emitter{conversion: c, written: &countingWriter{writer: writer}, maxStatementBytes: options.maxStatementBytes}
+	e.buffer = bufio.NewWriter(e.written)
+	e.output = e.buffer
+	if options.crlf {
+		e.output = crlfWriter{e.output}
+	}
+	if nil != options.checkpoint {
+		options.checkpoint.writer, options.checkpoint.flush = e.output, e.buffer.Flush
+		e.output = options.checkpoint
+	}
+	if options.dryRun {
+		e.output = io.Discard
+	}
+	if nil != options.database {
+		e.output = options.database
+	}
+	if nil != options.chunks {
+		e.output = options.chunks
+	}
+	if options.selfCheck {
+		e.recorded = new(bytes.Buffer)
+		e.output = io.MultiWriter(e.output, e.recorded)
+	}
+	if 0 < options.maxStatementBytes {
+		e.output = statementLimiter{e.output, options.maxStatementBytes}
+	}
+	if nil != options.selfTest {
+		options.selfTest.writer = e.output
+		e.output = options.selfTest
+	}
+	if options.checkSyntax {
+		e.output = syntaxChecker{e.output, c.dialect}
+	}
+	if nil != c.hook {
+		e.output = statementHookWriter{e.output, e}
+	}
+	e.statements = &countingWriter{writer: e.output}
+	e.output = e.statements
+	if 1 < c.batchSize {
+		e.output = mergeFlusher{e.output, e}
+	}
+	return e
+}
+
+// Generate the statements in a transaction with
+// [conversion.performTransaction]. When they fail, everything written so far
+// including the ROLLBACK is flushed so that the output shows where the failure
+// happened.
+func (e *emitter) transaction(statements csv2sqlStatements) error {
+	err := e.performTransaction(func(writer io.Writer) error {
+		if err := statements(writer); nil != err || nil == e.recorded {
+			return err
+		}
+		rows, err := e.parseStatements(e.recorded.String())
+		if nil != err {
+			return fmt.Errorf("Failed to parse the statements for the self-check: %w", err)
+		}
+		if err := checkRows(rows); nil != err {
+			return fmt.Errorf("Statements failed the self-check: %w", err)
+		}
+		return nil
+	}, e.output)
+	if nil != err {
+		if flushErr := e.flush(); nil != flushErr {
+			return errors.Join(err, flushErr)
+		}
+	}
+	return err
+}
+
+// Generate the statements outside of any transaction.
+func (e *emitter) write(statements csv2sqlStatements) error {
+	return statements(e.output)
+}
+
+// Write out every buffered statement, along with any rows held back for a
+// MERGE.
+func (e *emitter) flush() error {
+	if err := e.writeMergeBatch(e.output); nil != err {
+		return err
+	}
+	if err := e.buffer.Flush(); nil != err {
+		return fmt.Errorf("Failed to flush writer: %w", err)
+	}
+	return nil
+}
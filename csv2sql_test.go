@@ -0,0 +1,507 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+var update = flag.Bool("update", false, "Rewrite the golden files in testdata with the current output")
+
+// Absolute path of the golden files, resolved before any test changes into a
+// temporary directory.
+var testdata, _ = filepath.Abs("testdata")
+
+// Rail lines shared by most of the tests.
+const testLines = "Line,Red,Green,Blue\nRuby,255,0,0\nEmerald,0,255,0\n"
+
+// Write the files to a temporary directory and change into it, so that the
+// arguments of a test can name them without a path.
+func writeFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); nil != err {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+}
+
+// Run a conversion with the arguments, returning Standard Out, Standard Error,
+// and the error of run.
+func runArgs(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := run(args, &stdout, &stderr)
+	return stdout.String(), stderr.String(), err
+}
+
+// Compare the output to the golden file of the name in testdata, rewriting it
+// instead with -update.
+func checkGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join(testdata, name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); nil != err {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if string(want) != got {
+		t.Errorf("Output differs from %s:\n--- got ---\n%s\n--- want ---\n%s", name, got, want)
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		stations string
+		lines    string
+		args     []string
+		err      string // Text the error must contain
+		output   string // Everything written to Standard Out before failing
+	}{
+		{
+			name:     "missing file",
+			stations: "",
+			args:     []string{"-stations", "missing.csv"},
+			err:      "Failed to open missing.csv",
+		},
+		{
+			name:     "empty stations",
+			stations: "",
+			err:      "File is empty, expected a header row",
+		},
+		{
+			name:     "header only",
+			stations: "Station,Ruby,Emerald\n",
+			err:      "No station records found",
+		},
+		{
+			name:     "bad boolean",
+			stations: "Station,Ruby,Emerald\nFoo,maybe,false\n",
+			err:      `Failed to parse boolean value for Foo, line Ruby: strconv.ParseBool: parsing "maybe"`,
+		},
+		{
+			name:     "short record",
+			stations: "Station,Ruby,Emerald\nFoo,true\n",
+			err:      "wrong number of fields",
+		},
+		{
+			name:     "color out of range",
+			lines:    "Line,Red,Green,Blue\nRuby,256,0,0\n",
+			stations: "Station,Ruby\nFoo,true\n",
+			err:      `Failed to parse red value for Ruby`,
+		},
+		{
+			name:     "strict warning",
+			stations: "Station,Ruby,Emerald\nFoo,true,false\n",
+			args:     []string{"-strict"},
+			err:      "Every station is false in column 3 for rail line Emerald",
+		},
+		{
+			name:     "statement too long",
+			stations: "Station,Ruby,Emerald\nFoo,true,false\nA Very Long Station Name Indeed,true,true\n",
+			args:     []string{"-max-statement-bytes", "60"},
+			err:      "Failed to write station insert statement for row 3: Statement is 67 bytes, over the maximum of 60",
+			output: "BEGIN;\n" +
+				"INSERT INTO RailLines VALUES (1, 'Ruby', 255, 0, 0);\n" +
+				"INSERT INTO RailLines VALUES (2, 'Emerald', 0, 255, 0);\n" +
+				"COMMIT;\n" +
+				"BEGIN;\n" +
+				"INSERT INTO Stations VALUES (1, 'Foo');\n" +
+				"INSERT INTO LineStations VALUES (1, 1);\n" +
+				"ROLLBACK;\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lines := test.lines
+			if "" == lines {
+				lines = testLines
+			}
+			writeFiles(t, map[string]string{"lines.csv": lines, "stations.csv": test.stations})
+			stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv"}, test.args...)...)
+			if nil == err {
+				t.Fatalf("Expected an error containing %q, got none", test.err)
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected an error containing %q, got %q", test.err, err)
+			}
+			if test.output != stdout {
+				t.Errorf("Unexpected output before the error:\n%s\nwant:\n%s", stdout, test.output)
+			}
+		})
+	}
+}
+
+func TestRunUsage(t *testing.T) {
+	_, stderr, err := runArgs(t, "-bogus")
+	if errUsage != err {
+		t.Errorf("Expected errUsage, got %v", err)
+	}
+	if !strings.Contains(stderr, "flag provided but not defined: -bogus") {
+		t.Errorf("Expected the usage on Standard Error, got %q", stderr)
+	}
+}
+
+func TestRunGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nBar's,true,true\n"})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "basic.sql", stdout)
+}
+
+func TestCollation(t *testing.T) {
+	names := []string{"Zoo", "Ärger", "apple", "Bahn"}
+	tests := []struct {
+		collate string
+		want    []string
+	}{
+		{"", []string{"Bahn", "Zoo", "apple", "Ärger"}},
+		{"en", []string{"apple", "Ärger", "Bahn", "Zoo"}},
+		{"de", []string{"apple", "Ärger", "Bahn", "Zoo"}},
+		{"sv", []string{"apple", "Bahn", "Zoo", "Ärger"}}, // Swedish sorts Ä after Z
+	}
+	for _, test := range tests {
+		t.Run(test.collate, func(t *testing.T) {
+			var collator *collate.Collator
+			if "" != test.collate {
+				collator = collate.New(language.MustParse(test.collate))
+			}
+			sorted := slices.Clone(names)
+			sortByName(sorted, func(name string) string { return name }, nameComparer(false, collator))
+			if !slices.Equal(test.want, sorted) {
+				t.Errorf("Sorted %v, want %v", sorted, test.want)
+			}
+		})
+	}
+}
+
+func TestCollateStations(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nZoo,true,true\nÄrger,true,false\napple,false,true\n"})
+	for _, locale := range []string{"en", "de"} {
+		stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-sort-stations", "name", "-collate", locale)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if apple, arger, zoo := strings.Index(stdout, "'apple'"), strings.Index(stdout, "'Ärger'"), strings.Index(stdout, "'Zoo'"); apple > arger || arger > zoo {
+			t.Errorf("Stations not in %s order:\n%s", locale, stdout)
+		}
+	}
+}
+
+func TestExplicitLineOrder(t *testing.T) {
+	lines := []railLine{{name: "Zoo"}, {name: "Ärger"}, {name: "Bahn"}, {name: "apple"}}
+	order, err := explicitLineOrder(lines, []string{"Bahn"}, nameComparer(false, collate.New(language.German)))
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := []int{2, 3, 1, 0}; !slices.Equal(want, order) {
+		t.Errorf("Ordered %v, want %v", order, want)
+	}
+	if _, err := explicitLineOrder(lines, []string{"Bahn", "Bahn"}, strings.Compare); nil == err {
+		t.Error("Expected an error for a rail line listed twice")
+	}
+}
+
+func TestRunResetsComment(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\n#1 Street,true,true\n"})
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-comment", "#", "-allow-empty"); nil != err {
+		t.Fatal(err)
+	}
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "'#1 Street'") {
+		t.Errorf("The -comment of the previous run dropped a station:\n%s", stdout)
+	}
+}
+
+func TestTransactionsGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n"})
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"begin", nil},
+		{"no-transaction", []string{"-no-transaction"}},
+		{"start-transaction", []string{"-begin-keyword", "START TRANSACTION"}},
+		{"begin-transaction", []string{"-begin-keyword", " BEGIN TRANSACTION "}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv"}, test.args...)...)
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, "transactions/"+test.name+".sql", stdout)
+		})
+	}
+
+	_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-no-transaction", "-begin-keyword", "START TRANSACTION")
+	if nil == err || !strings.Contains(err.Error(), "A begin keyword cannot be given with -no-transaction") {
+		t.Errorf("Expected the begin keyword to be rejected with -no-transaction, got %v", err)
+	}
+}
+
+// A statement of exactly the maximum passes, one byte longer fails, and the
+// newline ending it does not count.
+func TestStatementLimiterBoundary(t *testing.T) {
+	statement := "INSERT INTO Stations VALUES (1, 'Foo');"
+	for _, test := range []struct {
+		limit int
+		fails bool
+	}{
+		{len(statement) + 1, false},
+		{len(statement), false},
+		{len(statement) - 1, true},
+	} {
+		var output bytes.Buffer
+		_, err := statementLimiter{&output, test.limit}.Write([]byte(statement + "\n"))
+		if test.fails != (nil != err) {
+			t.Errorf("Limit of %d for a statement of %d bytes gave %v", test.limit, len(statement), err)
+		}
+		if !test.fails && statement+"\n" != output.String() {
+			t.Errorf("Expected the statement to be written as it is, got %q", output.String())
+		}
+	}
+}
+
+// A station insert of exactly the maximum passes, also with CRLF newlines, and
+// one byte over names the row of the station.
+func TestMaxStatementBytes(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nA Very Long Station's Name,true,true\n"})
+	longest := len("INSERT INTO Stations VALUES (2, 'A Very Long Station''s Name');")
+	for _, newline := range []string{"lf", "crlf"} {
+		if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-max-statement-bytes", strconv.Itoa(longest), "-newline", newline); nil != err {
+			t.Errorf("Expected every statement to fit in %d bytes with %s newlines, got %v", longest, newline, err)
+		}
+	}
+	_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-max-statement-bytes", strconv.Itoa(longest-1))
+	if want := fmt.Sprintf("for row 3: Statement is %d bytes, over the maximum of %d", longest, longest-1); nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q, got %v", want, err)
+	}
+}
+
+// With -newline crlf every line ends with a carriage return and line feed,
+// BEGIN and COMMIT too, while the line feed inside a station name is kept as it
+// is.
+func TestNewlineCrlfGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\n\"Bar\nNorth\",true,true\n"})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-newline", "crlf")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "crlf.sql", stdout)
+	if want := 1 + strings.Count(stdout, "\r\n"); want != strings.Count(stdout, "\n") {
+		t.Errorf("Expected only the line feed in the name without a carriage return:\n%q", stdout)
+	}
+}
+
+// Stations CSV whose records get shorter, so that any cell kept from the reused
+// record of one station would show up in a later one.
+const reusedStations = "Station,Ruby,Emerald,exits,aliases,name:fr,@note\n" +
+	"Zebra Crossing Interchange,true,true,12,Zebra;ZCI,Passage Zèbre,Rebuilt in 1999\n" +
+	"Ant,true,false,1,,,\n" +
+	"Bee,false,true,,B,Abeille,\n"
+
+// The cells kept from each record of the stations CSV are its own, even when the
+// stations are all parsed before any is written, and the output stays the same
+// from one conversion to the next.
+func TestReusedRecordGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": reusedStations})
+	for _, order := range []string{"input", "name"} {
+		t.Run(order, func(t *testing.T) {
+			stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-sort-stations", order)
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, "reused-record/"+order+".sql", stdout)
+			again, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-sort-stations", order)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if stdout != again {
+				t.Errorf("Output changed on the second conversion:\n%s\nwant:\n%s", again, stdout)
+			}
+		})
+	}
+}
+
+// Convert generated networks of two sizes, reporting the allocations of each
+// conversion, which should grow by a small constant for every station row.
+func BenchmarkConvertGenerated(b *testing.B) {
+	for _, stations := range []int{1000, 4000} {
+		b.Run(strconv.Itoa(stations), func(b *testing.B) {
+			b.Chdir(b.TempDir())
+			if err := run([]string{"gen", "-stations", strconv.Itoa(stations), "-lines", "10"}, io.Discard, io.Discard); nil != err {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+			for b.Loop() {
+				if err := run([]string{"-lines", "lines.csv", "-stations", "stations.csv"}, io.Discard, io.Discard); nil != err {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// Canonical output is the same for the same rows and columns in any order, and
+// from one run to the next.
+func TestCanonicalGolden(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":          testLines,
+		"stations.csv":       "Station,Ruby,Emerald,exits,@note\nFoo,true,false,2,Rebuilt\nBar's,true,true,,\nBaz,false,true,1,Closed\n",
+		"shuffled-lines.csv": "Line,Red,Green,Blue\nEmerald,0,255,0\nRuby,255,0,0\n",
+		"shuffled.csv":       "Station,@note,Emerald,exits,Ruby\nBaz,Closed,true,1,false\nFoo,Rebuilt,false,2,true\nBar's,,true,,true\n",
+	})
+	var first string
+	for i, files := range [][2]string{{"lines.csv", "stations.csv"}, {"shuffled-lines.csv", "shuffled.csv"}, {"lines.csv", "stations.csv"}} {
+		stdout, _, err := runArgs(t, "-lines", files[0], "-stations", files[1], "-canonical", "-timestamp-column", "updated=2026-01-01T00:00:00Z")
+		if nil != err {
+			t.Fatal(err)
+		}
+		if 0 == i {
+			first = stdout
+			checkGolden(t, "canonical.sql", stdout)
+		} else if first != stdout {
+			t.Errorf("Canonical output of %v differs:\n%s\nwant:\n%s", files, stdout, first)
+		}
+	}
+
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-line-order", "Ruby,Emerald"}, "An explicit rail line order cannot be used with canonical output"},
+		{[]string{"-timestamp-column", "updated"}, "A timestamp column requires an explicit time with canonical output"},
+		{[]string{"-timestamp-column", "updated=now()"}, "A timestamp column requires an explicit time with canonical output"},
+		{[]string{"-newline", "crlf"}, "Canonical output requires -newline lf"},
+	} {
+		if _, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-canonical"}, test.args...)...); nil == err || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected %q with %v, got %v", test.err, test.args, err)
+		}
+	}
+}
+
+// The same -seed samples the same stations from one run to the next, in the
+// order of the file, while another seed samples others.
+func TestSampleSeed(t *testing.T) {
+	stations := "Station,Ruby,Emerald\n"
+	for i := 1; i <= 30; i++ {
+		stations += fmt.Sprintf("Station %d,true,false\n", i)
+	}
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": stations})
+	sample := func(seed string) (string, string) {
+		t.Helper()
+		stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-sample", "5", "-seed", seed)
+		if nil != err {
+			t.Fatal(err)
+		}
+		return stdout, stderr
+	}
+	first, logged := sample("42")
+	if again, _ := sample("42"); first != again {
+		t.Errorf("Sample changed with the same seed:\n%s\nwant:\n%s", again, first)
+	}
+	if 5 != strings.Count(first, "INSERT INTO Stations") {
+		t.Errorf("Expected 5 stations sampled:\n%s", first)
+	}
+	if !strings.Contains(logged, "Sampled 5 stations with seed 42: ") {
+		t.Errorf("Expected the sample to be logged:\n%s", logged)
+	}
+	names := regexp.MustCompile(`'Station (\d+)'`).FindAllStringSubmatch(first, -1)
+	if !slices.IsSortedFunc(names, func(a, b []string) int {
+		x, _ := strconv.Atoi(a[1])
+		y, _ := strconv.Atoi(b[1])
+		return x - y
+	}) {
+		t.Errorf("Expected the sampled stations in the order of the file:\n%s", first)
+	}
+	if other, _ := sample("7"); first == other {
+		t.Errorf("Expected another seed to sample other stations:\n%s", other)
+	}
+}
+
+// A header with more rail line columns than -max-lines is rejected before
+// anything is written, naming the first few columns, unless the limit is raised
+// or turned off.
+func TestMaxLines(t *testing.T) {
+	lines, header, record := "Line,Red,Green,Blue\n", "Station", "Foo"
+	for i := 1; i <= 8; i++ {
+		lines += fmt.Sprintf("Line %d,0,0,0\n", i)
+		header += fmt.Sprintf(",Line %d", i)
+		record += ",true"
+	}
+	writeFiles(t, map[string]string{"lines.csv": lines, "stations.csv": header + "\n" + record + "\n"})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-max-lines", "7")
+	if want := `The stations header has 8 rail line columns, more than the 7 of -max-lines, so it looks wrong. The first are "Line 1", "Line 2", "Line 3", "Line 4", "Line 5". Give a higher -max-lines`; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q, got %v", want, err)
+	}
+	if "" != stdout {
+		t.Errorf("Expected nothing written, got:\n%s", stdout)
+	}
+	for _, limit := range []string{"8", "0"} {
+		if stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-max-lines", limit); nil != err || 8 != strings.Count(stdout, "INSERT INTO LineStations") {
+			t.Errorf("Expected -max-lines %s to convert every rail line, got %v:\n%s", limit, err, stdout)
+		}
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-max-lines", "-1"); nil == err || !strings.Contains(err.Error(), "-1") {
+		t.Errorf("Expected a negative -max-lines to be an error, got %v", err)
+	}
+}
+
+// -require-header checks the first header cell in any case, and a first header
+// cell naming a rail line with a first station named like a boolean is warned
+// about as the columns being shifted.
+func TestFirstHeaderCell(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": basicStations,
+		"shifted.csv":  "Ruby,Emerald\ntrue,false\nfalse,true\n",
+		"named.csv":    "Ruby,Emerald\nFoo,false\n",
+	})
+	for _, name := range []string{"Station", "station"} {
+		if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-require-header", name); nil != err {
+			t.Errorf("Expected -require-header %s to pass, got %v", name, err)
+		}
+	}
+	_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "shifted.csv", "-stations-format", "matrix", "-require-header", "Station")
+	if want := `First header cell is "Ruby" instead of "Station"`; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q, got %v", want, err)
+	}
+
+	_, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "shifted.csv", "-stations-format", "matrix")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := "Warning: The first header cell is the rail line Ruby and the first station is named true, the columns may be shifted by one"; !strings.Contains(stderr, want) {
+		t.Errorf("Expected %q to be logged, got:\n%s", want, stderr)
+	}
+	for _, stations := range []string{"stations.csv", "named.csv"} {
+		if _, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", stations, "-stations-format", "matrix"); nil != err || strings.Contains(stderr, "shifted") {
+			t.Errorf("Expected no shifted columns in %s, got %v:\n%s", stations, err, stderr)
+		}
+	}
+}
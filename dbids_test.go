@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestDbIdsGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald,@platform\nFoo,true,false,island\nBar's,true,true,\n"})
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"standard", nil},
+		{"postgres", []string{"-dialect", "postgres"}},
+		{"mysql", []string{"-dialect", "mysql"}},
+		{"link-by-name", []string{"-link-by-name"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-db-ids"}, test.args...)...)
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, "db-ids/"+test.name+".sql", stdout)
+		})
+	}
+
+	// The database assigns IDs the links find again
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-db-ids", "-self-test"); nil != err {
+		t.Errorf("Self-test with database-assigned IDs failed: %v", err)
+	}
+}
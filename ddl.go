@@ -0,0 +1,350 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// A column of a table created by the schema.
+type columnDefinition struct {
+	name       string
+	definition string // Type and constraints
+	comment    string // Written on the line before the column, if any
+}
+
+// Column of a table referencing the id column of another, as declared in the
+// schema.
+type columnReference struct {
+	column, table string
+}
+
+// A table created by the schema.
+type tableDefinition struct {
+	name       string
+	group      string // Name of the tables for -tables, or empty for the core tables always created
+	referenced bool   // Created only for the optional columns referencing it, instead of always
+	columns    []columnDefinition
+	references []columnReference
+	primaryKey []string // Columns of a composite primary key, if any
+}
+
+// A nullable column of one of the core tables, filled only by some conversions.
+type optionalColumn struct {
+	table     string
+	column    columnDefinition
+	reference string // Table the column references, if any
+}
+
+// Optional columns of the core tables, with the definitions of the columns the
+// conversion fills.
+func optionalColumns() []optionalColumn {
+	columns := []optionalColumn{
+		{"RailLines", networkIdColumn, "Networks"},
+		{"RailLines", modeIdColumn, "Modes"},
+		{"RailLines", agencyIdColumn, "Agencies"},
+		{"Stations", networkIdColumn, "Networks"},
+		{"Stations", complexIdColumn, "Complexes"},
+		{"Stations", zoneIdColumn, "AlarmZones"},
+	}
+	for _, field := range stationFields {
+		if !slices.ContainsFunc(columns, func(c optionalColumn) bool { return field.column == c.column.name }) {
+			columns = append(columns, optionalColumn{"Stations", columnDefinition{field.column, field.definition, ""}, ""})
+		}
+	}
+	return append(columns, optionalColumn{"LineStations", networkIdColumn, "Networks"})
+}
+
+// Groups of optional tables accepted by -tables.
+var tableGroups = []string{"agencies", "attributes", "aliases", "names", "complexes", "panels", "zones", "devices", "entrances", "connections"}
+
+// Options of the generated schema.
+type ddlOptions struct {
+	maxNameLength int                 // Length of the VARCHAR columns holding station names
+	groups        map[string]bool     // Optional tables to create
+	columns       map[string][]string // Optional columns to add to each table, by table name
+}
+
+// Schema of setup.sql, written by the schema subcommand with its default flags:
+// the core tables without any optional tables or columns.
+var setupSql = generateSchema(ddlOptions{maxNameLength: 128})
+
+// Every optional table group.
+func allTableGroups() map[string]bool {
+	groups := make(map[string]bool, len(tableGroups))
+	for _, group := range tableGroups {
+		groups[group] = true
+	}
+	return groups
+}
+
+// Every optional column, by table name.
+func allOptionalColumns() map[string][]string {
+	columns := make(map[string][]string)
+	for _, optional := range optionalColumns() {
+		columns[optional.table] = append(columns[optional.table], optional.column.name)
+	}
+	return columns
+}
+
+// Parse a -tables list of optional table groups, or 'all' or 'none'.
+func parseTableGroups(list string) (map[string]bool, error) {
+	switch list {
+	case "all":
+		return allTableGroups(), nil
+	case "none":
+		return map[string]bool{}, nil
+	}
+	groups := make(map[string]bool)
+	for _, group := range strings.Split(list, ",") {
+		if group = strings.TrimSpace(group); !slices.Contains(tableGroups, group) {
+			return nil, fmt.Errorf("Unknown optional tables: %s", group)
+		}
+		groups[group] = true
+	}
+	return groups, nil
+}
+
+// Tables of the schema, with the station names sized to the given length.
+func schemaTables(maxNameLength int) []tableDefinition {
+	stationName := fmt.Sprintf("VARCHAR(%d) NOT NULL", maxNameLength)
+	id := columnDefinition{"id", "INTEGER PRIMARY KEY NOT NULL UNIQUE", ""}
+	stationId := columnDefinition{"station_id", "INTEGER NOT NULL", ""}
+	return []tableDefinition{
+		{name: "Networks", referenced: true, columns: []columnDefinition{id, {"name", "VARCHAR(128) NOT NULL UNIQUE", ""}}},
+		{name: "Modes", referenced: true, columns: []columnDefinition{id, {"name", "VARCHAR(32) NOT NULL UNIQUE", "Transit mode like rail, bus, or streetcar"}}},
+		{name: "Agencies", group: "agencies", columns: []columnDefinition{id, {"name", "VARCHAR(128) NOT NULL UNIQUE", ""}}},
+		{
+			name:       "AgencyAttributes",
+			group:      "agencies",
+			columns:    []columnDefinition{{"agency_id", "INTEGER NOT NULL", ""}, {"name", "VARCHAR(64) NOT NULL", ""}, {"value", "TEXT NOT NULL", ""}},
+			references: []columnReference{{"agency_id", "Agencies"}},
+			primaryKey: []string{"agency_id", "name"},
+		},
+		// Created before the stations, whose optional columns reference them
+		{name: "Complexes", group: "complexes", columns: []columnDefinition{id, {"name", "VARCHAR(128) NOT NULL UNIQUE", ""}}},
+		{name: "AlarmZones", group: "zones", columns: []columnDefinition{id, {"name", "VARCHAR(128) NOT NULL UNIQUE", ""}}},
+		{name: "RailLines", columns: []columnDefinition{
+			id,
+			{"name", "VARCHAR(16) NOT NULL UNIQUE", ""},
+			{"red", "SMALLINT NOT NULL", "Rail lines typically have colors associated with them, these are for the RGB value"},
+			{"green", "SMALLINT NOT NULL", ""},
+			{"blue", "SMALLINT NOT NULL", ""},
+		}},
+		{name: "Stations", columns: []columnDefinition{id, {"name", stationName + " UNIQUE", ""}}},
+		{
+			name:       "LineStations",
+			columns:    []columnDefinition{{"line_id", "INTEGER NOT NULL", ""}, stationId},
+			references: []columnReference{{"line_id", "RailLines"}, {"station_id", "Stations"}},
+			primaryKey: []string{"line_id", "station_id"},
+		},
+		{
+			name:       "StationAttributes",
+			group:      "attributes",
+			columns:    []columnDefinition{stationId, {"name", "VARCHAR(64) NOT NULL", ""}, {"value", "TEXT NOT NULL", ""}},
+			references: []columnReference{{"station_id", "Stations"}},
+			primaryKey: []string{"station_id", "name"},
+		},
+		{
+			name:       "StationAliases",
+			group:      "aliases",
+			columns:    []columnDefinition{stationId, {"alias", stationName, ""}},
+			references: []columnReference{{"station_id", "Stations"}},
+			primaryKey: []string{"station_id", "alias"},
+		},
+		{
+			name:  "StationNames",
+			group: "names",
+			columns: []columnDefinition{
+				stationId,
+				{"lang", "VARCHAR(35) NOT NULL", "BCP 47 language tag of the name"},
+				{"name", stationName, ""},
+			},
+			references: []columnReference{{"station_id", "Stations"}},
+			primaryKey: []string{"station_id", "lang"},
+		},
+		{
+			name:       "Panels",
+			group:      "panels",
+			columns:    []columnDefinition{id, stationId, {"tag", "VARCHAR(64) NOT NULL UNIQUE", "Asset tag of the fire alarm panel"}},
+			references: []columnReference{{"station_id", "Stations"}},
+		},
+		{
+			name:       "StationZones",
+			group:      "zones",
+			columns:    []columnDefinition{stationId, {"zone_id", "INTEGER NOT NULL", ""}},
+			references: []columnReference{{"station_id", "Stations"}, {"zone_id", "AlarmZones"}},
+			primaryKey: []string{"station_id", "zone_id"},
+		},
+		{
+			name:  "Devices",
+			group: "devices",
+			columns: []columnDefinition{
+				id,
+				stationId,
+				{"type", "VARCHAR(64) NOT NULL", ""},
+				{"serial", "VARCHAR(128) NOT NULL UNIQUE", ""},
+			},
+			references: []columnReference{{"station_id", "Stations"}},
+		},
+		{
+			name:  "Entrances",
+			group: "entrances",
+			columns: []columnDefinition{
+				id,
+				stationId,
+				{"name", "VARCHAR(128) NOT NULL", ""},
+				{"latitude", "DOUBLE PRECISION NOT NULL", ""},
+				{"longitude", "DOUBLE PRECISION NOT NULL", ""},
+				{"emergency_only", "BOOLEAN NOT NULL", "Whether the entrance is only opened in emergencies"},
+			},
+			references: []columnReference{{"station_id", "Stations"}},
+		},
+		{
+			name:  "Connections",
+			group: "connections",
+			columns: []columnDefinition{
+				{"line_id", "INTEGER NOT NULL", ""},
+				{"from_station_id", "INTEGER NOT NULL", ""},
+				{"to_station_id", "INTEGER NOT NULL", ""},
+				{"seconds", "INTEGER", "Travel time between the stations, if known"},
+			},
+			references: []columnReference{{"line_id", "RailLines"}, {"from_station_id", "Stations"}, {"to_station_id", "Stations"}},
+			primaryKey: []string{"line_id", "from_station_id", "to_station_id"},
+		},
+		{name: "Users", columns: []columnDefinition{
+			id,
+			{"email", "VARCHAR(320) NOT NULL UNIQUE", "Maximum length of an email address from https://datatracker.ietf.org/doc/html/rfc5321#section-4.5.3.1"},
+		}},
+		{
+			name:       "UserStations",
+			columns:    []columnDefinition{{"user_id", "INTEGER NOT NULL", ""}, stationId},
+			references: []columnReference{{"user_id", "Users"}, {"station_id", "Stations"}},
+			primaryKey: []string{"user_id", "station_id"},
+		},
+	}
+}
+
+// Write the CREATE TABLE statements of the core tables and the chosen optional
+// ones in a transaction, as in setup.sql. The chosen optional columns are added
+// to the end of their table, and reference their table only when it is created
+// too, the networks and transit modes being created only for such a column.
+// Columns the options name that are not an optional column, like the one of
+// -timestamp-column, are added as TEXT.
+func generateSchema(options ddlOptions) string {
+	tables := schemaTables(options.maxNameLength)
+	optional := optionalColumns()
+	created := func(name string) bool {
+		index := slices.IndexFunc(tables, func(table tableDefinition) bool { return name == table.name })
+		if 0 > index {
+			return false
+		} else if tables[index].referenced {
+			return slices.ContainsFunc(optional, func(o optionalColumn) bool {
+				return name == o.reference && slices.Contains(options.columns[o.table], o.column.name)
+			})
+		}
+		return "" == tables[index].group || options.groups[tables[index].group]
+	}
+
+	var ddl strings.Builder
+	ddl.WriteString("BEGIN;\n")
+	for _, table := range tables {
+		if !created(table.name) {
+			continue
+		}
+		columns, references := slices.Clone(table.columns), slices.Clone(table.references)
+		for _, name := range options.columns[table.name] {
+			if slices.ContainsFunc(columns, func(column columnDefinition) bool { return name == column.name }) {
+				continue
+			}
+			index := slices.IndexFunc(optional, func(o optionalColumn) bool { return table.name == o.table && name == o.column.name })
+			if 0 > index {
+				columns = append(columns, columnDefinition{name, "TEXT", ""})
+				continue
+			}
+			columns = append(columns, optional[index].column)
+			if "" != optional[index].reference && created(optional[index].reference) {
+				references = append(references, columnReference{name, optional[index].reference})
+			}
+		}
+
+		fmt.Fprintf(&ddl, "CREATE TABLE IF NOT EXISTS %s (\n", table.name)
+		var lines []string
+		for _, column := range columns {
+			line := fmt.Sprintf("    %s %s", column.name, column.definition)
+			if "" != column.comment {
+				line = "    -- " + column.comment + "\n" + line
+			}
+			lines = append(lines, line)
+		}
+		for _, reference := range references {
+			lines = append(lines, fmt.Sprintf("    FOREIGN KEY (%s) REFERENCES %s(id)", reference.column, reference.table))
+		}
+		if 0 < len(table.primaryKey) {
+			lines = append(lines, fmt.Sprintf("    PRIMARY KEY (%s)", strings.Join(table.primaryKey, ", ")))
+		}
+		ddl.WriteString(strings.Join(lines, ",\n"))
+		ddl.WriteString("\n);\n")
+	}
+	ddl.WriteString("COMMIT;\n")
+	return ddl.String()
+}
+
+// Write the CREATE TABLE statements the inserts are generated for, for the
+// schema subcommand.
+func runSchema(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("csv2sql schema", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dialectFlag := flags.String("dialect", "standard", "SQL dialect to create the tables in: 'standard', 'postgres', 'mysql', or 'sqlite'")
+	maxNameLength := flags.Int("max-name-length", 128, "Maximum length of a station name, alias, or translation")
+	tablesFlag := flags.String("tables", "none", "Comma-separated optional tables to create along with the rail lines and stations: "+strings.Join(tableGroups, ", ")+", 'all', or 'none'")
+	columnsFlag := flags.String("columns", "none", "Comma-separated optional columns to add to the rail lines, stations, and their links, 'all', or 'none'")
+	if err := flags.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if nil != err {
+		return errUsage
+	}
+	if 0 >= *maxNameLength {
+		return fmt.Errorf("Invalid maximum name length: %d", *maxNameLength)
+	}
+	switch *dialectFlag {
+	case "standard", "postgres", "sqlite":
+	case "mysql":
+		// InnoDB keys are at most 3072 bytes, and utf8mb4 takes 4 bytes a character
+		if 768 < *maxNameLength {
+			return fmt.Errorf("MySQL cannot index station names longer than 768 characters")
+		}
+	default:
+		return fmt.Errorf("Invalid dialect: %s", *dialectFlag)
+	}
+	groups, err := parseTableGroups(*tablesFlag)
+	if nil != err {
+		return err
+	}
+	options := ddlOptions{maxNameLength: *maxNameLength, groups: groups}
+	switch *columnsFlag {
+	case "all":
+		options.columns = allOptionalColumns()
+	case "none":
+	default:
+		options.columns = make(map[string][]string)
+		for _, name := range strings.Split(*columnsFlag, ",") {
+			name = strings.TrimSpace(name)
+			found := false
+			for _, optional := range optionalColumns() {
+				if name == optional.column.name {
+					options.columns[optional.table] = append(options.columns[optional.table], name)
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("Unknown optional column: %s", name)
+			}
+		}
+	}
+	_, err = io.WriteString(stdout, generateSchema(options))
+	return err
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSchemaGolden(t *testing.T) {
+	for _, dialect := range []string{"standard", "postgres", "mysql", "sqlite"} {
+		for name, args := range map[string][]string{
+			dialect:          nil,
+			dialect + "-all": {"-tables", "all", "-columns", "all"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				stdout, _, err := runArgs(t, append([]string{"schema", "-dialect", dialect}, args...)...)
+				if nil != err {
+					t.Fatal(err)
+				}
+				checkGolden(t, "schema/"+name+".sql", stdout)
+			})
+		}
+	}
+}
+
+func TestSchemaColumns(t *testing.T) {
+	stdout, _, err := runArgs(t, "schema", "-tables", "none", "-columns", "network_id,latitude")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"network_id INTEGER,\n    FOREIGN KEY (network_id) REFERENCES Networks(id)\n);\nCREATE TABLE IF NOT EXISTS Stations", "latitude DOUBLE PRECISION,\n"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Schema is missing %q:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "zone_id") {
+		t.Errorf("Schema has a column that was not asked for:\n%s", stdout)
+	}
+	if _, _, err := runArgs(t, "schema", "-columns", "bogus"); nil == err || !strings.Contains(err.Error(), "Unknown optional column: bogus") {
+		t.Errorf("Expected an unknown column error, got %v", err)
+	}
+}
+
+// References to a table not created are left out, as -tables none drops the
+// alarm zones the zone_id column would reference.
+func TestSchemaReferencesOnlyCreatedTables(t *testing.T) {
+	schema := generateSchema(ddlOptions{maxNameLength: 128, columns: map[string][]string{"Stations": {"zone_id", "updated"}}})
+	if !strings.Contains(schema, "zone_id INTEGER,\n    updated TEXT\n") || strings.Contains(schema, "AlarmZones") {
+		t.Errorf("Unexpected schema:\n%s", schema)
+	}
+}
+
+// Every column of the stations CSV filling a column of the Stations table has
+// that column in the schema.
+func TestOptionalColumnsCoverFields(t *testing.T) {
+	optional := optionalColumns()
+	for _, field := range stationFields {
+		if !slices.ContainsFunc(optional, func(o optionalColumn) bool {
+			return "Stations" == o.table && field.column == o.column.name && field.definition == o.column.definition
+		}) {
+			t.Errorf("No optional column of the Stations table for the %s column", field.header)
+		}
+	}
+}
+
+func TestSelfTestOptionalColumns(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv": testLines,
+		"stations.csv": "Station,Ruby,Emerald,status,complex,zone,exits,elevators,accessible,latitude,lon\n" +
+			"Foo,true,false,open,C1,Z1,2,1,true,38.9,-77.0\n" +
+			"Bar,true,true,closed,C1,Z2,,,,,\n",
+	})
+	for _, args := range [][]string{
+		{},
+		{"-zone-links"},
+		{"-network", "Ruby Rail", "-network-links"},
+		{"-timestamp-column", "updated=2020-01-01T00:00:00Z"},
+	} {
+		if _, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-stations-format", "matrix", "-self-test"}, args...)...); nil != err {
+			t.Errorf("Self-test failed with %v: %v", args, err)
+		}
+	}
+}
+
+// setup.sql is the schema subcommand's output with its default flags.
+func TestSetupSqlIsCurrent(t *testing.T) {
+	text, err := os.ReadFile("setup.sql")
+	if nil != err {
+		t.Fatal(err)
+	}
+	stdout, _, err := runArgs(t, "schema")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if stdout != string(text) || setupSql != stdout {
+		t.Error("setup.sql is out of date, regenerate it with the schema subcommand")
+	}
+}
+
+// The inserts of a conversion without options, like wmata/statements.sql that
+// the Dockerfile loads, go into setup.sql as they are.
+func TestDefaultOutputLoadsIntoSetup(t *testing.T) {
+	statements, err := os.ReadFile("wmata/statements.sql")
+	if nil != err {
+		t.Fatal(err)
+	}
+	stdout, _, err := runArgs(t, "-builtin", "wmata")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for name, script := range map[string]string{"conversion": stdout, "wmata/statements.sql": string(statements)} {
+		test, err := newSelfTest(setupSql, log.Default())
+		if nil != err {
+			t.Fatal(err)
+		}
+		if _, err := test.db.Exec(script); nil != err {
+			t.Errorf("Failed to load the %s into setup.sql: %v", name, err)
+		}
+		var count int
+		if err := test.db.QueryRow("SELECT COUNT(*) FROM LineStations").Scan(&count); nil != err || 0 == count {
+			t.Errorf("No links loaded from the %s: %v", name, err)
+		}
+		test.close()
+	}
+}
+
+// The optional tables and columns are left out by default, along with the
+// networks and transit modes that only optional columns reference.
+func TestSchemaDefaults(t *testing.T) {
+	stdout, _, err := runArgs(t, "schema")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, table := range []string{"Networks", "Modes", "Agencies", "StationAliases", "Connections"} {
+		if strings.Contains(stdout, "CREATE TABLE IF NOT EXISTS "+table+" ") {
+			t.Errorf("Schema creates the optional table %s by default", table)
+		}
+	}
+	for _, table := range []string{"RailLines", "Stations", "LineStations", "Users", "UserStations"} {
+		if !strings.Contains(stdout, "CREATE TABLE IF NOT EXISTS "+table+" ") {
+			t.Errorf("Schema is missing the core table %s", table)
+		}
+	}
+	if strings.Contains(stdout, "network_id") {
+		t.Errorf("Schema has an optional column by default:\n%s", stdout)
+	}
+	if _, _, err := runArgs(t, "schema", "-tables", "users"); nil == err || !strings.Contains(err.Error(), "Unknown optional tables: users") {
+		t.Errorf("Expected the users to no longer be optional, got %v", err)
+	}
+}
+
+func TestSelfTestNetwork(t *testing.T) {
+	for _, args := range [][]string{
+		{"-builtin", "wmata", "-network", "WMATA", "-self-test"},
+		{"-builtin", "wmata", "-network", "WMATA", "-network-links", "-self-test"},
+	} {
+		stdout, _, err := runArgs(t, args...)
+		if nil != err {
+			t.Fatalf("Self-test failed with %v: %v", args, err)
+		}
+		if !strings.Contains(stdout, "INSERT INTO Stations (id, name, network_id) VALUES (1, ") {
+			t.Errorf("Stations are missing their network_id with %v", args)
+		}
+	}
+}
+
+// The optional columns referencing a table of their own are filled in the
+// self-test's schema, with the table created for them.
+func TestSelfTestReferences(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":     testLines,
+		"modes.csv":     "Station,Ruby[rail],Emerald[streetcar]\nFoo,true,true\n",
+		"stations.csv":  "Station,Ruby,Emerald\nFoo,true,true\n",
+		"agencies.csv":  "line,agency,contact\nRuby,Ruby Transit,ops@example.com\n",
+		"complexes.csv": "Station,Ruby,Emerald,complex\nFoo,true,false,Center\nBar,false,true,Center\n",
+	})
+	tests := []struct {
+		name string
+		args []string
+		want string // Insert filling the column
+	}{
+		{"modes", []string{"-stations", "modes.csv"}, "INSERT INTO RailLines (id, name, red, green, blue, mode_id) VALUES (2, 'Emerald', 0, 255, 0, 2);"},
+		{"agencies", []string{"-stations", "stations.csv", "-agencies", "agencies.csv"}, "INSERT INTO RailLines (id, name, red, green, blue, agency_id) VALUES (2, 'Emerald', 0, 255, 0, NULL);"},
+		{"complexes", []string{"-stations", "complexes.csv"}, "INSERT INTO Stations (id, name, complex_id) VALUES (2, 'Bar', 1);"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-self-test"}, test.args...)...)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if !strings.Contains(stdout, test.want) {
+				t.Errorf("Expected %q with %v:\n%s", test.want, test.args, stdout)
+			}
+		})
+	}
+}
+
+// Every column of the stations CSV filling a column of the Stations table loads
+// into a setup.sql with every optional column.
+func TestFieldColumnsLoadIntoSetup(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv": testLines,
+		"stations.csv": "Station,Ruby,Emerald,exits,evac_capacity,capacity,elevators,escalators,accessible,underground,status,latitude,longitude\n" +
+			"Foo,true,true,2,500,800,1,4,true,false,open,38.9,-77.0\n" +
+			"Bar,true,false,,,,,,,,,,\n",
+		"setup.sql": generateSchema(ddlOptions{maxNameLength: 128, columns: allOptionalColumns()}),
+	})
+	for _, args := range [][]string{{"-self-test"}, {"-self-test", "-schema-file", "setup.sql"}} {
+		stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-stations-format", "matrix"}, args...)...)
+		if nil != err {
+			t.Fatalf("Self-test failed with %v: %v", args, err)
+		}
+		if !strings.Contains(stdout, "exit_count, evac_capacity, capacity, elevators, escalators, accessible, underground, status, latitude, longitude") {
+			t.Errorf("Stations are missing their columns with %v:\n%s", args, stdout)
+		}
+	}
+}
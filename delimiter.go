@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Delimiters tried when detecting the delimiter of a CSV file.
+var delimiterCandidates = []rune{',', '\t', ';', '|'}
+
+// Number of bytes at the start of a CSV file the delimiter is detected from.
+const sniffBytes = 64 << 10
+
+// Number of records after the header that must have as many columns as it for
+// a delimiter to be detected.
+const sniffRecords = 5
+
+// Parse the -delimiter flag, which is a single character or "tab", with "" for
+// detecting it.
+func parseDelimiter(value string) (rune, error) {
+	switch value {
+	case "":
+		return 0, nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	runes := []rune(value)
+	if 1 != len(runes) || '"' == runes[0] || '\r' == runes[0] || '\n' == runes[0] {
+		return 0, fmt.Errorf("Invalid delimiter: %q", value)
+	}
+	return runes[0], nil
+}
+
+// Work out the delimiter of the CSV file at path from the start of the input,
+// without consuming it. The candidate splitting the header into the most
+// columns wins, as long as the first records have as many columns as the header.
+// Several candidates splitting it into as many columns is an error rather than
+// a guess, and a header none of them splits is taken to be comma separated.
+func (c *conversion) sniffDelimiter(input *bufio.Reader, path string) (rune, error) {
+	sample, err := input.Peek(sniffBytes)
+	if nil != err && !errors.Is(err, io.EOF) {
+		return 0, fmt.Errorf("Failed to read %s: %w", path, err)
+	} else if nil == err {
+		// Leave out the record cut off at the end of the sample
+		if end := bytes.LastIndexByte(sample, '\n'); 0 <= end {
+			sample = sample[:end+1]
+		}
+	}
+
+	var best []rune
+	bestColumns := 1
+	for _, candidate := range delimiterCandidates {
+		if columns := c.sniffColumns(sample, candidate); bestColumns < columns {
+			best, bestColumns = []rune{candidate}, columns
+		} else if 1 < columns && bestColumns == columns {
+			best = append(best, candidate)
+		}
+	}
+	switch len(best) {
+	case 0:
+		return ',', nil
+	case 1:
+		if ',' != best[0] {
+			c.logger.Printf("Detected %s as the delimiter of %s\n", delimiterName(best[0]), path)
+		}
+		return best[0], nil
+	}
+	names := make([]string, len(best))
+	for i, candidate := range best {
+		names[i] = delimiterName(candidate)
+	}
+	return 0, fmt.Errorf("Cannot tell whether %s is delimited by %s, give -delimiter", path, strings.Join(names, " or "))
+}
+
+// Number of columns the candidate delimiter splits the header of the sample
+// into, or 0 when the first records do not have as many.
+func (c *conversion) sniffColumns(sample []byte, candidate rune) int {
+	reader := csv.NewReader(bytes.NewReader(sample))
+	reader.Comma = candidate
+	if candidate != c.comment {
+		reader.Comment = c.comment
+	}
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if nil != err {
+		return 0
+	}
+	for range sniffRecords {
+		if _, err := reader.Read(); errors.Is(err, io.EOF) {
+			break
+		} else if nil != err {
+			return 0
+		}
+	}
+	return len(header)
+}
+
+// Name of the delimiter for messages.
+func delimiterName(delimiter rune) string {
+	if '\t' == delimiter {
+		return "tab"
+	}
+	return fmt.Sprintf("%q", delimiter)
+}
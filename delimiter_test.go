@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Files of the basic.sql golden file delimited by something other than commas
+// are detected as such, and the delimiter is logged.
+func TestDelimiterDetected(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		delimiter string
+	}{
+		{"semicolon", ";"},
+		{"tab", "\t"},
+		{"pipe", "|"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{
+				"lines.csv":    strings.ReplaceAll(testLines, ",", test.delimiter),
+				"stations.csv": strings.ReplaceAll(basicStations, ",", test.delimiter),
+			})
+			stdout, warnings, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, "basic.sql", stdout)
+			want := "Detected " + delimiterName([]rune(test.delimiter)[0]) + " as the delimiter of stations.csv"
+			if !strings.Contains(warnings, want) {
+				t.Errorf("Expected %q to be logged, got:\n%s", want, warnings)
+			}
+		})
+	}
+}
+
+// A delimiter splitting the header into more columns is passed over when the
+// records after it do not split into as many, and commas are used without
+// logging.
+func TestDelimiterRecords(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,Ruby,Emerald,!Note;A;B;C;D\nFoo,true,false,a;b;c;d;e\nBar's,true,true,d\n",
+	})
+	stdout, warnings, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "basic.sql", stdout)
+	if strings.Contains(warnings, "Detected") {
+		t.Errorf("Expected commas without logging, got:\n%s", warnings)
+	}
+}
+
+// A header split as much by two delimiters is an error naming both, which
+// -delimiter settles.
+func TestDelimiterAmbiguous(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station;Ruby,Emerald\nFoo;true,false\nBar's;true,true\n",
+	})
+	_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if want := "Cannot tell whether stations.csv is delimited by ',' or ';', give -delimiter"; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q, got %v", want, err)
+	}
+
+	writeFiles(t, map[string]string{
+		"lines.csv":    strings.ReplaceAll(testLines, ",", ";"),
+		"stations.csv": "Station;Ruby;Emerald\nFoo;true;false\nBar's;true;true\n",
+	})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-delimiter", ";")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "basic.sql", stdout)
+}
+
+func TestParseDelimiter(t *testing.T) {
+	for value, want := range map[string]rune{"": 0, "tab": '\t', `\t`: '\t', ";": ';', "|": '|', "§": '§'} {
+		if got, err := parseDelimiter(value); nil != err || want != got {
+			t.Errorf("Expected %q for %q, got %q and %v", want, value, got, err)
+		}
+	}
+	for _, value := range []string{",,", `"`, "\n", "\r", "semicolon"} {
+		if _, err := parseDelimiter(value); nil == err || !strings.Contains(err.Error(), "Invalid delimiter") {
+			t.Errorf("Expected an invalid delimiter for %q, got %v", value, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// An alarm device read from the devices CSV.
+type device struct {
+	station string // Name of the station the device is in
+	kind    string
+	serial  string
+	row     int // Line of the devices CSV the device was read from
+}
+
+// Parse the devices CSV, rejecting any serial numbers that appear twice.
+func (c *conversion) parseDevices(reader *csv.Reader) ([]device, error) {
+	reader.FieldsPerRecord = 3 // Station Name, Device Type, and Device Serial
+	if _, err := reader.Read(); nil != err {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+
+	var devices []device
+	rows := make(map[string]int)
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read record for device %d: %w", len(devices)+1, err)
+		}
+		if err := c.nul.apply(reader, record); nil != err {
+			return nil, err
+		}
+
+		row, _ := reader.FieldPos(0)
+		current := device{
+			station: strings.TrimSpace(record[0]),
+			kind:    strings.TrimSpace(record[1]),
+			serial:  strings.TrimSpace(record[2]),
+			row:     row,
+		}
+		if 0 >= len(current.serial) {
+			return nil, fmt.Errorf("Missing serial for device in row %d", row)
+		}
+		if duplicate, found := rows[current.serial]; found {
+			if err := c.forgive("duplicates", fmt.Errorf("Duplicate serial %s in rows %d and %d", current.serial, duplicate, row)); nil != err {
+				return nil, err
+			}
+			// Only the first device with the serial is kept
+			continue
+		}
+		rows[current.serial] = row
+		devices = append(devices, current)
+	}
+	return devices, nil
+}
+
+// Look up the ID of the station each device is in, adding the prefix given to
+// the station names to the names in the devices CSV. Returns the devices kept,
+// without those in unknown stations that -force skips, and their station IDs.
+func (c *conversion) resolveDevices(devices []device, stations []station, prefix string) ([]device, []int, error) {
+	stationIds := make(map[string]int, len(stations))
+	for _, current := range stations {
+		stationIds[current.name] = current.id
+	}
+
+	var kept []device
+	var resolved []int
+	for _, current := range devices {
+		stationId, found := stationIds[prefix+current.station]
+		if !found {
+			if err := c.forgive("unknown-stations", fmt.Errorf("Unknown station %s for device in row %d", current.station, current.row)); nil != err {
+				return nil, nil, err
+			}
+			continue
+		}
+		kept = append(kept, current)
+		resolved = append(resolved, stationId)
+	}
+	return kept, resolved, nil
+}
+
+// Generate the SQL statements for populating the 'Devices' table, given the
+// station ID of each device from [conversion.resolveDevices].
+func (e *emitter) deviceStatements(devices []device, stationIds []int, writer io.Writer) error {
+	for i, current := range devices {
+		if err := e.writeInsert(writer, "Devices", []string{"id", "station_id", "type", "serial"}, []string{strconv.Itoa(i + 1),
+			strconv.Itoa(stationIds[i]), e.quoteSqlString(current.kind), e.quoteSqlString(current.serial)}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write device insert statement for row %d: %w", current.row, err)
+		}
+	}
+	return nil
+}
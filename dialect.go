@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Quote the value as a SQL string literal in the string style of the
+// conversion.
+func (c *conversion) quoteSqlString(value string) string {
+	switch c.stringStyle {
+	case "dollar":
+		return dollarQuote(c.escapeForStyle(value))
+	case "estring":
+		return "E'" + c.escapeForStyle(value) + "'"
+	default:
+		return "'" + c.escapeForStyle(value) + "'"
+	}
+}
+
+// Append the value quoted like [conversion.quoteSqlString] to the buffer. In
+// the standard string style without any escaping rules or line continuations it
+// is escaped byte by byte instead, without allocating.
+func (c *conversion) appendSqlString(buffer []byte, value string) []byte {
+	if "standard" != c.stringStyle || 0 < len(c.escapeRules) || ("mssql" == c.dialect && hasLineContinuation(value)) {
+		return append(buffer, c.quoteSqlString(value)...)
+	}
+	buffer = append(buffer, '\'')
+	for i := 0; i < len(value); i++ {
+		switch b := value[i]; {
+		case 0 == b:
+			// Dropped like in [EscapeSQL]
+		case '\'' == b:
+			buffer = append(buffer, '\'', '\'')
+		case '\\' == b && "mysql" == c.dialect:
+			buffer = append(buffer, '\\', '\\')
+		default:
+			buffer = append(buffer, b)
+		}
+	}
+	return append(buffer, '\'')
+}
+
+// SQL dialect of [EscapeSQL] and [QuoteLiteral], named like the values of
+// -dialect.
+type Dialect string
+
+const (
+	DialectStandard Dialect = "standard"
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+	DialectSQLite   Dialect = "sqlite"
+	DialectMSSQL    Dialect = "mssql"
+	DialectOracle   Dialect = "oracle"
+)
+
+// Replacers of [EscapeSQL], built once.
+var (
+	standardEscaper  = strings.NewReplacer("\x00", "", "'", "''")
+	backslashEscaper = strings.NewReplacer("\x00", "", `\`, `\\`, "'", "''")
+	// SQL Server drops a backslash before a line break along with the line break,
+	// so the backslash is doubled for one to be left and the line break repeated
+	// for the one dropped
+	continuationEscaper = strings.NewReplacer("\x00", "", "'", "''", "\\\r\n", "\\\\\r\n\r\n", "\\\n", "\\\\\n\n")
+)
+
+// Escape the value as it appears between the single quotes of a string literal
+// in the dialect, leaving out any escaping rules. NUL characters, which no
+// dialect accepts in a string, are dropped and single quotes are doubled in
+// every dialect. MySQL doubles backslashes too, and MSSQL escapes a backslash
+// before a line break, which SQL Server would otherwise take as a line
+// continuation; the others keep backslashes as they are. A value without
+// anything to escape is returned as it is, without allocating.
+func EscapeSQL(value string, dialect Dialect) string {
+	switch dialect {
+	case DialectMySQL:
+		if !strings.ContainsAny(value, "\x00'\\") {
+			return value
+		}
+		return backslashEscaper.Replace(value)
+	case DialectMSSQL:
+		if !strings.ContainsAny(value, "\x00'") && !hasLineContinuation(value) {
+			return value
+		}
+		return continuationEscaper.Replace(value)
+	}
+	if !strings.ContainsAny(value, "\x00'") {
+		return value
+	}
+	return standardEscaper.Replace(value)
+}
+
+// Whether the value has a backslash before a line break, which SQL Server takes
+// as a line continuation.
+func hasLineContinuation(value string) bool {
+	return strings.Contains(value, "\\\n") || strings.Contains(value, "\\\r\n")
+}
+
+// Quote the value as a string literal of the dialect with [EscapeSQL], for
+// messages and other fixed text that the escaping rules are not meant for.
+func QuoteLiteral(value string, dialect Dialect) string {
+	return "'" + EscapeSQL(value, dialect) + "'"
+}
+
+// Escape the value as it appears between the quotes of a string literal in the
+// string style of the conversion.
+func (c *conversion) escapeForStyle(value string) string {
+	switch c.stringStyle {
+	case "dollar":
+		return c.applyEscapeRules(strings.ReplaceAll(value, "\x00", ""))
+	case "estring":
+		// Backslashes are escapes in E'' strings as in mysql ones
+		return c.applyEscapeRules(EscapeSQL(value, DialectMySQL))
+	default:
+		return c.applyEscapeRules(EscapeSQL(value, Dialect(c.dialect)))
+	}
+}
+
+// Quote the value between PostgreSQL dollar quotes. The tag is numbered when
+// needed so that its first occurrence after the opening tag is the closing one.
+func dollarQuote(value string) string {
+	tag := "$csv$"
+	for i := 1; strings.Index(value+tag, tag) < len(value); i++ {
+		tag = fmt.Sprintf("$csv%d$", i)
+	}
+	return tag + value + tag
+}
+
+// Format the time as a SQL literal in UTC for the dialect of the conversion.
+func (c *conversion) timestampLiteral(timestamp time.Time) string {
+	timestamp = timestamp.UTC()
+	if "postgres" == c.dialect {
+		return timestamp.Format("TIMESTAMP WITH TIME ZONE '2006-01-02 15:04:05.999999Z07:00'")
+	}
+	return timestamp.Format("'2006-01-02 15:04:05.999999'")
+}
+
+// SQL for the current time in the dialect of the conversion.
+func (c *conversion) currentTimestamp() string {
+	if "postgres" == c.dialect {
+		return "now()"
+	}
+	return "CURRENT_TIMESTAMP"
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDollarQuote(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"Foo", "$csv$Foo$csv$"},
+		{"", "$csv$$csv$"},
+		{"Foo $csv$ Bar", "$csv1$Foo $csv$ Bar$csv1$"},
+		{"$csv$ and $csv1$", "$csv2$$csv$ and $csv1$$csv2$"},
+		// The closing tag would otherwise start inside the value
+		{"Foo $csv", "$csv1$Foo $csv$csv1$"},
+		{"csv$", "$csv$csv$$csv$"},
+	}
+	for _, test := range tests {
+		if got := dollarQuote(test.value); test.want != got {
+			t.Errorf("Quoted %q as %s, want %s", test.value, got, test.want)
+		}
+	}
+}
+
+func TestEscapeSQL(t *testing.T) {
+	tests := []struct {
+		value    string
+		standard string // Escaped in every dialect but mysql and mssql
+		mysql    string
+		mssql    string
+	}{
+		{"Foo", "Foo", "Foo", "Foo"},
+		{"", "", "", ""},
+		{"Bar's", "Bar''s", "Bar''s", "Bar''s"},
+		{"''", "''''", "''''", "''''"},
+		{`C:\path`, `C:\path`, `C:\\path`, `C:\path`},
+		{`\'`, `\''`, `\\''`, `\''`},
+		{"N\x00U\x00L", "NUL", "NUL", "NUL"},
+		{"\x00'\\\x00", `''\`, `''\\`, `''\`},
+		{"Zürich – 日本", "Zürich – 日本", "Zürich – 日本", "Zürich – 日本"},
+		{"North\\\nSouth", "North\\\nSouth", "North\\\\\nSouth", "North\\\\\n\nSouth"},
+		{"North\\\r\nSouth", "North\\\r\nSouth", "North\\\\\r\nSouth", "North\\\\\r\n\r\nSouth"},
+		{"North\\\\\nSouth's", "North\\\\\nSouth''s", "North\\\\\\\\\nSouth''s", "North\\\\\\\n\nSouth''s"},
+		{"North\\ \nSouth", "North\\ \nSouth", "North\\\\ \nSouth", "North\\ \nSouth"},
+	}
+	for _, test := range tests {
+		for _, dialect := range []Dialect{DialectStandard, DialectPostgres, DialectMySQL, DialectSQLite, DialectMSSQL, DialectOracle} {
+			want := test.standard
+			switch dialect {
+			case DialectMySQL:
+				want = test.mysql
+			case DialectMSSQL:
+				want = test.mssql
+			}
+			if got := EscapeSQL(test.value, dialect); want != got {
+				t.Errorf("Escaped %q as %q in %s, want %q", test.value, got, dialect, want)
+			}
+			if got := QuoteLiteral(test.value, dialect); "'"+want+"'" != got {
+				t.Errorf("Quoted %q as %q in %s, want '%s'", test.value, got, dialect, want)
+			}
+		}
+	}
+}
+
+// A value without anything to escape is returned as it is in every dialect.
+func TestEscapeSQLAllocs(t *testing.T) {
+	for _, dialect := range []Dialect{DialectStandard, DialectMySQL, DialectMSSQL} {
+		if allocs := testing.AllocsPerRun(10, func() { EscapeSQL("Zürich Hauptbahnhof – Nord", dialect) }); 0 != allocs {
+			t.Errorf("Expected no allocations in %s, got %g", dialect, allocs)
+		}
+	}
+}
+
+// A backslash before a line break in a station name is escaped for SQL Server
+// by the conversion as by [EscapeSQL], and only in the mssql dialect.
+func TestLineContinuation(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\n\"North\\\nSouth\",true,false\n"})
+	for dialect, want := range map[string]string{"mssql": "'North\\\\\n\nSouth'", "standard": "'North\\\nSouth'"} {
+		stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-dialect", dialect)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if !strings.Contains(stdout, "(1, "+want+")") {
+			t.Errorf("Expected %s in %s:\n%s", want, dialect, stdout)
+		}
+	}
+}
+
+func TestDollarStringStyle(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo $csv$ Bar,true,false\nBar's,true,true\n"})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-dialect", "postgres", "-string-style", "dollar")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"(1, $csv1$Foo $csv$ Bar$csv1$);", "(2, $csv$Bar's$csv$);"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %s in:\n%s", want, stdout)
+		}
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-string-style", "dollar"); nil == err {
+		t.Error("Expected dollar quotes outside the postgres dialect to be an error")
+	}
+}
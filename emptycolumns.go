@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// Number of empty cells after the first one at the end of the header, which a
+// spreadsheet may pad the header with but not the records.
+func paddedCells(header []string) int {
+	count := 0
+	for count+1 < len(header) && "" == strings.TrimSpace(header[len(header)-count-1]) {
+		count++
+	}
+	return count
+}
+
+// Header without the empty cells at its end, logging how many were trimmed.
+// Empty cells before the last named one are kept, for [parseStationColumns]
+// and [conversion.parseLines] to check.
+func (c *conversion) trimHeader(header []string, file string) []string {
+	count := paddedCells(header)
+	if 0 < count {
+		c.logger.Printf("Trimmed %d empty cells from the end of the %s CSV header\n", count, file)
+	}
+	return header[:len(header)-count]
+}
+
+// Record cut down to the width of the trimmed header. Records may be as wide as
+// either the trimmed header or the padded one, as long as the cells cut off are
+// empty.
+func trimRecord(reader *csv.Reader, record []string, width int, padded int) ([]string, error) {
+	row, _ := reader.FieldPos(0)
+	if len(record) != width && len(record) != padded {
+		return nil, fmt.Errorf("Record on row %d has %d fields, expected %d, or %d with the empty header cells", row, len(record), width, padded)
+	}
+	for i := width; i < len(record); i++ {
+		if value := strings.TrimSpace(record[i]); "" != value {
+			return nil, misalignedColumn(i+1, value, strings.TrimSpace(record[0]))
+		}
+	}
+	return record[:width], nil
+}
+
+// Error for a value in a column without a name, which means that the values of
+// the record are not under the header they belong to.
+func misalignedColumn(column int, value string, name string) error {
+	return fmt.Errorf("Column %d has no name but the value %q for %s, the columns may be misaligned", column, value, name)
+}
+
+// Warn that the columns of the CSV file, numbered from 1, which have neither a
+// name nor any values, were dropped.
+func (c *conversion) warnEmptyColumns(file string, columns []int) {
+	if 0 == len(columns) {
+		return
+	}
+	numbers := make([]string, len(columns))
+	for i, column := range columns {
+		numbers[i] = fmt.Sprint(column)
+	}
+	if 1 == len(columns) {
+		c.warn("empty-columns", "Dropped column %d of the %s CSV, which has no name or values", columns[0], file)
+	} else {
+		c.warn("empty-columns", "Dropped columns %s of the %s CSV, which have no name or values", strings.Join(numbers, ", "), file)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Columns without a name or values are dropped with a warning of each file,
+// and empty cells padding the end of the header are trimmed, records as wide as
+// the header with or without them.
+func TestEmptyColumnsDropped(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    "Line,,Red,Green,,Blue\nRuby,,255,0,,0\nEmerald,,0,255, ,0\n",
+		"stations.csv": "Station,Ruby,,Emerald,, \nFoo,true,,false,,\nBar's,true,,true\n",
+	})
+	stdout, warnings, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "basic.sql", stdout)
+	for _, want := range []string{
+		"Warning: Dropped columns 2, 5 of the rail lines CSV, which have no name or values\n",
+		"Warning: Dropped column 3 of the stations CSV, which has no name or values\n",
+		"Trimmed 2 empty cells from the end of the stations CSV header\n",
+	} {
+		if !strings.Contains(warnings, want) {
+			t.Errorf("Expected %q to be logged, got:\n%s", want, warnings)
+		}
+	}
+	if strings.Contains(warnings, "Warning: Dropped column 5 of the stations") {
+		t.Errorf("Expected the trimmed cells not to be warned about as well:\n%s", warnings)
+	}
+
+	_, _, err = runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-fail-on-warning")
+	if want := "Failing on 2 warnings with -fail-on-warning"; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q, got %v", want, err)
+	}
+}
+
+// A value in a column without a name is an error whatever the flags, as the
+// values are likely not under the header they belong to.
+func TestEmptyColumnsMisaligned(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		lines    string
+		stations string
+		err      string
+	}{
+		{"stations", testLines, "Station,Ruby,,Emerald\nFoo,true,false,\n", `Column 3 has no name but the value "false" for Foo, the columns may be misaligned`},
+		{"padding", testLines, "Station,Ruby,Emerald,\nFoo,true,false,x\n", `Column 4 has no name but the value "x" for Foo, the columns may be misaligned`},
+		{"lines", "Line,Red,,Green,Blue\nRuby,255,0,0,0\n", basicStations, `Column 3 has no name but the value "0" for Ruby, the columns may be misaligned`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"lines.csv": test.lines, "stations.csv": test.stations})
+			_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-force", "empty-columns")
+			if nil == err || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Encodings the CSV files can be decoded from, by their -encoding names. UTF-8
+// is read as it is.
+var encodings = map[string]encoding.Encoding{
+	"utf-8":        nil,
+	"utf-16le":     unicode.UTF16(unicode.LittleEndian, unicode.UseBOM),
+	"utf-16be":     unicode.UTF16(unicode.BigEndian, unicode.UseBOM),
+	"windows-1252": charmap.Windows1252,
+	"iso-8859-1":   charmap.ISO8859_1,
+}
+
+// Number of bytes at the start of a CSV file the encoding is detected from.
+const detectBytes = 64 << 10
+
+// Wrap the reader of the CSV file at path in a decoder to UTF-8 according to
+// the input encoding. When detecting it automatically, a byte order mark
+// decides, then text that is valid UTF-8 is taken as it is, and any other is
+// taken to be Windows-1252 if it has bytes in the range 0x80 to 0x9F that
+// ISO-8859-1 leaves to control characters, and ISO-8859-1 otherwise. Text read
+// as UTF-8 is checked all the way through, so that invalid UTF-8 is an error
+// instead of ending up in the statements.
+func (c *conversion) decode(reader io.Reader, path string) (io.Reader, error) {
+	buffered := bufio.NewReaderSize(reader, detectBytes)
+	name := c.inputEncoding
+	if "auto" == name {
+		sample, err := buffered.Peek(detectBytes)
+		if nil != err && io.EOF != err {
+			return nil, fmt.Errorf("Failed to read %s: %w", path, err)
+		}
+		var reason string
+		name, reason = detectEncoding(sample, nil == err)
+		if "" != reason {
+			c.logger.Printf("Reading %s as %s, %s\n", path, name, reason)
+		}
+	}
+
+	if "utf-8" == name {
+		if bom, _ := buffered.Peek(3); bytes.Equal([]byte("\xEF\xBB\xBF"), bom) {
+			buffered.Discard(3)
+		}
+		return &utf8Validator{reader: buffered, path: path}, nil
+	}
+	return encodings[name].NewDecoder().Reader(buffered), nil
+}
+
+// Pick the encoding of text starting with the sample, which is cut short unless
+// complete is set, with the reason when it is not plain UTF-8.
+func detectEncoding(sample []byte, complete bool) (string, string) {
+	switch {
+	case bytes.HasPrefix(sample, []byte("\xEF\xBB\xBF")):
+		return "utf-8", "from its byte order mark"
+	case bytes.HasPrefix(sample, []byte("\xFF\xFE")):
+		return "utf-16le", "from its byte order mark"
+	case bytes.HasPrefix(sample, []byte("\xFE\xFF")):
+		return "utf-16be", "from its byte order mark"
+	}
+
+	if 2 <= len(sample) {
+		// ASCII text in UTF-16 has every other byte zero
+		evenZeros, oddZeros := 0, 0
+		for i, b := range sample {
+			if 0 == b && 0 == i%2 {
+				evenZeros++
+			} else if 0 == b {
+				oddZeros++
+			}
+		}
+		if half := len(sample) / 2; half <= 2*oddZeros && 0 == evenZeros {
+			return "utf-16le", "from the zero bytes of its ASCII characters"
+		} else if half <= 2*evenZeros && 0 == oddZeros {
+			return "utf-16be", "from the zero bytes of its ASCII characters"
+		}
+	}
+
+	valid := sample
+	for i := len(valid) - 1; !complete && 0 <= i && len(valid)-utf8.UTFMax <= i; i-- {
+		// Leave out a character cut off at the end of the sample
+		if utf8.RuneStart(valid[i]) {
+			if !utf8.FullRune(valid[i:]) {
+				valid = valid[:i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(valid) {
+		return "utf-8", ""
+	}
+	if slices.ContainsFunc(sample, func(b byte) bool { return 0x80 <= b && 0x9F >= b }) {
+		return "windows-1252", "as it is not UTF-8 and has bytes typical of Windows-1252"
+	}
+	return "iso-8859-1", "as it is not UTF-8"
+}
+
+// Reader that fails on text that is not valid UTF-8, giving the byte offset of
+// the first invalid byte. Reads are expected to be into buffers longer than a
+// character, as those of a [bufio.Reader] are.
+type utf8Validator struct {
+	reader  io.Reader
+	path    string
+	pending []byte // Start of a character cut off at the end of the previous read
+	offset  int64  // Offset in the file of the first pending byte
+}
+
+func (v *utf8Validator) Read(p []byte) (int, error) {
+	k := copy(p, v.pending)
+	n, err := v.reader.Read(p[k:])
+	data := p[:k+n]
+	i := 0
+	for i < len(data) {
+		r, size := utf8.DecodeRune(data[i:])
+		if utf8.RuneError == r && 1 == size {
+			if nil == err && !utf8.FullRune(data[i:]) {
+				break
+			}
+			return 0, fmt.Errorf("Invalid UTF-8 at byte %d of %s, give -encoding for the encoding it is in", v.offset+int64(i), v.path)
+		}
+		i += size
+	}
+	v.offset += int64(i)
+	v.pending = append(v.pending[:0], data[i:]...)
+	return i, err
+}
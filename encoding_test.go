@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// Stations CSV with characters outside of ASCII, the en dash only in
+// Windows-1252 of the single byte encodings.
+const encodedStations = "Station,Ruby,Emerald\nZürich,true,false\nCafé's – Nord,true,true\n"
+
+// Encode the text, failing the test if it cannot be.
+func encodeText(t *testing.T, encoder *encoding.Encoder, text string) string {
+	t.Helper()
+	encoded, err := encoder.String(text)
+	if nil != err {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+// Files in other encodings convert to the same statements as in UTF-8, with the
+// encoding and why it was picked logged.
+func TestEncodingDetected(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": encodedStations})
+	want, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	latin1 := strings.ReplaceAll(encodedStations, "–", "-")
+	wantLatin1 := strings.ReplaceAll(want, "–", "-")
+	for _, test := range []struct {
+		name     string
+		stations string
+		want     string
+		logged   string
+	}{
+		{"iso-8859-1", encodeText(t, charmap.ISO8859_1.NewEncoder(), latin1), wantLatin1, "Reading stations.csv as iso-8859-1, as it is not UTF-8"},
+		{"windows-1252", encodeText(t, charmap.Windows1252.NewEncoder(), encodedStations), want, "Reading stations.csv as windows-1252, as it is not UTF-8 and has bytes typical of Windows-1252"},
+		{"utf-16le", encodeText(t, unicode.UTF16(unicode.LittleEndian, unicode.UseBOM).NewEncoder(), encodedStations), want, "Reading stations.csv as utf-16le, from its byte order mark"},
+		{"utf-16be", encodeText(t, unicode.UTF16(unicode.BigEndian, unicode.UseBOM).NewEncoder(), encodedStations), want, "Reading stations.csv as utf-16be, from its byte order mark"},
+		{"utf-16le-no-bom", encodeText(t, unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM).NewEncoder(), encodedStations), want, "Reading stations.csv as utf-16le, from the zero bytes of its ASCII characters"},
+		{"utf-8-bom", "\xEF\xBB\xBF" + encodedStations, want, "Reading stations.csv as utf-8, from its byte order mark"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": test.stations})
+			stdout, warnings, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+			if nil != err {
+				t.Fatal(err)
+			}
+			if test.want != stdout {
+				t.Errorf("Expected the same statements as from UTF-8:\n--- got ---\n%s\n--- want ---\n%s", stdout, test.want)
+			}
+			if !strings.Contains(warnings, test.logged) {
+				t.Errorf("Expected %q to be logged, got:\n%s", test.logged, warnings)
+			}
+			if strings.Contains(warnings, "lines.csv") {
+				t.Errorf("Expected nothing logged of the UTF-8 lines, got:\n%s", warnings)
+			}
+		})
+	}
+}
+
+// -encoding overrides detection, reading the same bytes as another encoding.
+func TestEncodingFlag(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": encodeText(t, charmap.Windows1252.NewEncoder(), encodedStations)})
+	stdout, warnings, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-encoding", "iso-8859-1")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "'Café''s \u0096 Nord'") {
+		t.Errorf("Expected the en dash read as an ISO-8859-1 control character:\n%s", stdout)
+	}
+	if strings.Contains(warnings, "Reading") {
+		t.Errorf("Expected nothing logged with -encoding, got:\n%s", warnings)
+	}
+}
+
+func TestEncodingErrors(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": encodeText(t, charmap.ISO8859_1.NewEncoder(), "Station,Ruby,Emerald\nZürich,true,false\n")})
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-encoding", "ebcdic"}, "Invalid encoding: ebcdic"},
+		{[]string{"-encoding", "UTF8"}, "Invalid encoding: UTF8"},
+		{[]string{"-encoding", "utf-8"}, "Invalid UTF-8 at byte 22 of stations.csv, give -encoding for the encoding it is in"},
+	} {
+		if _, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv"}, test.args...)...); nil == err || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected %q with %v, got %v", test.err, test.args, err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A street entrance of a station read from the entrances CSV.
+type entrance struct {
+	station       string // Name of the station the entrance leads to
+	name          string
+	latitude      string // SQL literal of the latitude
+	longitude     string // SQL literal of the longitude
+	emergencyOnly string // SQL literal of whether the entrance is only for emergencies
+	row           int    // Line of the entrances CSV the entrance was read from
+}
+
+// Parse the entrances CSV, rejecting any invalid coordinates. A blank
+// emergency_only cell means the entrance is open to everyone.
+func (c *conversion) parseEntrances(reader *csv.Reader) ([]entrance, error) {
+	reader.FieldsPerRecord = 5 // Station Name, Entrance Name, Latitude, Longitude, and Emergency Only
+	if _, err := reader.Read(); nil != err {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+
+	var entrances []entrance
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read record for entrance %d: %w", len(entrances)+1, err)
+		}
+		if err := c.nul.apply(reader, record); nil != err {
+			return nil, err
+		}
+
+		row, _ := reader.FieldPos(0)
+		current := entrance{station: strings.TrimSpace(record[0]), name: strings.TrimSpace(record[1]), emergencyOnly: "FALSE", row: row}
+		if 0 >= len(current.name) {
+			return nil, fmt.Errorf("Missing name for entrance in row %d", row)
+		}
+		if current.latitude, err = parseCoordinate(strings.TrimSpace(record[2]), 90); nil != err {
+			return nil, fmt.Errorf("Invalid latitude %q for entrance in row %d: %w", record[2], row, err)
+		}
+		if current.longitude, err = parseCoordinate(strings.TrimSpace(record[3]), 180); nil != err {
+			return nil, fmt.Errorf("Invalid longitude %q for entrance in row %d: %w", record[3], row, err)
+		}
+		if emergencyOnly := strings.TrimSpace(record[4]); "" != emergencyOnly {
+			value, err := strconv.ParseBool(emergencyOnly)
+			if nil != err {
+				return nil, fmt.Errorf("Invalid emergency_only %q for entrance in row %d: %w", record[4], row, err)
+			}
+			current.emergencyOnly = strings.ToUpper(strconv.FormatBool(value))
+		}
+		entrances = append(entrances, current)
+	}
+	return entrances, nil
+}
+
+// Look up the ID of the station of each entrance like
+// [conversion.resolveDevices], also returning the findings for the entrances
+// with the same name as an earlier one of the same station.
+func (c *conversion) resolveEntrances(entrances []entrance, stations []station, prefix string) ([]entrance, []int, []string, error) {
+	stationIds := make(map[string]int, len(stations))
+	for _, current := range stations {
+		stationIds[current.name] = current.id
+	}
+
+	var kept []entrance
+	var resolved []int
+	var findings []string
+	rows := make(map[string]int)
+	for _, current := range entrances {
+		stationId, found := stationIds[prefix+current.station]
+		if !found {
+			if err := c.forgive("unknown-stations", fmt.Errorf("Unknown station %s for entrance in row %d", current.station, current.row)); nil != err {
+				return nil, nil, nil, err
+			}
+			continue
+		}
+		key := strconv.Itoa(stationId) + "\x00" + strings.ToLower(current.name)
+		if row, found := rows[key]; found {
+			findings = append(findings, fmt.Sprintf("Station %s has the entrance %s in rows %d and %d", current.station, current.name, row, current.row))
+		} else {
+			rows[key] = current.row
+		}
+		kept = append(kept, current)
+		resolved = append(resolved, stationId)
+	}
+	return kept, resolved, findings, nil
+}
+
+// Generate the SQL statements for populating the 'Entrances' table, given the
+// station ID of each entrance from [conversion.resolveEntrances].
+func (e *emitter) entranceStatements(entrances []entrance, stationIds []int, writer io.Writer) error {
+	for i, current := range entrances {
+		if err := e.writeInsert(writer, "Entrances", []string{"id", "station_id", "name", "latitude", "longitude", "emergency_only"}, []string{strconv.Itoa(i + 1),
+			strconv.Itoa(stationIds[i]), e.quoteSqlString(current.name), current.latitude, current.longitude, current.emergencyOnly}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write entrance insert statement for row %d: %w", current.row, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Entrances of an unknown station are an error, or are dropped with a warning
+// with -force unknown-stations.
+func TestEntrancesUnknownStation(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":     testLines,
+		"stations.csv":  basicStations,
+		"entrances.csv": "Station,Entrance,Latitude,Longitude,Emergency Only\nFoo,North,38.8977,-77.0365,false\nBaz,South,38.8971,-77.036,\n",
+	})
+	_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-entrances", "entrances.csv")
+	if want := "Unknown station Baz for entrance in row 3"; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q, got %v", want, err)
+	}
+
+	stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-entrances", "entrances.csv", "-force", "unknown-stations")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := "INSERT INTO Entrances VALUES (1, 1, 'North', 38.8977, -77.0365, FALSE);\n"; !strings.Contains(stdout, want) || 1 != strings.Count(stdout, "INSERT INTO Entrances") {
+		t.Errorf("Expected only %q of the entrances:\n%s", want, stdout)
+	}
+	if !strings.Contains(stderr, "Unknown station Baz for entrance in row 3") {
+		t.Errorf("Expected the dropped entrance to be warned about:\n%s", stderr)
+	}
+}
+
+func TestEntrancesInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		entrance string
+		err      string
+	}{
+		{"latitude out of range", "Foo,North,90.5,-77.0365,false", `Invalid latitude "90.5" for entrance in row 2: Must be between -90 and 90`},
+		{"longitude out of range", "Foo,North,38.8977,-180.01,false", `Invalid longitude "-180.01" for entrance in row 2: Must be between -180 and 180`},
+		{"latitude not a number", "Foo,North,north,-77.0365,false", `Invalid latitude "north" for entrance in row 2`},
+		{"longitude NaN", "Foo,North,38.8977,NaN,false", `Invalid longitude "NaN" for entrance in row 2`},
+		{"blank longitude", "Foo,North,38.8977,,false", `Invalid longitude "" for entrance in row 2`},
+		{"missing name", "Foo, ,38.8977,-77.0365,false", "Missing name for entrance in row 2"},
+		{"emergency only", "Foo,North,38.8977,-77.0365,sometimes", `Invalid emergency_only "sometimes" for entrance in row 2`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{
+				"lines.csv":     testLines,
+				"stations.csv":  basicStations,
+				"entrances.csv": "Station,Entrance,Latitude,Longitude,Emergency Only\n" + test.entrance + "\n",
+			})
+			if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-entrances", "entrances.csv"); nil == err || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+// Two entrances of the same station with the same name in any case are a
+// warning, or an error with -strict, while the same name at another station is
+// not.
+func TestEntrancesDuplicateName(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":     testLines,
+		"stations.csv":  basicStations,
+		"entrances.csv": "Station,Entrance,Latitude,Longitude,Emergency Only\nFoo,North,38.8977,-77.0365,false\nBar's,North,38.9,-77.03,\nFoo,NORTH,38.8971,-77.036,true\n",
+	})
+	stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-entrances", "entrances.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := "Station Foo has the entrance NORTH in rows 2 and 4"; !strings.Contains(stderr, want) {
+		t.Errorf("Expected %q to be warned about:\n%s", want, stderr)
+	}
+	if strings.Contains(stderr, "Station Bar's has the entrance") {
+		t.Errorf("Expected no warning for the entrance of another station:\n%s", stderr)
+	}
+	if 3 != strings.Count(stdout, "INSERT INTO Entrances") {
+		t.Errorf("Expected every entrance to be kept:\n%s", stdout)
+	}
+
+	_, _, err = runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-entrances", "entrances.csv", "-strict")
+	if want := "Station Foo has the entrance NORTH in rows 2 and 4"; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q with -strict, got %v", want, err)
+	}
+}
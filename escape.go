@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Replacement applied by [conversion.escapeForStyle] after the escaping of the
+// dialect.
+type escapeRule struct {
+	from, to string
+}
+
+// Apply the escaping rules of the conversion to the value in order.
+func (c *conversion) applyEscapeRules(value string) string {
+	for _, rule := range c.escapeRules {
+		value = strings.ReplaceAll(value, rule.from, rule.to)
+	}
+	return value
+}
+
+// Parse an escaping rule of the form FROM=TO, where FROM and TO may contain
+// \xNN, \uNNNN, and \\ escapes.
+func parseEscapeRule(spec string) (escapeRule, error) {
+	from, to, found := strings.Cut(spec, "=")
+	if !found {
+		return escapeRule{}, fmt.Errorf("Missing '=' in %s", spec)
+	}
+
+	var rule escapeRule
+	var err error
+	if rule.from, err = unescapeRuleText(from); nil != err {
+		return escapeRule{}, fmt.Errorf("Invalid FROM in %s: %w", spec, err)
+	}
+	if 0 >= len(rule.from) {
+		return escapeRule{}, fmt.Errorf("Empty FROM in %s", spec)
+	}
+	if rule.to, err = unescapeRuleText(to); nil != err {
+		return escapeRule{}, fmt.Errorf("Invalid TO in %s: %w", spec, err)
+	}
+	return rule, nil
+}
+
+// Replace the \xNN, \uNNNN, and \\ escapes in one side of an escaping rule.
+func unescapeRuleText(text string) (string, error) {
+	var unescaped strings.Builder
+	for 0 < len(text) {
+		before, after, found := strings.Cut(text, `\`)
+		unescaped.WriteString(before)
+		if !found {
+			break
+		}
+
+		digits := 0
+		switch {
+		case strings.HasPrefix(after, `\`):
+			unescaped.WriteByte('\\')
+			text = after[1:]
+			continue
+		case strings.HasPrefix(after, "x"):
+			digits = 2
+		case strings.HasPrefix(after, "u"):
+			digits = 4
+		default:
+			return "", fmt.Errorf("Unknown escape sequence at %s", `\`+after)
+		}
+
+		if len(after) < 1+digits {
+			return "", fmt.Errorf("Incomplete escape sequence at %s", `\`+after)
+		}
+		code, err := strconv.ParseUint(after[1:1+digits], 16, 32)
+		if nil != err {
+			return "", fmt.Errorf("Invalid escape sequence at %s: %w", `\`+after, err)
+		}
+		if 2 == digits {
+			unescaped.WriteByte(byte(code))
+		} else {
+			unescaped.WriteRune(rune(code))
+		}
+		text = after[1+digits:]
+	}
+	return unescaped.String(), nil
+}
+
+// Read the escaping rules from a file with one FROM=TO rule per line, skipping
+// blank lines and lines starting with '#'. A failure to close the file is
+// logged to logger.
+func parseEscapeFile(path string, logger *log.Logger) ([]escapeRule, error) {
+	file, err := os.Open(path)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to open %s: %w", path, err)
+	}
+	defer func(file *os.File, path string) {
+		if err := file.Close(); nil != err {
+			logger.Printf("Failed to close %s: %v\n", path, err)
+		}
+	}(file, path)
+
+	var rules []escapeRule
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if "" == line || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule, err := parseEscapeRule(line)
+		if nil != err {
+			return nil, fmt.Errorf("Invalid rule on line %d of %s: %w", lineNumber, path, err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := scanner.Err(); nil != err {
+		return nil, fmt.Errorf("Failed to read %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// How NUL characters in the cells of the CSV files are handled. The zero value
+// strips them, which [EscapeSQL] does regardless.
+type nulPolicy struct {
+	reject      bool           // Whether a NUL character is an error
+	replacement string         // What each NUL character is replaced with
+	edits       []escapedValue // Every cell whose NUL characters were stripped or replaced so far
+}
+
+// Parse a -nul value: "strip", "error", "replace", or "replace=<char>".
+func parseNulPolicy(spec string) (nulPolicy, error) {
+	switch kind, replacement, found := strings.Cut(spec, "="); {
+	case "strip" == spec:
+		return nulPolicy{}, nil
+	case "error" == spec:
+		return nulPolicy{reject: true}, nil
+	case "replace" == spec:
+		return nulPolicy{replacement: "\uFFFD"}, nil
+	case "replace" == kind && found:
+		replacement, err := unescapeRuleText(replacement)
+		if nil != err {
+			return nulPolicy{}, err
+		}
+		if strings.Contains(replacement, "\x00") {
+			return nulPolicy{}, fmt.Errorf("Replacement must not contain NUL")
+		}
+		return nulPolicy{replacement: replacement}, nil
+	default:
+		return nulPolicy{}, fmt.Errorf("Unknown policy %s", spec)
+	}
+}
+
+// Apply the policy to the record most recently read by the reader, replacing
+// the NUL characters in place or returning an error naming the first offending
+// cell.
+func (policy *nulPolicy) apply(reader *csv.Reader, record []string) error {
+	for i, cell := range record {
+		if !strings.Contains(cell, "\x00") {
+			continue
+		}
+		if policy.reject {
+			row, _ := reader.FieldPos(i)
+			return fmt.Errorf("NUL character in row %d, column %d", row, i+1)
+		}
+		record[i] = strings.ReplaceAll(cell, "\x00", policy.replacement)
+		row, _ := reader.FieldPos(i)
+		policy.edits = append(policy.edits, escapedValue{Row: row, Column: fmt.Sprintf("column %d", i+1), Original: cell, Escaped: record[i], Nul: true})
+	}
+	return nil
+}
+
+// Value changed on its way into the statements, either by escaping or by the
+// NUL policy, as listed by -audit-escapes.
+type escapedValue struct {
+	Row      int    `json:"row"`
+	Column   string `json:"column"` // What the value is, or the column of the CSV file for NUL characters
+	Original string `json:"original"`
+	Escaped  string `json:"escaped"`
+	Nul      bool   `json:"nul,omitempty"` // Whether NUL characters were removed or replaced, losing information
+}
+
+// List every value about to be emitted that escaping changes, after the cells
+// whose NUL characters were stripped or replaced while parsing.
+func (c *conversion) auditEscapes(lines []railLine, stations []station, devices []device) []escapedValue {
+	audit := append([]escapedValue{}, c.nul.edits...)
+	visitValues(lines, stations, devices, func(value string, what string, row int) {
+		if escaped := c.escapeForStyle(value); value != escaped {
+			audit = append(audit, escapedValue{Row: row, Column: what, Original: value, Escaped: escaped})
+		}
+	})
+	return audit
+}
+
+// Write the audited values one per line, calling out those that lost NUL
+// characters.
+func writeEscapeAudit(writer io.Writer, audit []escapedValue) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, "Escaped values: %d\n", len(audit))
+	for _, value := range audit {
+		fmt.Fprintf(&text, "  Row %d, %s: %q -> %q", value.Row, value.Column, value.Original, value.Escaped)
+		if value.Nul {
+			text.WriteString(" (NUL characters removed, information was lost)")
+		}
+		text.WriteString("\n")
+	}
+	_, err := io.WriteString(writer, text.String())
+	return err
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEscapeRule(t *testing.T) {
+	tests := []struct {
+		spec string
+		want escapeRule
+		err  string
+	}{
+		{spec: `\\=\\\\`, want: escapeRule{`\`, `\\`}},
+		{spec: `\x0A=\\n`, want: escapeRule{"\n", `\n`}},
+		{spec: `\u00E9=e`, want: escapeRule{"é", "e"}},
+		{spec: `a=`, want: escapeRule{"a", ""}},
+		{spec: `a`, err: "Missing '='"},
+		{spec: `=b`, err: "Empty FROM"},
+		{spec: `\q=b`, err: "Unknown escape sequence"},
+		{spec: `\x4=b`, err: "Incomplete escape sequence"},
+	}
+	for _, test := range tests {
+		rule, err := parseEscapeRule(test.spec)
+		if "" != test.err {
+			if nil == err || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected %s to fail with %q, got %v", test.spec, test.err, err)
+			}
+		} else if nil != err || test.want != rule {
+			t.Errorf("Parsed %s into %+q and %v, want %+q", test.spec, rule, err, test.want)
+		}
+	}
+}
+
+// A rule doubling backslashes escapes them in a dialect that does not, after
+// the quotes are doubled.
+func TestBackslashRuleWithoutMysql(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo\\,true,false\nBar's \\n,true,true\n"})
+	plain, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plain, `'Foo\');`) {
+		t.Errorf("Expected the backslash to be left alone in standard SQL:\n%s", plain)
+	}
+	for _, dialect := range []string{"standard", "postgres", "sqlite"} {
+		stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-dialect", dialect, "-escape", `\\=\\\\`)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if !strings.Contains(stdout, `'Foo\\');`) || !strings.Contains(stdout, `'Bar''s \\n');`) {
+			t.Errorf("Expected the backslashes to be doubled in the %s dialect:\n%s", dialect, stdout)
+		}
+	}
+}
+
+func TestNulPolicies(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nB\x00ar,true,true\n"})
+	tests := []struct {
+		policy string
+		name   string // Name of the second station in the output
+		err    string
+	}{
+		{"strip", "'Bar'", ""},
+		{"replace", "'B\uFFFDar'", ""},
+		{`replace=\x20`, "'B ar'", ""},
+		{"error", "", "NUL character in row 3, column 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-nul", test.policy, "-audit-escapes")
+			if "" != test.err {
+				if nil == err || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("Expected an error containing %q, got %v", test.err, err)
+				}
+				return
+			}
+			if nil != err {
+				t.Fatal(err)
+			}
+			if !strings.Contains(stdout, "INSERT INTO Stations VALUES (2, "+test.name+");") {
+				t.Errorf("Expected station 2 to be %s:\n%s", test.name, stdout)
+			}
+			if !strings.Contains(stderr, "(NUL characters removed, information was lost)") {
+				t.Errorf("Expected the audit to call out the NUL character:\n%s", stderr)
+			}
+		})
+	}
+
+	if _, err := parseNulPolicy(`replace=\x00`); nil == err {
+		t.Error("Expected a NUL replacement to be an error")
+	}
+}
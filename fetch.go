@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Most redirects followed when fetching a CSV file.
+const maxRedirects = 5
+
+// Whether the path of a CSV file is an HTTP(S) URL to fetch it from.
+func isUrl(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://")
+}
+
+// Fetch the CSV file at url with the HTTP headers of the conversion, returning
+// its body. The body is an error once it is longer than the maximum download.
+func (c *conversion) fetchUrl(url string) (io.ReadCloser, error) {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if nil != err {
+		return nil, fmt.Errorf("Invalid URL %s: %w", url, err)
+	}
+	for _, header := range c.httpHeaders {
+		name, value, found := strings.Cut(header, ":")
+		if !found {
+			return nil, fmt.Errorf("Missing ':' in header %s", header)
+		}
+		request.Header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	client := &http.Client{
+		Timeout: c.httpTimeout,
+		CheckRedirect: func(request *http.Request, via []*http.Request) error {
+			if maxRedirects <= len(via) {
+				return fmt.Errorf("Stopped after %d redirects", maxRedirects)
+			}
+			return nil
+		},
+	}
+	response, err := client.Do(request)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to fetch %s: %w", url, err)
+	}
+	if http.StatusOK != response.StatusCode {
+		defer response.Body.Close()
+		start, _ := io.ReadAll(io.LimitReader(response.Body, 256))
+		return nil, fmt.Errorf("Failed to fetch %s: %s: %q", url, response.Status, start)
+	}
+	return &downloadLimiter{response.Body, url, c.maxDownloadBytes, c.maxDownloadBytes}, nil
+}
+
+// Body of a download that is an error once more than its limit of bytes is
+// read, and from then on.
+type downloadLimiter struct {
+	io.ReadCloser
+	url       string
+	limit     int64 // Most bytes the body may have
+	remaining int64 // Number of bytes left before the body is too long
+}
+
+func (limiter *downloadLimiter) Read(p []byte) (int, error) {
+	if int64(len(p)) > limiter.remaining+1 {
+		p = p[:limiter.remaining+1]
+	}
+	n, err := limiter.ReadCloser.Read(p)
+	if int64(n) > limiter.remaining {
+		// Nothing more is read, so that the rest of the body is never mistaken for
+		// the start of the file
+		limiter.remaining = -1
+		return 0, fmt.Errorf("Download of %s is over the maximum of %d bytes", limiter.url, limiter.limit)
+	}
+	limiter.remaining -= int64(n)
+	return n, err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Server of the CSV files of the basic.sql golden file, requiring the token as
+// its Authorization header.
+func csvServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	serve := func(text string) http.HandlerFunc {
+		return func(response http.ResponseWriter, request *http.Request) {
+			if "Bearer token" != request.Header.Get("Authorization") {
+				http.Error(response, "missing token", http.StatusUnauthorized)
+				return
+			}
+			response.Write([]byte(text))
+		}
+	}
+	mux.HandleFunc("/lines.csv", serve(testLines))
+	mux.HandleFunc("/stations.csv", serve(basicStations))
+	mux.HandleFunc("/big.csv", serve(basicStations+strings.Repeat("Baz,true,false\n", 100)))
+	mux.Handle("/moved.csv", http.RedirectHandler("/stations.csv", http.StatusFound))
+	mux.Handle("/loop.csv", http.RedirectHandler("/loop.csv", http.StatusFound))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchCsv(t *testing.T) {
+	server := csvServer(t)
+	for _, stations := range []string{"/stations.csv", "/moved.csv"} {
+		stdout, _, err := runArgs(t, "-lines", server.URL+"/lines.csv", "-stations", server.URL+stations, "-header", "Authorization: Bearer token")
+		if nil != err {
+			t.Fatalf("Fetching %s failed: %v", stations, err)
+		}
+		checkGolden(t, "basic.sql", stdout)
+	}
+}
+
+func TestFetchCsvErrors(t *testing.T) {
+	server := csvServer(t)
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"status", []string{"-stations", server.URL + "/stations.csv"},
+			"Failed to fetch " + server.URL + `/lines.csv: 401 Unauthorized: "missing token\n"`},
+		{"not found", []string{"-stations", server.URL + "/missing.csv", "-header", "Authorization: Bearer token"},
+			"Failed to fetch " + server.URL + `/missing.csv: 404 Not Found: "404 page not found\n"`},
+		{"redirects", []string{"-stations", server.URL + "/loop.csv", "-header", "Authorization: Bearer token"}, "Stopped after 5 redirects"},
+		{"too large", []string{"-stations", server.URL + "/big.csv", "-header", "Authorization: Bearer token", "-max-download-bytes", "1000"},
+			"Download of " + server.URL + "/big.csv is over the maximum of 1000 bytes"},
+		{"header", []string{"-stations", server.URL + "/stations.csv", "-header", "Authorization"}, "Missing ':' in header Authorization"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := runArgs(t, append([]string{"-lines", server.URL + "/lines.csv"}, test.args...)...)
+			if nil == err || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// The elevators, escalators, and accessible columns fill their columns of the
+// Stations table, with NULL for a blank cell.
+func TestVerticalTransportationColumns(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv": testLines,
+		"stations.csv": "Station,Ruby,Emerald,elevators,escalators,accessible\n" +
+			"Foo,true,false,2,0,true\n" +
+			"Bar,true,true,,6,F\n" +
+			"Baz,false,true,0,,\n",
+	})
+	stdout, warnings, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"INSERT INTO Stations (id, name, elevators, escalators, accessible) VALUES (1, 'Foo', 2, 0, TRUE);\n",
+		"INSERT INTO Stations (id, name, elevators, escalators, accessible) VALUES (2, 'Bar', NULL, 6, FALSE);\n",
+		"INSERT INTO Stations (id, name, elevators, escalators, accessible) VALUES (3, 'Baz', 0, NULL, NULL);\n",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Output is missing %q:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(warnings, "accessible") {
+		t.Errorf("Unexpected warning:\n%s", warnings)
+	}
+}
+
+func TestVerticalTransportationInvalid(t *testing.T) {
+	for _, test := range []struct {
+		column, value, err string
+	}{
+		{"elevators", "-1", `Invalid elevators "-1" for Foo: strconv.ParseUint: parsing "-1": invalid syntax`},
+		{"elevators", "1.5", `Invalid elevators "1.5" for Foo: strconv.ParseUint: parsing "1.5": invalid syntax`},
+		{"escalators", "many", `Invalid escalators "many" for Foo: strconv.ParseUint: parsing "many": invalid syntax`},
+		{"accessible", "maybe", `Invalid accessible "maybe" for Foo: strconv.ParseBool: parsing "maybe": invalid syntax`},
+	} {
+		t.Run(test.column+"/"+test.value, func(t *testing.T) {
+			writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald," + test.column + "\nFoo,true,false," + test.value + "\n"})
+			if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv"); nil == err || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("Expected an error containing %q, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+// An accessible station without any elevators is a warning naming it, but not
+// one with elevators, or without them and not accessible.
+func TestAccessibleWithoutElevators(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv": testLines,
+		"stations.csv": "Station,Ruby,Emerald,elevators,accessible\n" +
+			"Foo,true,false,0,true\n" +
+			"Bar,true,true,1,true\n" +
+			"Baz,false,true,0,false\n",
+	})
+	_, warnings, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(warnings, "Warning: Station Foo is marked accessible but has no elevators\n") {
+		t.Errorf("Expected a warning about Foo:\n%s", warnings)
+	}
+	if 1 != strings.Count(warnings, "marked accessible") {
+		t.Errorf("Expected only Foo to be warned about:\n%s", warnings)
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-fail-on-warning"); nil == err || !strings.Contains(err.Error(), "Station Foo is marked accessible but has no elevators") {
+		t.Errorf("Expected the warning to fail the conversion, got %v", err)
+	}
+}
+
+// The underground column fills its column of the Stations table, and an
+// underground station without a depth attribute is a warning naming it, but not
+// one with it or one above ground.
+func TestUnderground(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv": testLines,
+		"stations.csv": "Station,Ruby,Emerald,underground,@depth\n" +
+			"Foo,true,false,true,\n" +
+			"Bar,true,true,1,25\n" +
+			"Baz,false,true,F,\n" +
+			"Qux,false,true,,\n",
+	})
+	stdout, warnings, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"INSERT INTO Stations (id, name, underground) VALUES (1, 'Foo', TRUE);\n",
+		"INSERT INTO Stations (id, name, underground) VALUES (2, 'Bar', TRUE);\n",
+		"INSERT INTO Stations (id, name, underground) VALUES (3, 'Baz', FALSE);\n",
+		"INSERT INTO Stations (id, name, underground) VALUES (4, 'Qux', NULL);\n",
+		"INSERT INTO StationAttributes VALUES (2, 'depth', '25');\n",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Output is missing %q:\n%s", want, stdout)
+		}
+	}
+	if !strings.Contains(warnings, "Warning: Underground station Foo has no depth attribute for its pre-plan\n") {
+		t.Errorf("Expected a warning about Foo:\n%s", warnings)
+	}
+	if 1 != strings.Count(warnings, "Underground station") {
+		t.Errorf("Expected only Foo to be warned about:\n%s", warnings)
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-fail-on-warning"); nil == err || !strings.Contains(err.Error(), "Underground station Foo has no depth attribute for its pre-plan") {
+		t.Errorf("Expected the warning to fail the conversion, got %v", err)
+	}
+
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald,underground\nFoo,true,false,deep\n"})
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv"); nil == err || !strings.Contains(err.Error(), `Invalid underground "deep" for Foo`) {
+		t.Errorf("Expected an invalid underground, got %v", err)
+	}
+}
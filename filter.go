@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+)
+
+// External command the statements are piped through on their way to the
+// output, started once for the whole conversion.
+type filterCommand struct {
+	command string
+	process *exec.Cmd
+	stdin   io.WriteCloser
+	copied  chan error // Result of copying the command's Standard Out to the output
+	done    bool
+	logger  *log.Logger
+}
+
+// startFilter runs command with the shell and copies whatever it writes to
+// destination. The copy runs alongside the writes so a command that fills its
+// Standard Out before reading all of its Standard In cannot deadlock.
+func startFilter(command string, destination io.Writer, stderr io.Writer, logger *log.Logger) (*filterCommand, error) {
+	process := exec.Command("sh", "-c", command)
+	process.Stderr = stderr
+	stdin, err := process.StdinPipe()
+	if nil != err {
+		return nil, fmt.Errorf("Failed to connect to the filter command: %w", err)
+	}
+	stdout, err := process.StdoutPipe()
+	if nil != err {
+		return nil, fmt.Errorf("Failed to connect to the filter command: %w", err)
+	}
+	if err := process.Start(); nil != err {
+		return nil, fmt.Errorf("Failed to start the filter command: %w", err)
+	}
+	filter := &filterCommand{command: command, process: process, stdin: stdin, copied: make(chan error, 1), logger: logger}
+	go func() {
+		_, err := io.Copy(destination, stdout)
+		if nil != err {
+			// Keep draining so the command is not blocked writing
+			_, _ = io.Copy(io.Discard, stdout)
+		}
+		filter.copied <- err
+	}()
+	return filter, nil
+}
+
+func (filter *filterCommand) Write(data []byte) (int, error) {
+	written, err := filter.stdin.Write(data)
+	if nil != err {
+		return written, fmt.Errorf("Failed to write to the filter command: %w", err)
+	}
+	return written, nil
+}
+
+// finish closes the command's Standard In and waits for it to exit, failing
+// when it exits with a non-zero status or its output could not be written.
+func (filter *filterCommand) finish() error {
+	if filter.done {
+		return nil
+	}
+	filter.done = true
+	var errs []error
+	if err := filter.stdin.Close(); nil != err {
+		errs = append(errs, fmt.Errorf("Failed to close the filter command's input: %w", err))
+	}
+	if err := <-filter.copied; nil != err {
+		errs = append(errs, fmt.Errorf("Failed to write the filter command's output: %w", err))
+	}
+	if err := filter.process.Wait(); nil != err {
+		errs = append(errs, fmt.Errorf("Filter command %q failed: %w", filter.command, err))
+	}
+	return errors.Join(errs...)
+}
+
+// abort finishes the command when the conversion stopped early, only logging
+// any failure as the conversion already has an error of its own.
+func (filter *filterCommand) abort() {
+	if err := filter.finish(); nil != err {
+		filter.logger.Println(err)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestFilterCommand(t *testing.T) {
+	if _, err := exec.LookPath("sed"); nil != err {
+		t.Skip("No sed to filter with")
+	}
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n"})
+	plain, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	filtered, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-filter-cmd", "sed 's/^INSERT INTO/INSERT OR IGNORE INTO/'")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(plain, "INSERT INTO", "INSERT OR IGNORE INTO"); want != filtered {
+		t.Errorf("Filtered output:\n%s\nwant:\n%s", filtered, want)
+	}
+
+	// A failing command fails the conversion
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-filter-cmd", "sed -e 'bogus'"); nil == err {
+		t.Error("Expected the failing filter command to fail the conversion")
+	}
+}
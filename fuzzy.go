@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Station name compared for near duplicates, normalized as runes.
+type fuzzyName struct {
+	runes []rune
+	name  string
+	row   int
+}
+
+// Normalize a station name for finding near duplicates: compatibility
+// decomposed without its accents, lower case, with every kind of dash as a
+// hyphen and every run of spaces as one space.
+func normalizeFuzzy(name string) []rune {
+	var normalized []rune
+	space := false
+	for _, r := range norm.NFKD.String(strings.TrimSpace(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case unicode.Is(unicode.Dash, r):
+			r = '-'
+		default:
+			r = unicode.ToLower(r)
+		}
+		if space {
+			normalized = append(normalized, ' ')
+			space = false
+		}
+		normalized = append(normalized, r)
+	}
+	return normalized
+}
+
+// Levenshtein distance between a and b, or threshold+1 when it is more than the
+// threshold, returned as soon as that is known.
+func editDistance(a []rune, b []rune, threshold int) int {
+	previous, current := make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		lowest := current[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			lowest = min(lowest, current[j])
+		}
+		if lowest > threshold {
+			return threshold + 1
+		}
+		previous, current = current, previous
+	}
+	return min(previous[len(b)], threshold+1)
+}
+
+// List every pair of station names within the threshold of edits of each other
+// once normalized, along with their rows. Names are bucketed by length, so that
+// each is only compared to the names no more than the threshold longer.
+func findNearDuplicates(stations []station, threshold int) []string {
+	buckets := map[int][]fuzzyName{}
+	longest := 0
+	for _, current := range stations {
+		runes := normalizeFuzzy(current.name)
+		buckets[len(runes)] = append(buckets[len(runes)], fuzzyName{runes, current.name, current.row})
+		longest = max(longest, len(runes))
+	}
+	var findings []string
+	for length := 0; length <= longest; length++ {
+		for i, a := range buckets[length] {
+			for other := length; other <= length+threshold && other <= longest; other++ {
+				candidates := buckets[other]
+				if other == length {
+					candidates = candidates[i+1:]
+				}
+				for _, b := range candidates {
+					if distance := editDistance(a.runes, b.runes, threshold); distance <= threshold {
+						findings = append(findings, fmt.Sprintf("Station names %q in row %d and %q in row %d are %d edits apart once normalized",
+							a.name, a.row, b.name, b.row, distance))
+					}
+				}
+			}
+		}
+	}
+	return findings
+}
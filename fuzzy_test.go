@@ -0,0 +1,85 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b      string
+		threshold int
+		want      int
+	}{
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 5, 3},
+		// Cut short once every distance of a row is over the threshold
+		{"kitten", "sitting", 2, 3},
+		{"kitten", "sitting", 0, 1},
+		{"aaaa", "bbbb", 1, 2},
+		{"Foo", "Foo", 0, 0},
+		{"Foo", "Fo", 0, 1},
+		{"", "Foo", 3, 3},
+		{"", "Foo", 1, 2},
+		{"Foo", "", 1, 2},
+		{"ab", "ba", 2, 2},
+		// Over the threshold only once the last rune is compared
+		{"abcd", "abce", 0, 1},
+	}
+	for _, test := range tests {
+		if got := editDistance([]rune(test.a), []rune(test.b), test.threshold); test.want != got {
+			t.Errorf("Distance from %q to %q within %d is %d, want %d", test.a, test.b, test.threshold, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeFuzzy(t *testing.T) {
+	for name, want := range map[string]string{
+		"Franconia–Springfield": "franconia-springfield",
+		"Franconia—Springfield": "franconia-springfield",
+		" Café   de  Flore ":    "cafe de flore",
+		"ＧＷＵ":                   "gwu",
+		"Foggy Bottom\t- GWU":   "foggy bottom - gwu",
+	} {
+		if got := string(normalizeFuzzy(name)); want != got {
+			t.Errorf("Normalized %q as %q, want %q", name, got, want)
+		}
+	}
+}
+
+// Names are compared to those of the same length up to the threshold longer,
+// so pairs at the boundary are found whichever comes first, and the hyphen and
+// the en dash of the documented example are no edits apart.
+func TestFindNearDuplicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		names     []string // Names of the stations in rows 2 on
+		threshold int
+		want      []string
+	}{
+		{"dashes", []string{"Franconia-Springfield", "Franconia–Springfield"}, 0,
+			[]string{`"Franconia-Springfield" in row 2 and "Franconia–Springfield" in row 3 are 0 edits apart`}},
+		{"lengths at the threshold", []string{"Foo Street", "Foo St"}, 4,
+			[]string{`"Foo St" in row 3 and "Foo Street" in row 2 are 4 edits apart`}},
+		{"lengths past the threshold", []string{"Foo Street", "Foo St"}, 3, nil},
+		{"longest bucket", []string{"Barn", "Bar", "Baz"}, 1,
+			[]string{`"Bar" in row 3 and "Baz" in row 4 are 1 edits apart`, `"Bar" in row 3 and "Barn" in row 2 are 1 edits apart`}},
+		{"threshold zero", []string{"Bar", "Baz", "BAR"}, 0,
+			[]string{`"Bar" in row 2 and "BAR" in row 4 are 0 edits apart`}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stations []station
+			for i, name := range test.names {
+				stations = append(stations, station{name: name, row: i + 2})
+			}
+			var want []string
+			for _, pair := range test.want {
+				want = append(want, "Station names "+pair+" once normalized")
+			}
+			if got := findNearDuplicates(stations, test.threshold); !slices.Equal(want, got) {
+				t.Errorf("Found %q, want %q", got, want)
+			}
+		})
+	}
+}
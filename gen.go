@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+)
+
+// Patterns for the generated station names, exercising the escaping of quotes,
+// commas, and non-ASCII characters.
+var generatedStationNames = []string{
+	"Station %d",
+	"O'Brien Street %d",
+	"Market, Third %d",
+	`"Old" Depot %d`,
+	"Straße %d",
+	"Estación Ñuñoa %d",
+	"駅 %d",
+}
+
+// Patterns for the generated rail line names, kept within the 16 characters of
+// the RailLines table.
+var generatedLineNames = []string{
+	"Line %d",
+	"O'Line %d",
+	"Línea %d",
+	"Ring, %d",
+}
+
+// Write a synthetic network of any size to lines and stations CSV files, for
+// the gen subcommand.
+func (c *conversion) runGen(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("csv2sql gen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	stationCount := flags.Int("stations", 1000, "Number of stations to generate")
+	lineCount := flags.Int("lines", 10, "Number of rail lines to generate")
+	density := flags.Float64("density", 0.12, "Chance of each station being on each rail line, between 0 and 1")
+	seed := flags.Uint64("seed", 1, "Seed for the random memberships and colors")
+	linesPath := flags.String("lines-out", "lines.csv", "CSV file to write the rail lines to")
+	stationsPath := flags.String("stations-out", "stations.csv", "CSV file to write the stations to")
+	reportPath := flags.String("report", "", "File to write the expected statistics to as JSON, in the format of -report")
+	if err := flags.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if nil != err {
+		return errUsage
+	}
+	if 0 >= *stationCount || 0 >= *lineCount {
+		return fmt.Errorf("Generating requires at least one station and one rail line")
+	}
+	if 0 > *density || 1 < *density {
+		return fmt.Errorf("Invalid density: %g", *density)
+	}
+
+	lines, stations := generateNetwork(*lineCount, *stationCount, *density, *seed)
+	lineRecords := [][]string{{"Line", "Red", "Green", "Blue"}}
+	for _, line := range lines {
+		lineRecords = append(lineRecords, []string{line.name,
+			strconv.Itoa(int(line.red)), strconv.Itoa(int(line.green)), strconv.Itoa(int(line.blue))})
+	}
+	if err := writeCsvFile(*linesPath, lineRecords, c.logger); nil != err {
+		return fmt.Errorf("Failed to write rail lines: %w", err)
+	}
+
+	header := []string{"Station"}
+	for _, line := range lines {
+		header = append(header, line.name)
+	}
+	stationRecords := [][]string{header}
+	for _, current := range stations {
+		record := []string{current.name}
+		for lineId := 1; lineId <= len(lines); lineId++ {
+			record = append(record, strconv.FormatBool(slices.Contains(current.lines, lineId)))
+		}
+		stationRecords = append(stationRecords, record)
+	}
+	if err := writeCsvFile(*stationsPath, stationRecords, c.logger); nil != err {
+		return fmt.Errorf("Failed to write stations: %w", err)
+	}
+
+	if "" != *reportPath {
+		if err := c.newReport(lines, stations).writeFile(*reportPath, c.logger); nil != err {
+			return fmt.Errorf("Failed to write report: %w", err)
+		}
+	}
+	fmt.Fprintf(stderr, "Generated %d rail lines and %d stations\n", len(lines), len(stations))
+	return nil
+}
+
+// Generate the rail lines and stations of a network, deterministic for the seed.
+// Each station is on each rail line with the chance given by density, and on at
+// least one. The first stations link each rail line to the next so that the
+// network is connected.
+func generateNetwork(lineCount int, stationCount int, density float64, seed uint64) ([]railLine, []station) {
+	random := rand.New(rand.NewPCG(seed, seed))
+	lines := make([]railLine, lineCount)
+	for i := range lines {
+		pattern := generatedLineNames[i%len(generatedLineNames)]
+		lines[i] = railLine{
+			row:   i + 2,
+			name:  fmt.Sprintf(pattern, i+1),
+			red:   uint8(random.UintN(256)),
+			green: uint8(random.UintN(256)),
+			blue:  uint8(random.UintN(256)),
+		}
+	}
+
+	stations := make([]station, stationCount)
+	for i := range stations {
+		pattern := generatedStationNames[i%len(generatedStationNames)]
+		current := station{id: i + 1, row: i + 2, name: fmt.Sprintf(pattern, i+1)}
+		for lineId := 1; lineId <= lineCount; lineId++ {
+			if random.Float64() < density || (i+1 < lineCount && (lineId == i+1 || lineId == i+2)) {
+				current.lines = append(current.lines, lineId)
+			}
+		}
+		if 0 == len(current.lines) {
+			current.lines = []int{random.IntN(lineCount) + 1}
+		}
+		stations[i] = current
+	}
+	return lines, stations
+}
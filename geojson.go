@@ -0,0 +1,127 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// GeoJSON FeatureCollection of the network, per RFC 7946.
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+// GeoJSON Feature with a Point or LineString geometry.
+type feature struct {
+	Type       string   `json:"type"`
+	Geometry   geometry `json:"geometry"`
+	Properties any      `json:"properties"`
+}
+
+// GeoJSON geometry. Positions are longitude first, then latitude.
+type geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// Properties of the Point feature of a station.
+type stationProperties struct {
+	Id    int      `json:"id"`
+	Name  string   `json:"name"`
+	Lines []string `json:"lines"`
+}
+
+// Properties of the LineString feature of a rail line.
+type lineProperties struct {
+	Id    int    `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// Longitude and latitude of the station from its latitude and longitude fields,
+// and whether it has both.
+func coordinates(s station) ([2]float64, bool) {
+	var position [2]float64
+	found := 0
+	for _, f := range s.fields {
+		index := slices.Index([]string{"longitude", "latitude"}, f.column)
+		if 0 > index {
+			continue
+		}
+		degrees, err := strconv.ParseFloat(f.literal, 64)
+		if nil != err {
+			// NULL for a blank cell
+			return position, false
+		}
+		position[index] = degrees
+		found++
+	}
+	return position, 2 == found
+}
+
+// Write the network as a GeoJSON FeatureCollection: a Point feature for every
+// station in ID order, followed by a LineString feature for every rail line in
+// ID order when the positions of the stations along the rail lines are known.
+// A station without coordinates is left out, with a warning or when strict is
+// set an error, and the stations CSV must have latitude and longitude columns.
+func (c *conversion) writeGeoJson(writer io.Writer, lines []railLine, stations []station, strict bool) error {
+	if !slices.ContainsFunc(stations, func(s station) bool {
+		return slices.ContainsFunc(s.fields, func(f field) bool { return "latitude" == f.column }) &&
+			slices.ContainsFunc(s.fields, func(f field) bool { return "longitude" == f.column })
+	}) {
+		return fmt.Errorf("GeoJSON requires latitude and longitude columns in the stations CSV")
+	}
+
+	collection := featureCollection{Type: "FeatureCollection", Features: []feature{}}
+	positions := make(map[int][2]float64) // Coordinates by station ID
+	var missing []string
+	for _, current := range stations {
+		position, found := coordinates(current)
+		if !found {
+			missing = append(missing, fmt.Sprintf("Station %s in row %d has no coordinates and is left out of the GeoJSON", current.name, current.row))
+			continue
+		}
+		positions[current.id] = position
+		properties := stationProperties{Id: current.id, Name: current.name, Lines: []string{}}
+		for _, lineId := range current.lines {
+			properties.Lines = append(properties.Lines, lines[lineId-1].name)
+		}
+		collection.Features = append(collection.Features, feature{"Feature", geometry{"Point", position}, properties})
+	}
+	if err := c.lint("coordinates", missing, strict); nil != err {
+		return err
+	}
+	if err := c.checkWarnings(); nil != err {
+		return err
+	}
+
+	for i, line := range lines {
+		lineId := i + 1
+		var onLine []station
+		for _, current := range stations {
+			if _, found := current.positions[lineId]; found {
+				onLine = append(onLine, current)
+			}
+		}
+		slices.SortFunc(onLine, func(a, b station) int { return cmp.Compare(a.positions[lineId], b.positions[lineId]) })
+		var path [][2]float64
+		for _, current := range onLine {
+			if position, found := positions[current.id]; found {
+				path = append(path, position)
+			}
+		}
+		if 2 > len(path) {
+			continue
+		}
+		properties := lineProperties{lineId, line.name, fmt.Sprintf("#%02X%02X%02X", line.red, line.green, line.blue)}
+		collection.Features = append(collection.Features, feature{"Feature", geometry{"LineString", path}, properties})
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "\t")
+	return encoder.Encode(collection)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Members of a GTFS feed needed to build the network.
+var gtfsMembers = []string{"routes.txt", "stops.txt", "trips.txt", "stop_times.txt"}
+
+// First bytes of every ZIP archive.
+var zipMagic = []byte("PK\x03\x04")
+
+// Files of a GTFS feed, from a directory or a ZIP archive, opened by their
+// names in lower case without any directory.
+type gtfsFeed struct {
+	members map[string]func() (io.ReadCloser, error)
+	close   func() error
+	decode  func(io.Reader, string) (io.Reader, error) // Decodes a member to UTF-8
+	logger  *log.Logger                                // Where failures to close a member go
+}
+
+// Open the GTFS feed at path, either a directory or a ZIP archive, which is
+// read in place without extracting it, with its members decoded by decode.
+func openGtfs(feedPath string, decode func(io.Reader, string) (io.Reader, error), logger *log.Logger) (*gtfsFeed, error) {
+	info, err := os.Stat(feedPath)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to open GTFS feed: %w", err)
+	}
+	feed := &gtfsFeed{members: make(map[string]func() (io.ReadCloser, error)), close: func() error { return nil }, decode: decode, logger: logger}
+	if info.IsDir() {
+		entries, err := os.ReadDir(feedPath)
+		if nil != err {
+			return nil, fmt.Errorf("Failed to list GTFS feed %s: %w", feedPath, err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				name := filepath.Join(feedPath, entry.Name())
+				feed.members[strings.ToLower(entry.Name())] = func() (io.ReadCloser, error) { return os.Open(name) }
+			}
+		}
+		return feed, nil
+	}
+
+	if !strings.HasSuffix(strings.ToLower(feedPath), ".zip") {
+		magic := make([]byte, len(zipMagic))
+		file, err := os.Open(feedPath)
+		if nil != err {
+			return nil, fmt.Errorf("Failed to open GTFS feed: %w", err)
+		}
+		_, err = io.ReadFull(file, magic)
+		file.Close()
+		if nil != err || !bytes.Equal(zipMagic, magic) {
+			return nil, fmt.Errorf("GTFS feed %s is neither a directory nor a ZIP archive", feedPath)
+		}
+	}
+	archive, err := zip.OpenReader(feedPath)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to open GTFS archive %s: %w", feedPath, err)
+	}
+	for _, file := range archive.File {
+		if !file.FileInfo().IsDir() {
+			feed.members[strings.ToLower(path.Base(file.Name))] = file.Open
+		}
+	}
+	feed.close = archive.Close
+	return feed, nil
+}
+
+// Read every record of a member of the feed in turn, without holding the whole
+// member in memory, along with the line it starts on. The column function gives
+// the value of a column of the record by its name in the header, or "" if there
+// is no such column.
+func (feed *gtfsFeed) each(member string, visit func(row int, column func(string) string) error) error {
+	open, found := feed.members[member]
+	if !found {
+		return fmt.Errorf("Missing %s", member)
+	}
+	file, err := open()
+	if nil != err {
+		return fmt.Errorf("Failed to open %s: %w", member, err)
+	}
+	defer func(file io.ReadCloser) {
+		if err := file.Close(); nil != err {
+			feed.logger.Printf("Failed to close %s: %v\n", member, err)
+		}
+	}(file)
+
+	input, err := feed.decode(file, member)
+	if nil != err {
+		return err
+	}
+	reader := csv.NewReader(input)
+	reader.TrimLeadingSpace = true
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("%s is empty", member)
+	} else if nil != err {
+		return fmt.Errorf("Failed to read header of %s: %w", member, err)
+	}
+	indexes := make(map[string]int, len(header))
+	for i, name := range header {
+		indexes[strings.TrimSpace(name)] = i
+	}
+	var record []string
+	column := func(name string) string {
+		if index, found := indexes[name]; found {
+			return strings.TrimSpace(record[index])
+		}
+		return ""
+	}
+	for record, err = reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if nil != err {
+			return fmt.Errorf("Failed to read %s: %w", member, err)
+		}
+		row, _ := reader.FieldPos(0)
+		if err := visit(row, column); nil != err {
+			return fmt.Errorf("Row %d of %s: %w", row, member, err)
+		}
+	}
+	return nil
+}
+
+// Whether GTFS routes of the type run on rails: trams, subways, trains, cable
+// trams, funiculars, and monorails, along with the extended types for railways,
+// urban railways, and trams.
+func isRailRoute(routeType int) bool {
+	return slices.Contains([]int{0, 1, 2, 5, 7, 12}, routeType) ||
+		(100 <= routeType && 200 > routeType) || (400 <= routeType && 500 > routeType) || (900 <= routeType && 1000 > routeType)
+}
+
+// Build the rail lines and stations from the GTFS feed at path. Every rail route
+// is a rail line, named by its short name or else its long name and colored by
+// its route_color, and every stop a rail route calls at is a station, with stops
+// inside a parent station counting as that station. Only stop_times.txt, usually
+// by far the largest member, is not held in memory.
+func (c *conversion) parseGtfs(feedPath string) ([]railLine, []station, error) {
+	feed, err := openGtfs(feedPath, c.decode, c.logger)
+	if nil != err {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := feed.close(); nil != err {
+			c.logger.Printf("Failed to close %s: %v\n", feedPath, err)
+		}
+	}()
+	var missing []string
+	for _, member := range gtfsMembers {
+		if _, found := feed.members[member]; !found {
+			missing = append(missing, member)
+		}
+	}
+	if 0 < len(missing) {
+		found := slices.Sorted(maps.Keys(feed.members))
+		return nil, nil, fmt.Errorf("GTFS feed %s is missing %s, found only %s", feedPath, strings.Join(missing, ", "), strings.Join(found, ", "))
+	}
+
+	var lines []railLine
+	lineIds := make(map[string]int) // IDs of the rail lines by route_id
+	if err := feed.each("routes.txt", func(row int, column func(string) string) error {
+		routeType, err := strconv.Atoi(column("route_type"))
+		if nil != err {
+			return fmt.Errorf("Invalid route_type: %w", err)
+		}
+		if !isRailRoute(routeType) {
+			return nil
+		}
+		name := column("route_short_name")
+		if "" == name {
+			name = column("route_long_name")
+		}
+		if "" == name {
+			return fmt.Errorf("Route %s has no name", column("route_id"))
+		}
+		line := railLine{row: row, name: name, red: 0xFF, green: 0xFF, blue: 0xFF}
+		if color := column("route_color"); "" != color {
+			rgb, err := strconv.ParseUint(color, 16, 24)
+			if nil != err || 6 != len(color) {
+				return fmt.Errorf("Invalid route_color %q for route %s", color, name)
+			}
+			line.red, line.green, line.blue = uint8(rgb>>16), uint8(rgb>>8), uint8(rgb)
+		}
+		lines = append(lines, line)
+		lineIds[column("route_id")] = len(lines)
+		return nil
+	}); nil != err {
+		return nil, nil, fmt.Errorf("Failed to read routes: %w", err)
+	}
+
+	var stations []station
+	stationIndexes := make(map[string]int) // Index in stations by stop_id
+	parents := make(map[string]string)     // Parent station of each stop inside one, by stop_id
+	if err := feed.each("stops.txt", func(row int, column func(string) string) error {
+		stopId := column("stop_id")
+		if parent := column("parent_station"); "" != parent {
+			parents[stopId] = parent
+			return nil
+		}
+		if locationType := column("location_type"); "" != locationType && "0" != locationType && "1" != locationType {
+			// Entrances, generic nodes, and boarding areas are not stations
+			return nil
+		}
+		stationIndexes[stopId] = len(stations)
+		stations = append(stations, station{row: row, name: column("stop_name")})
+		return nil
+	}); nil != err {
+		return nil, nil, fmt.Errorf("Failed to read stops: %w", err)
+	}
+
+	tripLines := make(map[string]int) // Rail line ID of each trip on a rail route, by trip_id
+	if err := feed.each("trips.txt", func(_ int, column func(string) string) error {
+		if lineId, found := lineIds[column("route_id")]; found {
+			tripLines[column("trip_id")] = lineId
+		}
+		return nil
+	}); nil != err {
+		return nil, nil, fmt.Errorf("Failed to read trips: %w", err)
+	}
+
+	if err := feed.each("stop_times.txt", func(_ int, column func(string) string) error {
+		lineId, found := tripLines[column("trip_id")]
+		if !found {
+			return nil
+		}
+		stopId := column("stop_id")
+		if parent, found := parents[stopId]; found {
+			stopId = parent
+		}
+		index, found := stationIndexes[stopId]
+		if !found {
+			return fmt.Errorf("Trip %s calls at unknown stop %s", column("trip_id"), column("stop_id"))
+		}
+		if !slices.Contains(stations[index].lines, lineId) {
+			stations[index].lines = append(stations[index].lines, lineId)
+		}
+		return nil
+	}); nil != err {
+		return nil, nil, fmt.Errorf("Failed to read stop times: %w", err)
+	}
+
+	// Stops only served by other routes are not rail stations
+	stations = slices.DeleteFunc(stations, func(s station) bool { return 0 == len(s.lines) })
+	for i := range stations {
+		slices.Sort(stations[i].lines)
+	}
+	return lines, stations, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Members of a GTFS feed with a rail route and a bus route, and a platform
+// inside the station it belongs to.
+var gtfsFeedMembers = map[string]string{
+	"routes.txt":     "route_id,route_short_name,route_long_name,route_type,route_color\nR,Ruby,,1,FF0000\nB,42,,3,\nE,,Emerald,2,00FF00\n",
+	"stops.txt":      "stop_id,stop_name,location_type,parent_station\nfoo,Foo,1,\nfoo-1,Foo Platform 1,0,foo\nbar,Bar's,,\nbus,Bus Stop,,\n",
+	"trips.txt":      "route_id,trip_id\nR,r1\nB,b1\nE,e1\n",
+	"stop_times.txt": "trip_id,stop_id,stop_sequence\nr1,foo-1,1\nr1,bar,2\nb1,bus,1\ne1,bar,1\n",
+}
+
+// Write the members to a ZIP archive at path, each under dir and named as given.
+func writeZip(t *testing.T, path string, dir string, members map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+	for name, text := range members {
+		member, err := archive.Create(dir + name)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if _, err := member.Write([]byte(text)); nil != err {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); nil != err {
+		t.Fatal(err)
+	}
+}
+
+// A feed converts the same from a directory, a ZIP archive with its members in
+// a directory and in upper case, and a ZIP archive without the extension.
+func TestGtfsZip(t *testing.T) {
+	writeFiles(t, gtfsFeedMembers)
+	upper := make(map[string]string)
+	for name, text := range gtfsFeedMembers {
+		upper[strings.ToUpper(name)] = text
+	}
+	writeZip(t, "feed.zip", "google_transit/", upper)
+	writeZip(t, "feed.bin", "", gtfsFeedMembers)
+
+	want, _, err := runArgs(t, "-gtfs", ".")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"INSERT INTO RailLines VALUES (1, 'Ruby', 255, 0, 0);", "INSERT INTO RailLines VALUES (2, 'Emerald', 0, 255, 0);", "'Foo'", "'Bar''s'", "INSERT INTO LineStations VALUES (2, 2);"} {
+		if !strings.Contains(want, line) {
+			t.Errorf("Expected %s in:\n%s", line, want)
+		}
+	}
+	for _, unwanted := range []string{"42", "Bus Stop", "Platform"} {
+		if strings.Contains(want, unwanted) {
+			t.Errorf("Expected no %s in:\n%s", unwanted, want)
+		}
+	}
+	for _, feed := range []string{"feed.zip", "feed.bin"} {
+		stdout, _, err := runArgs(t, "-gtfs", feed)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if want != stdout {
+			t.Errorf("Conversion of %s:\n%s\nwant:\n%s", feed, stdout, want)
+		}
+	}
+}
+
+func TestGtfsZipErrors(t *testing.T) {
+	writeFiles(t, map[string]string{"feed.txt": "route_id\n"})
+	writeZip(t, "partial.zip", "", map[string]string{"routes.txt": gtfsFeedMembers["routes.txt"], "stops.txt": gtfsFeedMembers["stops.txt"], "agency.txt": "agency_id\n"})
+	for _, test := range []struct {
+		feed string
+		err  string
+	}{
+		{"partial.zip", "GTFS feed partial.zip is missing trips.txt, stop_times.txt, found only agency.txt, routes.txt, stops.txt"},
+		{"feed.txt", "GTFS feed feed.txt is neither a directory nor a ZIP archive"},
+		{"missing.zip", "Failed to open GTFS feed"},
+	} {
+		if _, _, err := runArgs(t, "-gtfs", test.feed); nil == err || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected an error containing %q with %s, got %v", test.err, test.feed, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+)
+
+// Table recording every import made with -mark-import.
+const importLogTable = `CREATE TABLE IF NOT EXISTS ImportLog (hash CHAR(64) PRIMARY KEY, ` +
+	`imported_at TIMESTAMP NOT NULL, rail_lines INTEGER NOT NULL, stations INTEGER NOT NULL, links INTEGER NOT NULL);`
+
+// Hash the rows the statements load, so that the same data has the same hash
+// however its CSV files are laid out.
+func importHash(planned tableRows) string {
+	hash := sha256.New()
+	for _, table := range slices.Sorted(maps.Keys(planned)) {
+		fmt.Fprintf(hash, "%s\n", table)
+		for _, row := range planned[table] {
+			for _, column := range slices.Sorted(maps.Keys(row)) {
+				fmt.Fprintf(hash, "%s=%q\n", column, row[column])
+			}
+			fmt.Fprintln(hash)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Write the statements creating the import log and stopping the script when it
+// already has the hash, before any data, with [conversion.guardStatement]. The
+// other dialects cannot fail a script outside a stored program, so they get a
+// SELECT of the earlier import after a comment, and only fail on the primary
+// key of the hash once the data is in.
+func (c *conversion) importGuard(writer io.Writer, hash string) error {
+	message := fmt.Sprintf("Already imported the data with hash %s", hash)
+	statements := []string{"-- Import of the data with hash " + hash, importLogTable}
+	if guard, found := c.guardStatement(fmt.Sprintf("EXISTS (SELECT 1 FROM ImportLog WHERE hash = '%s')", hash), message); found {
+		statements = append(statements, guard)
+	} else {
+		statements = append(statements, "-- Stop if this finds a row: "+message,
+			fmt.Sprintf("SELECT imported_at FROM ImportLog WHERE hash = '%s';", hash))
+	}
+	return writeStatements(writer, statements)
+}
+
+// Write the statement recording the import with its number of rail lines,
+// stations, and links, after the data.
+func (c *conversion) importRecord(writer io.Writer, hash string, planned tableRows) error {
+	return writeStatements(writer, []string{fmt.Sprintf("INSERT INTO ImportLog VALUES ('%s', %s, %d, %d, %d);",
+		hash, c.currentTimestamp(), len(planned["raillines"]), len(planned["stations"]), len(planned["linestations"]))})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Index created by a CREATE INDEX statement of the schema.
+type schemaIndex struct {
+	name      string // Name of the index as spelled in the schema
+	table     string // Name of the indexed table as spelled in the schema
+	statement string // The whole statement on one line, including the semicolon
+}
+
+// Every CREATE INDEX statement, up to its semicolon.
+var createIndexPattern = regexp.MustCompile(`(?i)CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)\s+ON\s+(?:ONLY\s+)?([^\s(]+)[^;]*;`)
+
+// Find the CREATE INDEX statements of a schema. Primary keys and other table
+// constraints are part of their CREATE TABLE statements and never among them.
+func parseIndexes(text string) []schemaIndex {
+	var indexes []schemaIndex
+	for _, match := range createIndexPattern.FindAllStringSubmatch(uncomment(text), -1) {
+		indexes = append(indexes, schemaIndex{
+			name:      match[1],
+			table:     match[2],
+			statement: strings.Join(strings.Fields(match[0]), " "),
+		})
+	}
+	return indexes
+}
+
+// Write the statements dropping the indexes before the data, so that the rows
+// are inserted without maintaining them, explained by a comment.
+func (c *conversion) dropIndexes(writer io.Writer, indexes []schemaIndex) error {
+	names := make([]string, len(indexes))
+	for i, index := range indexes {
+		names[i] = index.name
+	}
+	statements := []string{"-- Indexes dropped until after the data, which is faster to insert without them: " + strings.Join(names, ", ")}
+	for _, index := range indexes {
+		if "mysql" == c.dialect {
+			statements = append(statements, fmt.Sprintf("DROP INDEX %s ON %s;", index.name, index.table))
+		} else {
+			statements = append(statements, fmt.Sprintf("DROP INDEX IF EXISTS %s;", index.name))
+		}
+	}
+	return writeStatements(writer, statements)
+}
+
+// Write the statements creating the indexes dropped by [conversion.dropIndexes]
+// again, after the data.
+func createIndexes(writer io.Writer, indexes []schemaIndex) error {
+	statements := []string{"-- Indexes dropped before the data created again"}
+	for _, index := range indexes {
+		statements = append(statements, index.statement)
+	}
+	return writeStatements(writer, statements)
+}
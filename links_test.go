@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// A link given twice in the pairs format is emitted once, and the one dropped is
+// counted in the summary and the report.
+func TestDuplicateLinksDropped(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,Line\nFoo,Ruby\nBar's,Ruby\nFoo,Ruby\nBar's,Emerald\n",
+	})
+	stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-stations-format", "pairs", "-summary", "-report", "report.json")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "basic.sql", stdout)
+	if !strings.Contains(stderr, "Duplicate links dropped: 1\n") {
+		t.Errorf("Expected the dropped link in the summary:\n%s", stderr)
+	}
+	text, err := os.ReadFile("report.json")
+	if nil != err {
+		t.Fatal(err)
+	}
+	var report struct {
+		DuplicateLinks int `json:"duplicate_links"`
+	}
+	if err := json.Unmarshal(text, &report); nil != err {
+		t.Fatal(err)
+	}
+	if 1 != report.DuplicateLinks {
+		t.Errorf("Expected 1 duplicate link in the report, got %d", report.DuplicateLinks)
+	}
+
+	// Without duplicates the summary leaves the count out
+	if err := os.WriteFile("unique.csv", []byte("Station,Line\nFoo,Ruby\nBar's,Ruby\nBar's,Emerald\n"), 0o644); nil != err {
+		t.Fatal(err)
+	}
+	if _, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "unique.csv", "-stations-format", "pairs", "-summary"); nil != err || strings.Contains(stderr, "Duplicate links") {
+		t.Errorf("Unexpected summary with %v:\n%s", err, stderr)
+	}
+}
+
+func TestDedupeLinks(t *testing.T) {
+	stations := []station{{name: "Foo", lines: []int{2, 1, 2, 2}}, {name: "Bar", lines: []int{1}}}
+	if dropped := dedupeLinks(stations); 2 != dropped {
+		t.Errorf("Expected 2 links dropped, got %d", dropped)
+	}
+	if !slices.Equal([]int{1, 2}, stations[0].lines) || !slices.Equal([]int{1}, stations[1].lines) {
+		t.Errorf("Unexpected links: %v", stations)
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Classes of the findings of the data quality checks, for -force.
+var findingClasses = []string{"agencies", "bool-styles", "complexes", "coordinates", "disconnected", "distances", "duplicates", "empty-columns", "inconsistent", "names", "near-duplicates",
+	"panels", "renames", "shifted", "stale-columns", "suspicious", "sync", "unknown-stations"}
+
+// Finding of a data quality check raised as a warning. Its severity is
+// "warning", or "forced" when it would have been an error without -force.
+type finding struct {
+	Class    string `json:"class"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Log a warning of the class to Standard Error and record it for the summary and
+// report.
+func (c *conversion) warn(class string, format string, args ...any) {
+	c.raise(class, "warning", fmt.Sprintf(format, args...))
+}
+
+// Log a finding of the class with its severity to Standard Error and record it.
+func (c *conversion) raise(class string, severity string, message string) {
+	if "forced" == severity {
+		c.logger.Println("Warning (forced):", message)
+	} else {
+		c.logger.Println("Warning:", message)
+	}
+	c.warnings = append(c.warnings, message)
+	c.findings = append(c.findings, finding{class, severity, message})
+}
+
+// Raise each finding of a data quality check of the class as a warning, or when
+// strict is set, return them all as an error instead, unless -force names the
+// class.
+func (c *conversion) lint(class string, messages []string, strict bool) error {
+	severity := "warning"
+	if strict && slices.Contains(c.forcedClasses, class) {
+		severity = "forced"
+	} else if strict {
+		errs := make([]error, len(messages))
+		for i, message := range messages {
+			errs[i] = errors.New(message)
+		}
+		return errors.Join(errs...)
+	}
+	for _, message := range messages {
+		c.raise(class, severity, message)
+	}
+	return nil
+}
+
+// Turn the error of the class into a warning and return nil when -force names
+// the class, so that the caller can skip what it was about, and otherwise
+// return the error.
+func (c *conversion) forgive(class string, err error) error {
+	if !slices.Contains(c.forcedClasses, class) {
+		return err
+	}
+	c.raise(class, "forced", err.Error())
+	return nil
+}
+
+// Fail with -fail-on-warning when any warning was raised that -force did not
+// ask for.
+func (c *conversion) checkWarnings() error {
+	if !c.failOnWarning {
+		return nil
+	}
+	var errs []error
+	for _, current := range c.findings {
+		if "warning" == current.Severity {
+			errs = append(errs, errors.New(current.Message))
+		}
+	}
+	if 0 < len(errs) {
+		return fmt.Errorf("Failing on %d warnings with -fail-on-warning: %w", len(errs), errors.Join(errs...))
+	}
+	return nil
+}
+
+// Values that could be part of an SQL injection attack: statement separators,
+// comments, and destructive statements.
+var suspiciousPattern = regexp.MustCompile(`;|--|/\*|(?i:\b(DROP|DELETE|UPDATE) )`)
+
+// List every value about to be emitted that looks like it could be an SQL
+// injection attempt, along with where it came from.
+func findSuspicious(lines []railLine, stations []station, devices []device) []string {
+	var findings []string
+	visitValues(lines, stations, devices, func(value string, what string, row int) {
+		if suspiciousPattern.MatchString(value) {
+			findings = append(findings, fmt.Sprintf("Suspicious %s in row %d: %q", what, row, value))
+		}
+	})
+	return findings
+}
+
+// List every rail line and station name that fails to match one of the patterns
+// given for it, along with its row and the pattern.
+func findNameViolations(lines []railLine, stations []station, linePatterns []*regexp.Regexp, stationPatterns []*regexp.Regexp) []string {
+	var findings []string
+	for _, line := range lines {
+		for _, pattern := range linePatterns {
+			if !pattern.MatchString(line.name) {
+				findings = append(findings, fmt.Sprintf("Rail line name %q in row %d does not match -line-name-pattern %q", line.name, line.row, pattern))
+			}
+		}
+	}
+	for _, current := range stations {
+		for _, pattern := range stationPatterns {
+			if !pattern.MatchString(current.name) {
+				findings = append(findings, fmt.Sprintf("Station name %q in row %d does not match -name-pattern %q", current.name, current.row, pattern))
+			}
+		}
+	}
+	return findings
+}
+
+// Call visit with every string value about to be emitted as a string literal,
+// what it is, and the row of the CSV file it came from.
+func visitValues(lines []railLine, stations []station, devices []device, visit func(value string, what string, row int)) {
+	for _, line := range lines {
+		visit(line.name, "rail line name", line.row)
+	}
+	for _, current := range stations {
+		visit(current.name, "station name", current.row)
+		visit(current.zone, "alarm zone", current.row)
+		visit(current.complex, "station complex", current.row)
+		for _, tag := range current.panels {
+			visit(tag, "panel tag", current.row)
+		}
+		for _, alias := range current.aliases {
+			visit(alias, "station alias", current.row)
+		}
+		for _, name := range current.names {
+			visit(name.name, "translated station name", current.row)
+		}
+		for _, attr := range current.attributes {
+			visit(attr.key, "attribute name", current.row)
+			visit(attr.value, "attribute value", current.row)
+		}
+	}
+	for _, current := range devices {
+		visit(current.kind, "device type", current.row)
+		visit(current.serial, "device serial", current.row)
+	}
+}
+
+// Spelling style of a boolean token accepted by [strconv.ParseBool], like
+// "TRUE/FALSE" or "1/0".
+func boolTokenStyle(token string) string {
+	switch token {
+	case "1", "0":
+		return "1/0"
+	case "t", "f":
+		return "t/f"
+	case "T", "F":
+		return "T/F"
+	case "true", "false":
+		return "true/false"
+	case "TRUE", "FALSE":
+		return "TRUE/FALSE"
+	}
+	return "True/False"
+}
+
+// First place a boolean token style was seen in the stations CSV.
+type boolTokenSample struct {
+	style  string
+	row    int
+	column string
+}
+
+// Finding for a scope of the stations CSV, a rail line column or the whole file,
+// that mixes the boolean token styles of the samples, if it does.
+func mixedBoolStyles(scope string, samples []boolTokenSample, withColumns bool) []string {
+	if 2 > len(samples) {
+		return nil
+	}
+	seen := make([]string, len(samples))
+	for i, sample := range samples {
+		if withColumns {
+			seen[i] = fmt.Sprintf("%s (row %d, %s)", sample.style, sample.row, sample.column)
+		} else {
+			seen[i] = fmt.Sprintf("%s (row %d)", sample.style, sample.row)
+		}
+	}
+	return []string{fmt.Sprintf("%s mixes boolean styles, it may have been edited by several people: %s", scope, strings.Join(seen, ", "))}
+}
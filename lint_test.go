@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Every name must match every pattern given for it, and each failure is a
+// warning listed in the report with the pattern, or an error with -strict.
+func TestNamePatterns(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines + "ruby-2,0,0,255\n",
+		"stations.csv": "Station,Ruby,Emerald,ruby-2\nFoo,true,false,false\nbar Station,true,true,true\nBäz,false,true,false\n",
+	})
+	args := []string{"-lines", "lines.csv", "-stations", "stations.csv", "-name-pattern", "^[A-Z]", "-name-pattern", "^[ -~]+$", "-line-name-pattern", "^[A-Z][a-z]+$", "-report", "report.json"}
+	_, stderr, err := runArgs(t, args...)
+	if nil != err {
+		t.Fatal(err)
+	}
+	want := []string{
+		`Rail line name "ruby-2" in row 4 does not match -line-name-pattern "^[A-Z][a-z]+$"`,
+		`Station name "bar Station" in row 3 does not match -name-pattern "^[A-Z]"`,
+		`Station name "Bäz" in row 4 does not match -name-pattern "^[ -~]+$"`,
+	}
+	for _, message := range want {
+		if !strings.Contains(stderr, message) {
+			t.Errorf("Expected %q to be warned about:\n%s", message, stderr)
+		}
+	}
+	if strings.Contains(stderr, `"Foo"`) || strings.Contains(stderr, `"bar Station" in row 3 does not match -name-pattern "^[ -~]+$"`) {
+		t.Errorf("Expected only the failed patterns to be warned about:\n%s", stderr)
+	}
+	text, err := os.ReadFile("report.json")
+	if nil != err {
+		t.Fatal(err)
+	}
+	var report struct {
+		Findings []finding `json:"findings"`
+	}
+	if err := json.Unmarshal(text, &report); nil != err {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, current := range report.Findings {
+		if "names" == current.Class {
+			got = append(got, current.Message)
+		}
+	}
+	if strings.Join(want, "\n") != strings.Join(got, "\n") {
+		t.Errorf("Report lists the names:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	if _, _, err := runArgs(t, append(args, "-strict")...); nil == err || !strings.Contains(err.Error(), want[1]) {
+		t.Errorf("Expected the names to be an error with -strict, got %v", err)
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-name-pattern", "("); nil == err || !strings.Contains(err.Error(), "-name-pattern") {
+		t.Errorf("Expected an invalid pattern to be an error, got %v", err)
+	}
+}
+
+// Columns mixing boolean spellings are warned about with a sample row of each
+// spelling, or the whole file with -bool-consistency file, without changing
+// how the values are read.
+func TestMixedBoolStyles(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,Ruby,Emerald\nFoo,TRUE,1\nBar's,TRUE,1\nBaz,1,0\nQux,FALSE,1\n",
+	})
+	want, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-bool-consistency", "off")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		scope    string
+		warnings []string
+		unwanted string
+	}{
+		{"column", []string{"Column 2 for rail line Ruby mixes boolean styles, it may have been edited by several people: TRUE/FALSE (row 2), 1/0 (row 4)"}, "Column 3"},
+		{"file", []string{"The stations CSV mixes boolean styles, it may have been edited by several people: TRUE/FALSE (row 2, Ruby), 1/0 (row 2, Emerald)"}, "Column 2"},
+		{"off", nil, "mixes boolean styles"},
+	} {
+		t.Run(test.scope, func(t *testing.T) {
+			stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-bool-consistency", test.scope)
+			if nil != err {
+				t.Fatal(err)
+			}
+			if want != stdout {
+				t.Errorf("Expected the same statements as without the check:\n%s\nwant:\n%s", stdout, want)
+			}
+			for _, warning := range test.warnings {
+				if !strings.Contains(stderr, warning) {
+					t.Errorf("Expected %q to be warned about:\n%s", warning, stderr)
+				}
+			}
+			if strings.Contains(stderr, test.unwanted) {
+				t.Errorf("Expected no %q in:\n%s", test.unwanted, stderr)
+			}
+		})
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-bool-consistency", "row"); nil == err {
+		t.Error("Expected an invalid -bool-consistency to be an error")
+	}
+}
+
+// Values that look like an injection attempt are warned about with their row
+// but written as they are, and are an error with -strict.
+func TestSuspiciousValues(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,Ruby,Emerald,@note\nFoo'); DROP TABLE Stations; --,true,false,\nBar's,true,true,/* hidden */\nBaz,false,true,Update soon\nQux,false,true,Dropped off\n",
+	})
+	stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`Suspicious station name in row 2: "Foo'); DROP TABLE Stations; --"`,
+		`Suspicious attribute value in row 3: "/* hidden */"`,
+		`Suspicious attribute value in row 4: "Update soon"`,
+	} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("Expected %q to be warned about:\n%s", want, stderr)
+		}
+	}
+	if strings.Contains(stderr, "Dropped off") {
+		t.Errorf("Expected no warning of a word only starting like DROP:\n%s", stderr)
+	}
+	if !strings.Contains(stdout, "(1, 'Foo''); DROP TABLE Stations; --');") {
+		t.Errorf("Expected the value to be written as it is:\n%s", stdout)
+	}
+
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-strict"); nil == err || !strings.Contains(err.Error(), "Suspicious station name in row 2") {
+		t.Errorf("Expected the suspicious values to be an error with -strict, got %v", err)
+	}
+	if _, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-strict", "-warn-suspicious=false"); nil != err || strings.Contains(stderr, "Suspicious") {
+		t.Errorf("Expected no check with -warn-suspicious=false, got %v:\n%s", err, stderr)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Shapes of the stations CSV: a "matrix" with a column for every rail line, a
+// "list" of the rail lines of each station in one column, or "pairs" of a
+// station and one of its rail lines on every row.
+var stationShapes = []string{"matrix", "list", "pairs"}
+
+// Work out the shape of the stations CSV from its header and first records.
+// Rail lines separated by semicolons make a list, and more than two columns
+// otherwise make a matrix. Two columns make pairs unless the second could be a
+// rail line column of booleans or positions, which is an error rather than a
+// guess.
+func sniffShape(header []string, sample [][]string) (string, error) {
+	if 2 > len(header) {
+		return "matrix", nil
+	}
+	if slices.ContainsFunc(sample, func(record []string) bool { return 1 < len(record) && strings.Contains(record[1], ";") }) {
+		return "list", nil
+	}
+	if 2 < len(header) {
+		return "matrix", nil
+	}
+	membership := func(record []string) bool {
+		value := strings.TrimSpace(record[1])
+		_, boolErr := strconv.ParseBool(value)
+		position, intErr := strconv.Atoi(value)
+		return "" == value || nil == boolErr || (nil == intErr && 0 <= position)
+	}
+	if 0 == len(sample) || !slices.ContainsFunc(sample, func(record []string) bool { return 2 > len(record) || !membership(record) }) {
+		return "", fmt.Errorf("Cannot tell whether the stations CSV is a rail line column of booleans or pairs of stations and rail lines, give -stations-format")
+	}
+	return "pairs", nil
+}
+
+// Work out the shape of the stations CSV from the header and first records at
+// the start of the input with [sniffShape], without consuming it, so that the
+// parser reads the file as it is and numbers its rows the same. A record cut
+// off at the end of the sample, or one the parser is going to fail on, ends the
+// sample early.
+func (c *conversion) detectShape(input *bufio.Reader, comma rune) (string, error) {
+	sample, err := input.Peek(sniffBytes)
+	if nil != err && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("Failed to read the stations CSV: %w", err)
+	} else if nil == err {
+		if end := bytes.LastIndexByte(sample, '\n'); 0 <= end {
+			sample = sample[:end+1]
+		}
+	}
+
+	reader := csv.NewReader(bytes.NewReader(sample))
+	reader.Comma = comma
+	reader.Comment = c.comment
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	var records [][]string
+	for range 1 + sniffRecords {
+		record, err := reader.Read()
+		if nil != err {
+			break
+		}
+		records = append(records, record)
+	}
+
+	shape := "matrix"
+	if 0 < len(records) {
+		if shape, err = sniffShape(records[0], records[1:]); nil != err {
+			return "", err
+		}
+	}
+	if "matrix" != shape {
+		c.logger.Printf("Detected the %s format for the stations CSV\n", shape)
+	}
+	return shape, nil
+}
+
+// Read a stations CSV in the list format, where the second column lists the rail
+// lines of each station separated by semicolons, or in the pairs format, where
+// every row gives a station and one of its rail lines, and rewrite it as the
+// matrix format with a column for every rail line in the order they are first
+// mentioned. Any further columns of the list format are kept after the rail
+// line columns, while pairs must have just the two. The rail lines must be
+// mentioned in the order of the lines CSV, as the matrix columns are numbered by
+// it. A rail line given for a station more than once is only kept once, and the
+// number of those dropped is returned along with the reader.
+func listToMatrix(reader *csv.Reader, lines []railLine, pairs bool) (*csv.Reader, int, error) {
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, 0, fmt.Errorf("File is empty, expected a header row naming the station column and the rail lines column")
+	} else if nil != err {
+		return nil, 0, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+	if 2 > len(header) {
+		return nil, 0, fmt.Errorf("Expected a rail lines column after the station column")
+	} else if pairs && 2 < len(header) {
+		return nil, 0, fmt.Errorf("Expected only a station column and a rail line column in pairs, found %d columns", len(header))
+	}
+	header = slices.Clone(header)
+	reader.FieldsPerRecord = len(header)
+
+	var names []string
+	var records [][]string
+	var memberships [][]string      // Rail lines of each record, without duplicates
+	indexes := make(map[string]int) // Index in records of each station of pairs by name
+	duplicates := 0
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if nil != err {
+			return nil, 0, fmt.Errorf("Failed to read record for station %d: %w", len(records)+1, err)
+		}
+		index, found := len(records), false
+		if pairs {
+			// Every row of a station after the first only adds a rail line
+			if index, found = indexes[strings.TrimSpace(record[0])]; !found {
+				index = len(records)
+				indexes[strings.TrimSpace(record[0])] = index
+			}
+		}
+		if !found {
+			records = append(records, slices.Clone(record))
+			memberships = append(memberships, nil)
+		}
+		for _, name := range strings.Split(record[1], ";") {
+			if name = strings.TrimSpace(name); "" != name && slices.Contains(memberships[index], name) {
+				duplicates++
+			} else if "" != name {
+				memberships[index] = append(memberships[index], name)
+			}
+			if "" != name && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	for i, name := range names {
+		if len(lines) <= i {
+			return nil, 0, fmt.Errorf("Rail line %s is not in the lines CSV", name)
+		} else if lines[i].name != name {
+			return nil, 0, fmt.Errorf("Rail line %s is mentioned as rail line %d, but that is %s in the lines CSV", name, i+1, lines[i].name)
+		}
+	}
+
+	var matrix bytes.Buffer
+	writer := csv.NewWriter(&matrix)
+	if err := writer.Write(slices.Concat(header[:1], names, header[2:])); nil != err {
+		return nil, 0, err
+	}
+	for i, record := range records {
+		row := []string{record[0]}
+		for _, name := range names {
+			row = append(row, fmt.Sprint(slices.Contains(memberships[i], name)))
+		}
+		if err := writer.Write(append(row, record[2:]...)); nil != err {
+			return nil, 0, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); nil != err {
+		return nil, 0, err
+	}
+
+	matrixReader := csv.NewReader(&matrix)
+	matrixReader.TrimLeadingSpace = reader.TrimLeadingSpace
+	return matrixReader, duplicates, nil
+}
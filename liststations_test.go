@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Detecting the shape leaves the file as it is for the parser, so rows are
+// numbered by the lines of the file past blank lines, comments, and cells
+// spanning several lines, the same as with the shape given.
+func TestDetectShapeKeepsRows(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,Ruby,Emerald\n\n# Moved\nFoo,true,false\n\"Bar\nNorth\",true,true\nA Very Long Station's Name,true,true\n",
+	})
+	longest := len("INSERT INTO Stations VALUES (3, 'A Very Long Station''s Name');")
+	for _, format := range []string{"auto", "matrix"} {
+		_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-comment", "#", "-stations-format", format, "-max-statement-bytes", strconv.Itoa(longest-1))
+		if want := fmt.Sprintf("for row 7: Statement is %d bytes", longest); nil == err || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error containing %q with -stations-format %s, got %v", want, format, err)
+		}
+	}
+}
+
+func TestSniffShape(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+		sample [][]string
+		want   string // Shape, or empty for the ambiguous error
+	}{
+		{"one column", []string{"Station"}, [][]string{{"Foo"}}, "matrix"},
+		{"matrix", []string{"Station", "Ruby", "Emerald"}, [][]string{{"Foo", "true", "false"}}, "matrix"},
+		{"matrix without records", []string{"Station", "Ruby", "Emerald"}, nil, "matrix"},
+		{"list", []string{"Station", "Lines"}, [][]string{{"Foo", "Ruby"}, {"Bar", "Ruby; Emerald"}}, "list"},
+		{"list with more columns", []string{"Station", "Lines", "exits"}, [][]string{{"Foo", "Ruby;Emerald", "2"}}, "list"},
+		{"pairs", []string{"Station", "Line"}, [][]string{{"Foo", "Ruby"}, {"Foo", "Emerald"}}, "pairs"},
+		{"pairs after booleans", []string{"Station", "Line"}, [][]string{{"Foo", "true"}, {"Bar", "Emerald"}}, "pairs"},
+		{"booleans", []string{"Station", "Ruby"}, [][]string{{"Foo", "true"}, {"Bar", "F"}, {"Baz", ""}}, ""},
+		{"positions", []string{"Station", "Ruby"}, [][]string{{"Foo", "1"}, {"Bar", "2"}}, ""},
+		{"two columns without records", []string{"Station", "Ruby"}, nil, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shape, err := sniffShape(test.header, test.sample)
+			if "" == test.want {
+				if nil == err || !strings.Contains(err.Error(), "give -stations-format") {
+					t.Errorf("Expected an ambiguous shape, got %q and %v", shape, err)
+				}
+			} else if nil != err || test.want != shape {
+				t.Errorf("Expected %s, got %q and %v", test.want, shape, err)
+			}
+		})
+	}
+}
+
+// A rail line column of booleans alone is not taken for pairs, and the error
+// goes away once the format is given.
+func TestDetectShapeAmbiguous(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": "Line,Red,Green,Blue\nRuby,255,0,0\n", "stations.csv": "Station,Ruby\nFoo,true\nBar,false\nBaz,true\n"})
+	_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if want := "Cannot tell whether the stations CSV is a rail line column of booleans or pairs of stations and rail lines, give -stations-format"; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q, got %v", want, err)
+	}
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-stations-format", "matrix")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "INSERT INTO LineStations VALUES (1, 3);\n") || strings.Contains(stdout, "VALUES (1, 2);") {
+		t.Errorf("Expected Foo and Baz on Ruby:\n%s", stdout)
+	}
+}
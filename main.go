@@ -0,0 +1,19 @@
+//go:build !js
+
+package main
+
+import (
+	"errors"
+	"log"
+	"os"
+)
+
+// Reads rail line and station data from CSV files specified via command-line flags
+// and generates SQL INSERT statements. Output is written to Standard Out.
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); errors.Is(err, errUsage) {
+		os.Exit(2)
+	} else if nil != err {
+		log.Fatalln(err)
+	}
+}
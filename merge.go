@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// A rail network read from its own pair of lines and stations CSV files.
+type network struct {
+	label    string
+	lines    []railLine
+	stations []station
+}
+
+// Flag that can be given multiple times, collecting every value in order.
+type repeatedFlag []string
+
+func (values *repeatedFlag) String() string {
+	return strings.Join(*values, " ")
+}
+
+func (values *repeatedFlag) Set(value string) error {
+	*values = append(*values, value)
+	return nil
+}
+
+// Parse the network described by a -merge value of the form
+// "label=lines.csv,stations.csv".
+func (c *conversion) parseNetwork(spec string, options stationOptions) (network, error) {
+	label, paths, found := strings.Cut(spec, "=")
+	label = strings.TrimSpace(label)
+	if !found || 0 >= len(label) {
+		return network{}, fmt.Errorf("Missing label in %s", spec)
+	}
+
+	linesPath, stationsPath, found := strings.Cut(paths, ",")
+	if !found {
+		return network{}, fmt.Errorf("Expected lines and stations CSV files for %s", label)
+	}
+
+	lines, err := parseCsvFile(c, strings.TrimSpace(linesPath), c.parseLines)
+	if nil != err {
+		return network{}, fmt.Errorf("Failed to parse rail lines for %s: %w", label, err)
+	}
+	options.lines = lines
+	sniff, parse := c.stationParser(options)
+	stations, err := parseCsvFileSkipping(c, strings.TrimSpace(stationsPath), nil, sniff, parse)
+	if nil != err {
+		return network{}, fmt.Errorf("Failed to parse stations for %s: %w", label, err)
+	}
+	return network{label, lines, stations}, nil
+}
+
+// Combine the networks into one, with the IDs of each network following on from
+// the ones before it. When prefix is set every station name is prefixed with the
+// label of its network. When mergeLines is set rail lines sharing a name across
+// networks become a single unprefixed rail line that keeps the colors of its
+// first occurrence, otherwise they are kept apart and prefixed like stations.
+func mergeNetworks(networks []network, prefix bool, mergeLines bool) ([]railLine, []station, error) {
+	var lines []railLine
+	var stations []station
+	mergedIds := make(map[string]int)
+	for networkIndex, current := range networks {
+		newIds := make([]int, len(current.lines)+1)
+		for i, line := range current.lines {
+			if lineId, found := mergedIds[line.name]; mergeLines && found {
+				newIds[i+1] = lineId
+				continue
+			}
+
+			mergedIds[line.name] = len(lines) + 1
+			newIds[i+1] = len(lines) + 1
+			if prefix && !mergeLines {
+				line.name = current.label + ":" + line.name
+			}
+			line.network = networkIndex
+			lines = append(lines, line)
+		}
+
+		for _, s := range current.stations {
+			lineIds := make([]int, 0, len(s.lines))
+			positions := s.positions
+			s.positions = nil
+			for _, lineId := range s.lines {
+				if len(current.lines) < lineId {
+					return nil, nil, fmt.Errorf("Station %s in %s is on rail line %d which is not in its lines CSV", s.name, current.label, lineId)
+				}
+				lineIds = append(lineIds, newIds[lineId])
+				if position, found := positions[lineId]; found {
+					s.setPosition(newIds[lineId], position)
+				}
+			}
+			if prefix {
+				s.name = current.label + ":" + s.name
+			}
+			s.network = networkIndex
+			slices.Sort(lineIds)
+			s.lines = slices.Compact(lineIds)
+			stations = append(stations, s)
+		}
+	}
+	return lines, stations, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// Writer counting the writes and bytes passing through it.
+type countingWriter struct {
+	writer io.Writer
+	writes int
+	bytes  int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.writes++
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Timings and throughput of a conversion. Rows per second are over the parse
+// phase and statements per second over the emit phase.
+type metrics struct {
+	TotalSeconds        float64       `json:"total_seconds"`
+	RowsParsed          int           `json:"rows_parsed"`
+	RowsPerSecond       float64       `json:"rows_per_second"`
+	Statements          int           `json:"statements"`
+	StatementsPerSecond float64       `json:"statements_per_second"`
+	BytesWritten        int64         `json:"bytes_written"`
+	Phases              []phaseTiming `json:"phases,omitempty"` // Only with -metrics
+}
+
+// Time spent in one phase of a conversion.
+type phaseTiming struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Start times of the phases of a conversion, each phase ending where the next
+// starts.
+type phaseClock struct {
+	start, parse, prepare, emit, end time.Time
+}
+
+// Work out the metrics from the phase times and counts, with the breakdown by
+// phase when detailed is set.
+func newMetrics(clock phaseClock, rows int, statements int, bytes int64, detailed bool) *metrics {
+	perSecond := func(count int, from time.Time, to time.Time) float64 {
+		if seconds := to.Sub(from).Seconds(); 0 < seconds {
+			return float64(count) / seconds
+		}
+		return 0
+	}
+	m := &metrics{
+		TotalSeconds:        clock.end.Sub(clock.start).Seconds(),
+		RowsParsed:          rows,
+		RowsPerSecond:       perSecond(rows, clock.parse, clock.prepare),
+		Statements:          statements,
+		StatementsPerSecond: perSecond(statements, clock.emit, clock.end),
+		BytesWritten:        bytes,
+	}
+	if detailed {
+		m.Phases = []phaseTiming{
+			{"setup", clock.parse.Sub(clock.start).Seconds()},
+			{"parse", clock.prepare.Sub(clock.parse).Seconds()},
+			{"prepare", clock.emit.Sub(clock.prepare).Seconds()},
+			{"emit", clock.end.Sub(clock.emit).Seconds()},
+		}
+	}
+	return m
+}
+
+// Write the metrics in a human readable form.
+func (m *metrics) writeSummary(writer io.Writer) error {
+	if _, err := fmt.Fprintf(writer, "Time: %.3fs\nRows parsed: %d (%.0f/s)\nStatements: %d (%.0f/s)\nBytes written: %d\n",
+		m.TotalSeconds, m.RowsParsed, m.RowsPerSecond, m.Statements, m.StatementsPerSecond, m.BytesWritten); nil != err {
+		return err
+	}
+	if 0 < len(m.Phases) {
+		if _, err := io.WriteString(writer, "Phases:\n"); nil != err {
+			return err
+		}
+	}
+	for _, phase := range m.Phases {
+		share := 0.0
+		if 0 < m.TotalSeconds {
+			share = 100 * phase.Seconds / m.TotalSeconds
+		}
+		if _, err := fmt.Fprintf(writer, "  %-7s %.3fs %3.0f%%\n", phase.Name, phase.Seconds, share); nil != err {
+			return err
+		}
+	}
+	return nil
+}
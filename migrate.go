@@ -0,0 +1,340 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Changes between the network an old pair of CSV files was converted into and
+// the network of a new pair, with the IDs the old conversion assigned.
+type migration struct {
+	removedLinks    [][2]int // Line and station IDs of the links to delete
+	removedStations []int
+	removedLines    []int
+	addedLines      []railLine // With the IDs following on from the old ones
+	addedLineIds    []int
+	addedStations   []station // With the IDs following on from the old ones
+	addedLinks      [][2]int  // Line and station IDs of the links to insert
+
+	dependents    []dependentRows // Rows to delete before the removed stations and rail lines they reference
+	subscriptions bool            // Whether the database has the UserStations table, as setup.sql does
+}
+
+// Column of a table referencing a station or rail line, whose rows are deleted
+// along with it.
+type dependentRows struct {
+	table, column, references string
+}
+
+// Columns of the optional tables of the groups, other than the links, that
+// reference the stations and rail lines, in the order their rows are deleted:
+// the reverse of the schema, so that every row goes before the rows it
+// references. UserStations is left out, as the subscriptions of users are not
+// for a migration to delete but a conflict to report.
+func dependentTables(groups map[string]bool) []dependentRows {
+	var dependents []dependentRows
+	for _, table := range slices.Backward(schemaTables(128)) {
+		if "" == table.group || !groups[table.group] {
+			continue
+		}
+		for _, reference := range table.references {
+			if "Stations" == reference.table || "RailLines" == reference.table {
+				dependents = append(dependents, dependentRows{table.name, reference.column, reference.table})
+			}
+		}
+	}
+	return dependents
+}
+
+// Row deleted by a migration, by the IDs in its columns.
+type deletion struct {
+	table   string
+	columns []string
+	ids     []int
+}
+
+// Rows the changes delete, in the order they are deleted: the removed links,
+// then for each removed station and rail line the rows of the
+// [migration.dependents] referencing it before the row itself.
+func (changes migration) deletions() []deletion {
+	var deletions []deletion
+	for _, link := range changes.removedLinks {
+		deletions = append(deletions, deletion{"LineStations", []string{"line_id", "station_id"}, []int{link[0], link[1]}})
+	}
+	for _, removed := range []struct {
+		table string
+		ids   []int
+	}{{"Stations", changes.removedStations}, {"RailLines", changes.removedLines}} {
+		for _, id := range removed.ids {
+			for _, dependent := range changes.dependents {
+				if removed.table == dependent.references {
+					deletions = append(deletions, deletion{dependent.table, []string{dependent.column}, []int{id}})
+				}
+			}
+			deletions = append(deletions, deletion{removed.table, []string{"id"}, []int{id}})
+		}
+	}
+	return deletions
+}
+
+// Convert only the changes between two versions of the CSV files into
+// statements for a database already holding the old version, for the migrate
+// subcommand.
+func (c *conversion) runMigrate(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("csv2sql migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	linesPath := flags.String("lines", "lines.csv", "CSV file for the new rail lines")
+	fromLinesPath := flags.String("from-lines", "", "CSV file for the old rail lines, if different from -lines")
+	fromPath := flags.String("from", "", "CSV file for the old stations")
+	toPath := flags.String("to", "", "CSV file for the new stations")
+	dialectFlag := flags.String("dialect", "standard", "SQL dialect of the statements: 'standard', 'postgres', 'mysql', or 'sqlite'")
+	tablesFlag := flags.String("tables", "none", "Comma-separated optional tables of the database, as given to the schema subcommand, whose rows referencing removed stations and rail lines are deleted")
+	noTransaction := flags.Bool("no-transaction", false, "Emit the statements without BEGIN and COMMIT, for executors that wrap the script in a transaction")
+	beginFlag := flags.String("begin-keyword", "BEGIN", "Keyword starting the transaction, like 'START TRANSACTION'")
+	if err := flags.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if nil != err {
+		return errUsage
+	}
+	if "" == *fromPath || "" == *toPath {
+		return fmt.Errorf("Migrating requires both -from and -to")
+	}
+	if err := c.setTransactions(flags, *noTransaction, *beginFlag); nil != err {
+		return err
+	}
+	switch *dialectFlag {
+	case "standard", "postgres", "mysql", "sqlite":
+		c.dialect = *dialectFlag
+	default:
+		return fmt.Errorf("Invalid dialect: %s", *dialectFlag)
+	}
+	groups, err := parseTableGroups(*tablesFlag)
+	if nil != err {
+		return err
+	}
+	if "" == *fromLinesPath {
+		*fromLinesPath = *linesPath
+	}
+
+	oldLines, oldStations, err := c.parseVersion(*fromLinesPath, *fromPath)
+	if nil != err {
+		return fmt.Errorf("Failed to parse the old version: %w", err)
+	}
+	newLines, newStations, err := c.parseVersion(*linesPath, *toPath)
+	if nil != err {
+		return fmt.Errorf("Failed to parse the new version: %w", err)
+	}
+	changes := diffVersions(oldLines, oldStations, newLines, newStations)
+	changes.dependents, changes.subscriptions = dependentTables(groups), true
+
+	changes.summarize(stderr)
+	return c.emitMigration(changes, stdout)
+}
+
+// Write the statements for the changes in one transaction.
+func (c *conversion) emitMigration(changes migration, stdout io.Writer) error {
+	output := c.newEmitter(stdout, emitterOptions{})
+	if err := output.transaction(func(writer io.Writer) error { return changes.statements(output, writer) }); nil != err {
+		return fmt.Errorf("Failed to generate migration SQL statements: %w", err)
+	}
+	return output.flush()
+}
+
+// Parse one version of the lines and stations CSV files, numbering the stations
+// in input order as a conversion without options does.
+func (c *conversion) parseVersion(linesPath string, stationsPath string) ([]railLine, []station, error) {
+	lines, err := parseCsvFile(c, linesPath, c.parseLines)
+	if nil != err {
+		return nil, nil, fmt.Errorf("Failed to parse rail lines: %w", err)
+	}
+	options := stationOptions{zoneColumn: "zone", maxCapacity: 1_000_000, lines: lines, shape: "auto"}
+	sniff, parse := c.stationParser(options)
+	stations, err := parseCsvFileSkipping(c, stationsPath, nil, sniff, parse)
+	if nil != err {
+		return nil, nil, fmt.Errorf("Failed to parse stations: %w", err)
+	}
+	for i := range stations {
+		stations[i].id = i + 1
+		for _, lineId := range stations[i].lines {
+			if len(lines) < lineId {
+				return nil, nil, fmt.Errorf("Station %s is on rail line %d which is not in the lines CSV", stations[i].name, lineId)
+			}
+		}
+	}
+	return lines, stations, nil
+}
+
+// Rail lines and stations already loaded into a database, with their IDs.
+type loadedNetwork struct {
+	lineIds  map[string]int // IDs of the rail lines by name
+	stations []station      // With their IDs and the IDs of the rail lines they are linked to
+}
+
+// Work out the changes from the old version to the new one, matching rail lines
+// and stations by name.
+func diffVersions(oldLines []railLine, oldStations []station, newLines []railLine, newStations []station) migration {
+	old := loadedNetwork{lineIds: make(map[string]int, len(oldLines)), stations: oldStations}
+	for i, line := range oldLines {
+		old.lineIds[line.name] = i + 1
+	}
+	return diffNetwork(old, newLines, newStations, func(name string) string { return name })
+}
+
+// Work out the changes from the loaded network to the given rail lines and
+// stations, matching them by the key of their names. Rail lines and stations
+// that are not loaded get IDs following on from the largest loaded ones.
+func diffNetwork(old loadedNetwork, lines []railLine, stations []station, key func(string) string) migration {
+	var changes migration
+	lineIds := make(map[string]int, len(old.lineIds)) // IDs of the rail lines by the key of their names
+	nextLineId := 1
+	for name, lineId := range old.lineIds {
+		lineIds[key(name)] = lineId
+		nextLineId = max(nextLineId, lineId+1)
+	}
+	keptLines := make(map[int]bool, len(lines))
+	for _, line := range lines {
+		lineId, found := lineIds[key(line.name)]
+		if !found {
+			lineId = nextLineId
+			nextLineId++
+			lineIds[key(line.name)] = lineId
+			changes.addedLines = append(changes.addedLines, line)
+			changes.addedLineIds = append(changes.addedLineIds, lineId)
+		}
+		keptLines[lineId] = true
+	}
+	for _, lineId := range lineIds {
+		if !keptLines[lineId] {
+			changes.removedLines = append(changes.removedLines, lineId)
+		}
+	}
+	slices.Sort(changes.removedLines)
+	newLineIds := func(s station) []int {
+		ids := make([]int, len(s.lines))
+		for i, lineId := range s.lines {
+			ids[i] = lineIds[key(lines[lineId-1].name)]
+		}
+		slices.Sort(ids)
+		return ids
+	}
+
+	oldByKey := make(map[string]station, len(old.stations))
+	nextStationId := 1
+	for _, current := range old.stations {
+		oldByKey[key(current.name)] = current
+		nextStationId = max(nextStationId, current.id+1)
+	}
+	keptStations := make(map[int]bool, len(stations))
+	for _, current := range stations {
+		loaded, found := oldByKey[key(current.name)]
+		if !found {
+			current.id = nextStationId
+			nextStationId++
+			current.lines = newLineIds(current)
+			changes.addedStations = append(changes.addedStations, current)
+			for _, lineId := range current.lines {
+				changes.addedLinks = append(changes.addedLinks, [2]int{lineId, current.id})
+			}
+			continue
+		}
+
+		keptStations[loaded.id] = true
+		currentLines := newLineIds(current)
+		for _, lineId := range loaded.lines {
+			if !slices.Contains(currentLines, lineId) {
+				changes.removedLinks = append(changes.removedLinks, [2]int{lineId, loaded.id})
+			}
+		}
+		for _, lineId := range currentLines {
+			if !slices.Contains(loaded.lines, lineId) {
+				changes.addedLinks = append(changes.addedLinks, [2]int{lineId, loaded.id})
+			}
+		}
+	}
+
+	for _, loaded := range old.stations {
+		if !keptStations[loaded.id] {
+			changes.removedStations = append(changes.removedStations, loaded.id)
+			for _, lineId := range loaded.lines {
+				changes.removedLinks = append(changes.removedLinks, [2]int{lineId, loaded.id})
+			}
+		}
+	}
+	return changes
+}
+
+// Print the number of rail lines, stations, and links added and removed.
+func (changes migration) summarize(writer io.Writer) {
+	fmt.Fprintf(writer, "Rail lines: %d added, %d removed\n", len(changes.addedLines), len(changes.removedLines))
+	fmt.Fprintf(writer, "Stations: %d added, %d removed\n", len(changes.addedStations), len(changes.removedStations))
+	fmt.Fprintf(writer, "Links: %d added, %d removed\n", len(changes.addedLinks), len(changes.removedLinks))
+	if changes.subscriptions && 0 < len(changes.removedStations) {
+		fmt.Fprintln(writer, "Subscriptions in UserStations to the removed stations are a conflict that stops the migration")
+	}
+}
+
+// Message of the conflict when users are subscribed to a removed station.
+const subscriptionConflict = "Users are subscribed to stations being removed"
+
+// Query of the subscriptions in UserStations to the removed stations, or an
+// empty string without subscriptions or removed stations.
+func (changes migration) subscriptionQuery() string {
+	if !changes.subscriptions || 0 == len(changes.removedStations) {
+		return ""
+	}
+	ids := make([]string, len(changes.removedStations))
+	for i, stationId := range changes.removedStations {
+		ids[i] = strconv.Itoa(stationId)
+	}
+	return fmt.Sprintf("SELECT user_id, station_id FROM UserStations WHERE station_id IN (%s)", strings.Join(ids, ", "))
+}
+
+// Generate the statements for the changes with the emitter, making the
+// [migration.deletions] before inserting the rail lines, stations, and links.
+// With subscriptions the script is first stopped by [conversion.guardStatement]
+// when a removed station is subscribed to.
+func (changes migration) statements(e *emitter, writer io.Writer) error {
+	if query := changes.subscriptionQuery(); "" != query {
+		message := subscriptionConflict
+		statements := []string{"-- Stop if this finds a row: " + message, query + ";"}
+		if guard, found := e.guardStatement("EXISTS ("+query+")", message); found {
+			statements = []string{guard}
+		}
+		if err := writeStatements(writer, statements); nil != err {
+			return fmt.Errorf("Failed to write subscription check statement: %w", err)
+		}
+	}
+	for _, deletion := range changes.deletions() {
+		conditions := make([]string, len(deletion.columns))
+		for i, column := range deletion.columns {
+			conditions[i] = fmt.Sprintf("%s = %d", column, deletion.ids[i])
+		}
+		if _, err := fmt.Fprintf(writer, "DELETE FROM %s WHERE %s;\n", deletion.table, strings.Join(conditions, " AND ")); nil != err {
+			return fmt.Errorf("Failed to write %s delete statement: %w", deletion.table, err)
+		}
+	}
+
+	for i, line := range changes.addedLines {
+		if err := e.writeInsert(writer, "RailLines", []string{"id", "name", "red", "green", "blue"}, []string{strconv.Itoa(changes.addedLineIds[i]), e.quoteSqlString(line.name),
+			strconv.Itoa(int(line.red)), strconv.Itoa(int(line.green)), strconv.Itoa(int(line.blue))}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write line insert statement for row %d: %w", line.row, err)
+		}
+	}
+	for _, current := range changes.addedStations {
+		if err := e.stationInsert(writer, current.id, station{name: current.name}, nil); nil != err {
+			return fmt.Errorf("Failed to write station insert statement for row %d: %w", current.row, err)
+		}
+	}
+	for _, link := range changes.addedLinks {
+		if err := e.writeInsert(writer, "LineStations", []string{"line_id", "station_id"},
+			[]string{strconv.Itoa(link[0]), strconv.Itoa(link[1])}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write link statement: %w", err)
+		}
+	}
+	return nil
+}
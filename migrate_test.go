@@ -0,0 +1,108 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// The shape of the stations CSV files is detected like in a conversion. Before
+// it was, the table of booleans every other test migrates between was read as a
+// list, failing with "Rail line true is mentioned as rail line 1, but that is
+// Ruby in the lines CSV".
+func TestMigrateDetectsShape(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		old, new string
+	}{
+		{"matrix", "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n", "Station,Ruby,Emerald\nFoo,true,true\n"},
+		{"list", "Station,Lines\nFoo,Ruby\nBar,Ruby;Emerald\n", "Station,Lines\nFoo,Ruby; Emerald\n"},
+		{"pairs", "Station,Line\nFoo,Ruby\nBar,Ruby\nBar,Emerald\n", "Station,Line\nFoo,Ruby\nFoo,Emerald\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"old-lines.csv": testLines, "old.csv": test.old, "lines.csv": testLines, "new.csv": test.new})
+			stdout, _, err := runArgs(t, "migrate", "-from-lines", "old-lines.csv", "-lines", "lines.csv", "-from", "old.csv", "-to", "new.csv")
+			if nil != err {
+				t.Fatal(err)
+			}
+			if !strings.Contains(stdout, "DELETE FROM Stations WHERE id = 2;\n") || !strings.Contains(stdout, "INSERT INTO LineStations VALUES (2, 1);\n") {
+				t.Errorf("Unexpected migration:\n%s", stdout)
+			}
+		})
+	}
+}
+
+// Versions of the network before and after removing the station Bar and the
+// rail line Sapphire.
+var migrateFiles = map[string]string{
+	"old-lines.csv": "Line,Red,Green,Blue\nRuby,255,0,0\nEmerald,0,255,0\nSapphire,0,0,255\n",
+	"old.csv":       "Station,Ruby,Emerald,Sapphire\nFoo,true,false,true\nBar,true,true,false\nBaz,false,true,true\n",
+	"lines.csv":     testLines,
+	"new.csv":       "Station,Ruby,Emerald\nFoo,true,false\nBaz,false,true\nQux,true,true\n",
+	"devices.csv":   "Station,Type,Serial\nBar,smoke,S-1\nFoo,heat,S-2\n",
+}
+
+func TestMigrateGolden(t *testing.T) {
+	writeFiles(t, migrateFiles)
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"migrate", []string{"-tables", "all"}},
+		{"migrate-sqlite", []string{"-dialect", "sqlite", "-tables", "all"}},
+		{"migrate-no-tables", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, stderr, err := runArgs(t, append([]string{"migrate", "-from-lines", "old-lines.csv", "-lines", "lines.csv", "-from", "old.csv", "-to", "new.csv"}, test.args...)...)
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, test.name+".sql", stdout)
+			if !strings.Contains(stderr, "UserStations") {
+				t.Errorf("Unexpected summary:\n%s", stderr)
+			}
+		})
+	}
+}
+
+// The migration deletes the rows referencing the removed station and rail line
+// in a database enforcing its foreign keys, and stops when a user is subscribed
+// to the station.
+func TestMigrateDependents(t *testing.T) {
+	writeFiles(t, migrateFiles)
+	if _, _, err := runArgs(t, "-lines", "old-lines.csv", "-stations", "old.csv", "-devices", "devices.csv", "-sqlite-out", "network.db"); nil != err {
+		t.Fatal(err)
+	}
+	script, _, err := runArgs(t, "migrate", "-dialect", "sqlite", "-from-lines", "old-lines.csv", "-lines", "lines.csv", "-from", "old.csv", "-to", "new.csv", "-tables", "all")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", "network.db?_pragma=foreign_keys(1)")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO Connections VALUES (3, 1, 3, 90), (1, 1, 2, 60); INSERT INTO Users VALUES (1, 'rider@example.com'); INSERT INTO UserStations VALUES (1, 2);"); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(script); nil == err || !strings.Contains(err.Error(), "Users are subscribed to stations being removed") {
+		t.Fatalf("Expected the subscription to stop the migration, got %v", err)
+	}
+	if _, err := db.Exec("ROLLBACK; DELETE FROM UserStations;"); nil != err {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(script); nil != err {
+		t.Fatalf("Migration failed: %v", err)
+	}
+	for table, want := range map[string]int{"Devices": 1, "Connections": 0, "Stations": 3, "RailLines": 2} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); nil != err {
+			t.Fatal(err)
+		}
+		if want != count {
+			t.Errorf("Expected %d rows in %s, got %d", want, table, count)
+		}
+	}
+}
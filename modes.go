@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Rail line column header of the stations CSV naming the transit mode of the
+// rail line in square brackets after its name, like "Red[rail]" or "70[bus]".
+var modePattern = regexp.MustCompile(`^(.*?)\s*\[\s*([^\[\]]*?)\s*\]$`)
+
+// Split the header of a rail line column into the name of the rail line and its
+// transit mode in lower case, which is "" when the header gives none.
+func splitMode(entry string) (string, string) {
+	match := modePattern.FindStringSubmatch(entry)
+	if nil == match || "" == match[1] || "" == match[2] {
+		return entry, ""
+	}
+	return match[1], strings.ToLower(match[2])
+}
+
+// Give every rail line without a transit mode from the header of the stations
+// CSV the mode of -mode, if any.
+func assignDefaultMode(lines []railLine, mode string) {
+	if mode = strings.ToLower(strings.TrimSpace(mode)); "" == mode {
+		return
+	}
+	for i := range lines {
+		if "" == lines[i].mode {
+			lines[i].mode = mode
+		}
+	}
+}
+
+// Indices of the kept rail lines whose transit mode is one of the modes. Naming
+// a mode no rail line has is an error to catch typos.
+func filterModes(lines []railLine, kept []int, modes []string) ([]int, error) {
+	for _, mode := range modes {
+		if !slices.ContainsFunc(lines, func(line railLine) bool { return mode == line.mode }) {
+			return nil, fmt.Errorf("No rail line has the mode %s", mode)
+		}
+	}
+	return slices.DeleteFunc(kept, func(index int) bool { return !slices.Contains(modes, lines[index].mode) }), nil
+}
+
+// List the distinct transit modes of the rail lines in the order they are first
+// seen. The ID of each mode is its position in the list plus one.
+func collectModes(lines []railLine) []string {
+	var modes []string
+	for _, line := range lines {
+		if "" != line.mode && !slices.Contains(modes, line.mode) {
+			modes = append(modes, line.mode)
+		}
+	}
+	return modes
+}
+
+// Column of the 'RailLines' table with the Modes row of the transit mode of the
+// rail line.
+var modeIdColumn = columnDefinition{"mode_id", "INTEGER", ""}
+
+// Fill the mode_id column of every rail line with the ID of its transit mode.
+func assignModeFields(lines []railLine, modes []string) {
+	if 0 == len(modes) {
+		return
+	}
+	for i := range lines {
+		literal := "NULL"
+		if modeId := slices.Index(modes, lines[i].mode); 0 <= modeId {
+			literal = strconv.Itoa(modeId + 1)
+		}
+		lines[i].fields = append(lines[i].fields, field{modeIdColumn.name, literal})
+	}
+}
+
+// Generate the SQL statements for populating the 'Modes' table.
+func (e *emitter) modeStatements(modes []string, writer io.Writer) error {
+	for i, mode := range modes {
+		if err := e.writeInsert(writer, "Modes", []string{"id", "name"}, []string{strconv.Itoa(i + 1), e.quoteSqlString(mode)}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write mode insert statement: %w", err)
+		}
+	}
+	return nil
+}
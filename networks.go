@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Column of the 'RailLines' and 'Stations' tables, and with -network-links the
+// 'LineStations' table, with the Networks row of the network of the row.
+var networkIdColumn = columnDefinition{"network_id", "INTEGER", "Network the row is from, with -network or -merge"}
+
+// Give every rail line and station a network_id column for the Networks row of
+// the network it is from, where the first network has the ID firstId.
+func assignNetworkFields(lines []railLine, stations []station, firstId int) {
+	for i := range lines {
+		lines[i].fields = append(lines[i].fields, field{networkIdColumn.name, strconv.Itoa(firstId + lines[i].network)})
+	}
+	for i := range stations {
+		stations[i].fields = append(stations[i].fields, field{networkIdColumn.name, strconv.Itoa(firstId + stations[i].network)})
+	}
+}
+
+// Generate the SQL statements for populating the 'Networks' table, numbering
+// the networks from firstId.
+func (e *emitter) networkStatements(names []string, firstId int, writer io.Writer) error {
+	for i, name := range names {
+		if err := e.writeInsert(writer, "Networks", []string{"id", "name"}, []string{strconv.Itoa(firstId + i), e.quoteSqlString(name)}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write network insert statement: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Setup with the tables widened by columns the CSV files have no values for.
+const widenedSetup = `CREATE TABLE RailLines (id INTEGER PRIMARY KEY, name TEXT NOT NULL, opened DATE, red INTEGER, green INTEGER, blue INTEGER);
+CREATE TABLE Stations (id INTEGER PRIMARY KEY, name TEXT NOT NULL, opened DATE, status TEXT DEFAULT 'open');
+CREATE TABLE LineStations (line_id INTEGER NOT NULL REFERENCES RailLines (id), station_id INTEGER NOT NULL REFERENCES Stations (id), since DATE);
+`
+
+func TestPadNullsGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n", "widened.sql": widenedSetup})
+	for _, padding := range []string{"", "columns", "positional"} {
+		t.Run(padding, func(t *testing.T) {
+			args := []string{"-lines", "lines.csv", "-stations", "stations.csv", "-schema-file", "widened.sql"}
+			name := "schema"
+			if "" != padding {
+				args, name = append(args, "-pad-nulls", padding), padding
+			}
+			stdout, _, err := runArgs(t, args...)
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, "pad-nulls/"+name+".sql", stdout)
+		})
+	}
+}
+
+// The padded inserts load into the widened tables, except for a DEFAULT that
+// SQLite does not take in VALUES.
+func TestPadNullsSelfTest(t *testing.T) {
+	withoutDefault := strings.Replace(widenedSetup, " DEFAULT 'open'", "", 1)
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n",
+		"widened.sql": widenedSetup, "nodefault.sql": withoutDefault})
+	for _, padding := range []string{"columns", "positional"} {
+		if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-schema-file", "nodefault.sql", "-pad-nulls", padding, "-self-test"); nil != err {
+			t.Errorf("Self-test with -pad-nulls %s failed: %v", padding, err)
+		}
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-schema-file", "widened.sql", "-pad-nulls", "positional", "-self-test"); nil == err {
+		t.Error("Expected DEFAULT in the VALUES of the self-test to be an error")
+	}
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-pad-nulls", "columns"); nil == err || !strings.Contains(err.Error(), "Padding NULLs requires -schema-file") {
+		t.Errorf("Expected padding without a schema to be an error, got %v", err)
+	}
+}
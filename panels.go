@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// Header name of the stations CSV column with the asset tags of the fire alarm
+// panels of each station, separated by semicolons.
+const panelsColumn = "panels"
+
+// Whether the station is in service, having no status or the status open, so
+// that it must have an alarm panel.
+func (c *conversion) isActive(current station) bool {
+	return !slices.ContainsFunc(current.fields, func(f field) bool {
+		return "status" == f.column && "NULL" != f.literal && c.quoteSqlString("open") != f.literal
+	})
+}
+
+// Check that no panel tag is given to two stations, keeping the first station
+// to have it when -force duplicates forgives it, and list the findings for the
+// active stations without a panel when the stations CSV has a panels column.
+func (c *conversion) checkPanels(stations []station) ([]string, error) {
+	owners := make(map[string]*station)
+	var findings []string
+	for i := range stations {
+		current := &stations[i]
+		if nil == current.panels {
+			continue
+		}
+		if 0 == len(current.panels) && c.isActive(*current) {
+			findings = append(findings, fmt.Sprintf("Station %s in row %d has no alarm panels", current.name, current.row))
+		}
+		var err error
+		current.panels = slices.DeleteFunc(current.panels, func(tag string) bool {
+			owner, found := owners[tag]
+			if !found {
+				owners[tag] = current
+				return false
+			}
+			if nil == err {
+				err = c.forgive("duplicates", fmt.Errorf("Panel tag %s is given to both station %s in row %d and station %s in row %d",
+					tag, owner.name, owner.row, current.name, current.row))
+			}
+			return true
+		})
+		if nil != err {
+			return nil, err
+		}
+	}
+	return findings, nil
+}
+
+// Generate the SQL statements for populating the 'Panels' table, numbering the
+// panels of all of the stations in order from 1.
+func (e *emitter) panelStatements(stations []station, writer io.Writer) error {
+	panelId := 0
+	for _, current := range stations {
+		for _, tag := range current.panels {
+			panelId++
+			if err := e.writeInsert(writer, "Panels", []string{"id", "station_id", "tag"},
+				[]string{strconv.Itoa(panelId), strconv.Itoa(current.id), e.quoteSqlString(tag)}, nil, nil); nil != err {
+				return fmt.Errorf("Failed to write panel insert statement for row %d: %w", current.row, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// A panel tag given to two stations is an error naming both, unless -force
+// duplicates keeps it on the first station only.
+func TestPanelsDuplicateTag(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,Ruby,Emerald,panels\nFoo,true,false,FA-1; FA-2\nBar's,true,true,FA-3;FA-2\n",
+	})
+	_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if want := "Panel tag FA-2 is given to both station Foo in row 2 and station Bar's in row 3"; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q, got %v", want, err)
+	}
+
+	stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-force", "duplicates")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"INSERT INTO Panels VALUES (1, 1, 'FA-1');\n", "INSERT INTO Panels VALUES (2, 1, 'FA-2');\n", "INSERT INTO Panels VALUES (3, 2, 'FA-3');\n"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("Expected %q in:\n%s", want, stdout)
+		}
+	}
+	if strings.Contains(stdout, "(4, ") {
+		t.Errorf("Expected the duplicate tag to be dropped from Bar's:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "Panel tag FA-2 is given to both station Foo in row 2 and station Bar's in row 3") {
+		t.Errorf("Expected the forgiven duplicate to be warned about:\n%s", stderr)
+	}
+}
+
+// An active station with a blank panels cell raises a warning, which -strict
+// makes an error, while a closed one does not.
+func TestPanelsBlankCell(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,Ruby,Emerald,panels,status\nFoo,true,false,FA-1,open\nBar's,true,true, ,\nBaz,true,false,,closed\n",
+	})
+	stdout, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := "Station Bar's in row 3 has no alarm panels"; !strings.Contains(stderr, want) {
+		t.Errorf("Expected %q to be warned about:\n%s", want, stderr)
+	}
+	if strings.Contains(stderr, "Station Baz") {
+		t.Errorf("Expected no warning for the closed station:\n%s", stderr)
+	}
+	if 1 != strings.Count(stdout, "INSERT INTO Panels") {
+		t.Errorf("Expected only the panel of Foo:\n%s", stdout)
+	}
+
+	_, _, err = runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-strict")
+	if want := "Station Bar's in row 3 has no alarm panels"; nil == err || !strings.Contains(err.Error(), want) {
+		t.Errorf("Expected an error containing %q with -strict, got %v", want, err)
+	}
+}
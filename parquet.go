@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Number of rows read from a Parquet file at a time.
+const parquetBatchRows = 256
+
+// Reader of the CSV text of the rows of a Parquet stations file, which is read
+// one batch of rows of one row group at a time as the text is needed.
+type parquetReader struct {
+	groups  []parquet.RowGroup
+	rows    parquet.Rows // Rows of the current row group, if any
+	batch   []parquet.Row
+	text    bytes.Buffer
+	writer  *csv.Writer
+	columns int
+}
+
+// Check that the schema of the Parquet file is the wide schema of a string
+// station name column and a boolean (or with -bool-style sequence, integer)
+// column for every rail line, or the long schema of two string columns giving a
+// station and one of its rail lines, and return the names of its columns.
+func parquetHeader(schema *parquet.Schema) ([]string, error) {
+	fields := schema.Fields()
+	if 2 > len(fields) {
+		return nil, fmt.Errorf("Expected a station column and at least one other column in the Parquet schema, found %d", len(fields))
+	}
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.Name()
+		if !field.Leaf() || field.Repeated() {
+			return nil, fmt.Errorf("Parquet column %s is not a plain column", field.Name())
+		}
+		kind := field.Type().Kind()
+		switch {
+		case 0 == i || (2 == len(fields) && parquet.ByteArray == kind):
+			if parquet.ByteArray != kind {
+				return nil, fmt.Errorf("Parquet column %s is %s, expected BYTE_ARRAY strings", field.Name(), kind)
+			}
+		case parquet.Boolean != kind && parquet.Int32 != kind && parquet.Int64 != kind:
+			return nil, fmt.Errorf("Parquet column %s is %s, expected BOOLEAN, or INT32 or INT64 positions", field.Name(), kind)
+		}
+	}
+	return header, nil
+}
+
+// Open the Parquet file for reading its rows as CSV text, starting with a header
+// of the column names.
+func newParquetReader(file *os.File) (*parquetReader, error) {
+	info, err := file.Stat()
+	if nil != err {
+		return nil, err
+	}
+	parsed, err := parquet.OpenFile(file, info.Size())
+	if nil != err {
+		return nil, fmt.Errorf("Failed to read Parquet file: %w", err)
+	}
+	header, err := parquetHeader(parsed.Schema())
+	if nil != err {
+		return nil, err
+	}
+	reader := &parquetReader{groups: parsed.RowGroups(), batch: make([]parquet.Row, parquetBatchRows), columns: len(header)}
+	reader.writer = csv.NewWriter(&reader.text)
+	if err := reader.writer.Write(header); nil != err {
+		return nil, err
+	}
+	reader.writer.Flush()
+	return reader, nil
+}
+
+func (r *parquetReader) Read(p []byte) (int, error) {
+	for 0 == r.text.Len() {
+		if err := r.next(); nil != err {
+			return 0, err
+		}
+	}
+	return r.text.Read(p)
+}
+
+// Write the next batch of rows to the text, moving on to the next row group
+// once the current one runs out.
+func (r *parquetReader) next() error {
+	if nil == r.rows {
+		if 0 == len(r.groups) {
+			return io.EOF
+		}
+		r.rows, r.groups = r.groups[0].Rows(), r.groups[1:]
+	}
+	count, err := r.rows.ReadRows(r.batch)
+	for _, row := range r.batch[:count] {
+		record := make([]string, r.columns)
+		for _, value := range row {
+			if column := value.Column(); 0 <= column && column < r.columns && !value.IsNull() {
+				switch value.Kind() {
+				case parquet.Boolean:
+					record[column] = strconv.FormatBool(value.Boolean())
+				case parquet.ByteArray:
+					record[column] = string(value.ByteArray())
+				default:
+					record[column] = value.String()
+				}
+			}
+		}
+		if err := r.writer.Write(record); nil != err {
+			return err
+		}
+	}
+	r.writer.Flush()
+	if errors.Is(err, io.EOF) {
+		err = r.rows.Close()
+		r.rows = nil
+	}
+	if nil != err {
+		return fmt.Errorf("Failed to read Parquet rows: %w", err)
+	}
+	return nil
+}
+
+// Manages file operations for parsing a Parquet stations file as CSV records,
+// first handing the start of their text to sniff when it is not nil. Parquet is
+// read from its footer, so the file must be a local one.
+func parseParquetFile[T any](path string, sniff func(*bufio.Reader, rune) error, parse func(*csv.Reader) (T, error), logger *log.Logger) (T, error) {
+	var zero T
+	if "-" == path || isUrl(path) || isBuiltin(path) {
+		return zero, fmt.Errorf("Parquet must be read from a local file, not %s", path)
+	}
+	file, err := os.Open(path)
+	if nil != err {
+		return zero, fmt.Errorf("Failed to open %s: %w", path, err)
+	}
+	defer func(file *os.File, path string) {
+		if err := file.Close(); nil != err {
+			logger.Printf("Failed to close %s: %v\n", path, err)
+		}
+	}(file, path)
+	input, err := newParquetReader(file)
+	if nil != err {
+		return zero, err
+	}
+	buffered := bufio.NewReaderSize(input, sniffBytes)
+	if nil != sniff {
+		if err := sniff(buffered, ','); nil != err {
+			return zero, err
+		}
+	}
+	return parse(csv.NewReader(buffered))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// Spatial reference system of the coordinates, WGS 84.
+const coordinateSrid = 4326
+
+// Converts a decimal latitude in degrees to a SQL literal.
+func (c *conversion) parseLatitude(s string, _ stationOptions) (string, error) {
+	return parseCoordinate(s, 90)
+}
+
+// Converts a decimal longitude in degrees to a SQL literal.
+func (c *conversion) parseLongitude(s string, _ stationOptions) (string, error) {
+	return parseCoordinate(s, 180)
+}
+
+// Converts a decimal number of degrees to a SQL literal, rejecting any beyond
+// the limit either way.
+func parseCoordinate(s string, limit float64) (string, error) {
+	degrees, err := strconv.ParseFloat(s, 64)
+	if nil != err {
+		return "", err
+	}
+	if math.IsNaN(degrees) || limit < math.Abs(degrees) {
+		return "", fmt.Errorf("Must be between -%g and %g", limit, limit)
+	}
+	return strconv.FormatFloat(degrees, 'f', -1, 64), nil
+}
+
+// Replace the latitude and longitude fields of every station by a PostGIS point
+// in the geometry column. PostGIS points take the longitude first, as x, and the
+// latitude second, as y. A station with both blank gets a NULL geometry, while
+// one with only one of them is an error. Stations are left alone when the
+// stations CSV has neither column.
+func pointFields(stations []station, column string) error {
+	for i := range stations {
+		current := &stations[i]
+		var latitude, longitude string
+		current.fields = slices.DeleteFunc(current.fields, func(f field) bool {
+			switch f.column {
+			case "latitude":
+				latitude = f.literal
+			case "longitude":
+				longitude = f.literal
+			default:
+				return false
+			}
+			return true
+		})
+		if "" == latitude && "" == longitude {
+			continue
+		} else if "" == latitude || "" == longitude || ("NULL" == latitude) != ("NULL" == longitude) {
+			return fmt.Errorf("Station %s has only one of a latitude and a longitude", current.name)
+		}
+		point := "NULL"
+		if "NULL" != latitude {
+			point = fmt.Sprintf("ST_SetSRID(ST_MakePoint(%s, %s), %d)", longitude, latitude, coordinateSrid)
+		}
+		current.fields = append(current.fields, field{column, point})
+	}
+	return nil
+}
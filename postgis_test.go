@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostgisGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines,
+		"stations.csv": "Station,Ruby,Emerald,latitude,longitude\nFoo,true,false,38.8977,-77.0365\nBar,true,true,,\n"})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-dialect", "postgres", "-postgis")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "postgis.sql", stdout)
+}
+
+// The point takes the longitude first, whatever order the columns are in.
+func TestPointOrder(t *testing.T) {
+	for _, fields := range [][]field{
+		{{"latitude", "38.8977"}, {"longitude", "-77.0365"}},
+		{{"longitude", "-77.0365"}, {"latitude", "38.8977"}},
+	} {
+		stations := []station{{name: "Foo", fields: fields}}
+		if err := pointFields(stations, "location"); nil != err {
+			t.Fatal(err)
+		}
+		want := []field{{"location", "ST_SetSRID(ST_MakePoint(-77.0365, 38.8977), 4326)"}}
+		if len(want) != len(stations[0].fields) || want[0] != stations[0].fields[0] {
+			t.Errorf("Fields %v became %v, want %v", fields, stations[0].fields, want)
+		}
+	}
+
+	stations := []station{{name: "Foo", fields: []field{{"latitude", "38.8977"}, {"longitude", "NULL"}}}}
+	if err := pointFields(stations, "location"); nil == err || !strings.Contains(err.Error(), "only one of a latitude and a longitude") {
+		t.Errorf("Expected a station with only a latitude to be an error, got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Widest a rail line column or the station column of the preview may be.
+const (
+	previewLineWidth    = 8
+	previewStationWidth = 32
+)
+
+// Write a table of the stations against the rail lines they are on, with a row
+// of the totals, for eyeballing the parsed network. Only the first maxLines rail
+// lines get a column, with a note about the rest.
+func writePreview(writer io.Writer, lines []railLine, stations []station, maxLines int) error {
+	shown := lines
+	if 0 < maxLines && maxLines < len(lines) {
+		shown = lines[:maxLines]
+	}
+
+	stationWidth := utf8.RuneCountInString("Total")
+	for _, current := range stations {
+		stationWidth = max(stationWidth, utf8.RuneCountInString(current.name))
+	}
+	stationWidth = min(stationWidth, previewStationWidth)
+
+	headers := make([]string, len(shown))
+	widths := make([]int, len(shown))
+	for i, line := range shown {
+		headers[i] = truncate(line.name, previewLineWidth)
+		widths[i] = max(utf8.RuneCountInString(headers[i]), 3)
+	}
+
+	var preview strings.Builder
+	row := func(name string, cells []string) {
+		line := pad(name, stationWidth)
+		for i, cell := range cells {
+			line += " | " + pad(cell, widths[i])
+		}
+		preview.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+
+	row("Station", headers)
+	separator := strings.Repeat("-", stationWidth)
+	for _, width := range widths {
+		separator += "-+-" + strings.Repeat("-", width)
+	}
+	preview.WriteString(separator + "\n")
+
+	totals := make([]int, len(shown))
+	links := 0
+	for _, current := range stations {
+		cells := make([]string, len(shown))
+		for i := range shown {
+			if slices.Contains(current.lines, i+1) {
+				cells[i] = "✓"
+				totals[i]++
+			}
+		}
+		links += len(current.lines)
+		row(truncate(current.name, stationWidth), cells)
+	}
+
+	preview.WriteString(separator + "\n")
+	cells := make([]string, len(shown))
+	for i, total := range totals {
+		cells[i] = fmt.Sprint(total)
+	}
+	row("Total", cells)
+
+	if len(shown) < len(lines) {
+		fmt.Fprintf(&preview, "(%d more rail lines not shown)\n", len(lines)-len(shown))
+	}
+	fmt.Fprintf(&preview, "%d rail lines, %d stations, %d links\n", len(lines), len(stations), links)
+
+	_, err := io.WriteString(writer, preview.String())
+	return err
+}
+
+// Shorten s to at most width characters, marking that it was cut with an ellipsis.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// Pad s with spaces to width characters.
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(s)))
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// Profiles being recorded around a conversion for -cpuprofile, -memprofile,
+// and -trace.
+type profiler struct {
+	cpu     *os.File // File of the CPU profile being recorded, if any
+	trace   *os.File // File of the execution trace being recorded, if any
+	memPath string   // Path to write the heap profile to when stopping, if any
+}
+
+// Start recording the CPU profile and the execution trace to the paths that are
+// not empty. Whatever was started is stopped again when another fails to start.
+func startProfiling(cpuPath string, memPath string, tracePath string) (*profiler, error) {
+	p := &profiler{memPath: memPath}
+	if "" != cpuPath {
+		file, err := os.Create(cpuPath)
+		if nil != err {
+			return nil, fmt.Errorf("Failed to create CPU profile: %w", err)
+		}
+		if err := pprof.StartCPUProfile(file); nil != err {
+			file.Close()
+			return nil, fmt.Errorf("Failed to start CPU profile: %w", err)
+		}
+		p.cpu = file
+	}
+	if "" != tracePath {
+		file, err := os.Create(tracePath)
+		if nil == err {
+			if err = trace.Start(file); nil != err {
+				file.Close()
+			}
+		}
+		if nil != err {
+			p.memPath = ""
+			return nil, errors.Join(fmt.Errorf("Failed to start trace: %w", err), p.stop())
+		}
+		p.trace = file
+	}
+	return p, nil
+}
+
+// Stop recording and write out the profiles, including the heap profile after
+// a garbage collection so that it shows what is still live.
+func (p *profiler) stop() error {
+	var errs []error
+	if nil != p.cpu {
+		pprof.StopCPUProfile()
+		if err := p.cpu.Close(); nil != err {
+			errs = append(errs, fmt.Errorf("Failed to write CPU profile: %w", err))
+		}
+	}
+	if nil != p.trace {
+		trace.Stop()
+		if err := p.trace.Close(); nil != err {
+			errs = append(errs, fmt.Errorf("Failed to write trace: %w", err))
+		}
+	}
+	if "" != p.memPath {
+		if err := writeHeapProfile(p.memPath); nil != err {
+			errs = append(errs, fmt.Errorf("Failed to write memory profile: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Write the heap profile to the file at the path.
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if nil != err {
+		return err
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(file); nil != err {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
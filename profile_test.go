@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// Every profile is written out and not empty, also when the conversion fails.
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		stations string
+		fails    bool
+	}{
+		{"success", "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n", false},
+		{"failure", "Station,Ruby,Emerald\nFoo,maybe,false\n", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": test.stations})
+			_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-cpuprofile", "cpu.pprof", "-memprofile", "mem.pprof", "-trace", "trace.out")
+			if test.fails != (nil != err) {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, path := range []string{"cpu.pprof", "mem.pprof", "trace.out"} {
+				if info, err := os.Stat(path); nil != err {
+					t.Errorf("Missing %s: %v", path, err)
+				} else if 0 == info.Size() {
+					t.Errorf("%s is empty", path)
+				}
+			}
+		})
+	}
+}
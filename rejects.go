@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"os"
+)
+
+// File the stations CSV records dropped by the filters are written to for
+// -rejects, each after a comment line giving its row and why it was dropped.
+// The file is only created once a record is dropped, starting with the header
+// of the stations CSV, so that it can be repaired and converted again.
+type rejectsFile struct {
+	path   string
+	header []string // Header of the stations CSV, set by [conversion.parseStations]
+	comma  rune
+	file   *os.File
+	writer *csv.Writer
+	count  int
+	err    error // First error writing the file
+	logger *log.Logger
+}
+
+// Write the record of the dropped station, creating the file first if needed.
+// Errors are kept for [rejectsFile.finish].
+func (r *rejectsFile) reject(current station, reason string) {
+	if nil != r.err {
+		return
+	}
+	if nil == r.file {
+		if r.file, r.err = os.Create(r.path); nil != r.err {
+			return
+		}
+		r.writer = csv.NewWriter(r.file)
+		r.writer.Comma = r.comma
+		if r.err = r.writer.Write(r.header); nil != r.err {
+			return
+		}
+	}
+	r.writer.Flush()
+	if _, r.err = fmt.Fprintf(r.file, "# Row %d: %s\n", current.row, reason); nil != r.err {
+		return
+	}
+	r.err = r.writer.Write(current.record)
+	r.count++
+}
+
+// Close the file, if any record was dropped, and log how many were.
+func (r *rejectsFile) finish() error {
+	if nil == r.file && nil == r.err {
+		return nil
+	}
+	if nil != r.file {
+		if nil == r.err {
+			r.writer.Flush()
+			r.err = r.writer.Error()
+		}
+		if err := r.file.Close(); nil != err && nil == r.err {
+			r.err = err
+		}
+	}
+	if nil != r.err {
+		return fmt.Errorf("Failed to write rejects file %s: %w", r.path, r.err)
+	}
+	r.logger.Printf("Wrote %d rejected station records to %s\n", r.count, r.path)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// New name for a rail line or station, read from the rename map CSV.
+type rename struct {
+	kind     string // What is renamed: "line" or "station"
+	from, to string
+	row      int // Line of the rename map CSV the rename was read from
+}
+
+// Parse the rename map CSV, with the kind of name ("line" or "station"), the
+// old name, and the new name on each row.
+func (c *conversion) parseRenames(reader *csv.Reader) ([]rename, error) {
+	reader.FieldsPerRecord = 3
+	if _, err := reader.Read(); nil != err {
+		return nil, fmt.Errorf("Failed to read CSV header: %w", err)
+	}
+
+	var renames []rename
+	for record, err := reader.Read(); io.EOF != err; record, err = reader.Read() {
+		if nil != err {
+			return nil, fmt.Errorf("Failed to read record for rename %d: %w", len(renames)+1, err)
+		}
+		if err := c.nul.apply(reader, record); nil != err {
+			return nil, err
+		}
+
+		row, _ := reader.FieldPos(0)
+		current := rename{
+			kind: strings.ToLower(strings.TrimSpace(record[0])),
+			from: strings.TrimSpace(record[1]),
+			to:   strings.TrimSpace(record[2]),
+			row:  row,
+		}
+		if "line" != current.kind && "station" != current.kind {
+			return nil, fmt.Errorf("Invalid kind %q in row %d, expected line or station", record[0], row)
+		}
+		if 0 >= len(current.to) {
+			return nil, fmt.Errorf("Missing new name in row %d", row)
+		}
+		renames = append(renames, current)
+	}
+	return renames, nil
+}
+
+// Rename the rail lines and stations, returning a finding for every rename that
+// matched no name as the map is likely stale. A rename giving a rail line or
+// station the name of another one is an error.
+func applyRenames(lines []railLine, stations []station, renames []rename) ([]string, error) {
+	used := make([]bool, len(renames))
+	apply := func(kind string, name *string) bool {
+		for i, current := range renames {
+			if kind == current.kind && *name == current.from {
+				*name = current.to
+				used[i] = true
+				return true
+			}
+		}
+		return false
+	}
+
+	lineRows := make(map[string][]int, len(lines))
+	var renamedLines []string
+	for i := range lines {
+		if apply("line", &lines[i].name) {
+			renamedLines = append(renamedLines, lines[i].name)
+		}
+	}
+	for _, line := range lines {
+		lineRows[line.name] = append(lineRows[line.name], line.row)
+	}
+	for _, name := range renamedLines {
+		if rows := lineRows[name]; 1 < len(rows) {
+			return nil, fmt.Errorf("Renaming gives the rail lines in rows %d and %d the same name %s", rows[0], rows[1], name)
+		}
+	}
+
+	stationRows := make(map[string][]int, len(stations))
+	var renamedStations []string
+	for i := range stations {
+		if apply("station", &stations[i].name) {
+			renamedStations = append(renamedStations, stations[i].name)
+		}
+	}
+	for _, current := range stations {
+		stationRows[current.name] = append(stationRows[current.name], current.row)
+	}
+	for _, name := range renamedStations {
+		if rows := stationRows[name]; 1 < len(rows) {
+			return nil, fmt.Errorf("Renaming gives the stations in rows %d and %d the same name %s", rows[0], rows[1], name)
+		}
+	}
+
+	var findings []string
+	for i, current := range renames {
+		if !used[i] {
+			findings = append(findings, fmt.Sprintf("Rename in row %d of %s %s matched nothing", current.row, current.kind, current.from))
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// Statistics about the generated network, rendered either as a human readable
+// summary or as a JSON report.
+type report struct {
+	Lines            int            `json:"lines"`
+	Stations         int            `json:"stations"`
+	Links            int            `json:"links"`
+	DuplicateLinks   int            `json:"duplicate_links,omitempty"`
+	TransferStations int            `json:"transfer_stations"`
+	Panels           int            `json:"panels,omitempty"`
+	LargestLine      string         `json:"largest_line"`
+	StationsPerLine  []lineCount    `json:"stations_per_line"`
+	Zones            []zoneCount    `json:"zones,omitempty"`
+	Complexes        []zoneCount    `json:"complexes,omitempty"`
+	FilteredByName   *int           `json:"filtered_by_name,omitempty"`   // Stations left out by -station-filter and -station-exclude, if given
+	FilteredByStatus *int           `json:"filtered_by_status,omitempty"` // Stations left out by -exclude-status, if given
+	Sampled          []string       `json:"sampled,omitempty"`
+	EscapedValues    []escapedValue `json:"escaped_values,omitempty"`
+	Warnings         []string       `json:"warnings"`
+	Findings         []finding      `json:"findings,omitempty"` // The warnings with their class and effective severity
+	Metrics          *metrics       `json:"metrics,omitempty"`
+}
+
+// Number of stations on a rail line.
+type lineCount struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	Stations int    `json:"stations"`
+}
+
+// Number of stations in an alarm zone or a station complex.
+type zoneCount struct {
+	Id       int    `json:"id"`
+	Name     string `json:"name"`
+	Stations int    `json:"stations"`
+}
+
+// Width of the longest bar in the stations per line histogram of the summary.
+const histogramWidth = 40
+
+// Gather the statistics for the rail lines and stations as they will be emitted.
+func (c *conversion) newReport(lines []railLine, stations []station) report {
+	r := report{
+		Lines:           len(lines),
+		Stations:        len(stations),
+		StationsPerLine: make([]lineCount, len(lines)),
+		Warnings:        append([]string{}, c.warnings...),
+		Findings:        c.findings,
+	}
+	for i, line := range lines {
+		r.StationsPerLine[i] = lineCount{Id: i + 1, Name: line.name}
+	}
+
+	for _, s := range stations {
+		r.Links += len(s.lines)
+		r.Panels += len(s.panels)
+		if 2 <= len(s.lines) {
+			r.TransferStations++
+		}
+		for _, lineId := range s.lines {
+			if lineId <= len(lines) {
+				r.StationsPerLine[lineId-1].Stations++
+			}
+		}
+	}
+
+	for i, zone := range collectZones(stations) {
+		count := zoneCount{Id: i + 1, Name: zone}
+		for _, s := range stations {
+			if zone == s.zone {
+				count.Stations++
+			}
+		}
+		r.Zones = append(r.Zones, count)
+	}
+
+	complexes, _ := collectComplexes(stations)
+	for i, complex := range complexes {
+		count := zoneCount{Id: i + 1, Name: complex}
+		for _, s := range stations {
+			if complex == s.complex {
+				count.Stations++
+			}
+		}
+		r.Complexes = append(r.Complexes, count)
+	}
+
+	largest := 0
+	for _, count := range r.StationsPerLine {
+		if largest < count.Stations {
+			largest = count.Stations
+			r.LargestLine = count.Name
+		}
+	}
+	return r
+}
+
+// Write the statistics in a human readable form.
+func (r report) writeSummary(writer io.Writer) error {
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Rail lines: %d\n", r.Lines)
+	fmt.Fprintf(&summary, "Stations: %d (%d transfer stations)\n", r.Stations, r.TransferStations)
+	fmt.Fprintf(&summary, "Links: %d\n", r.Links)
+	if 0 < r.Panels {
+		fmt.Fprintf(&summary, "Alarm panels: %d\n", r.Panels)
+	}
+	if 0 < r.DuplicateLinks {
+		fmt.Fprintf(&summary, "Duplicate links dropped: %d\n", r.DuplicateLinks)
+	}
+	if "" != r.LargestLine {
+		fmt.Fprintf(&summary, "Largest line: %s\n", r.LargestLine)
+	}
+
+	largest, nameWidth := 0, 0
+	for _, count := range r.StationsPerLine {
+		largest = max(largest, count.Stations)
+		nameWidth = max(nameWidth, len(count.Name))
+	}
+	if 0 < len(r.StationsPerLine) {
+		summary.WriteString("Stations per line:\n")
+	}
+	for _, count := range r.StationsPerLine {
+		bar := 0
+		if 0 < largest {
+			bar = count.Stations * histogramWidth / largest
+		}
+		row := fmt.Sprintf("  %-*s %4d %s", nameWidth, count.Name, count.Stations, strings.Repeat("#", bar))
+		summary.WriteString(strings.TrimRight(row, " ") + "\n")
+	}
+
+	if 0 < len(r.Zones) {
+		summary.WriteString("Alarm zones:\n")
+	}
+	for _, count := range r.Zones {
+		fmt.Fprintf(&summary, "  %s: %d stations\n", count.Name, count.Stations)
+	}
+	if 0 < len(r.Complexes) {
+		summary.WriteString("Station complexes:\n")
+	}
+	for _, count := range r.Complexes {
+		fmt.Fprintf(&summary, "  %s: %d stations\n", count.Name, count.Stations)
+	}
+
+	if nil != r.FilteredByName {
+		fmt.Fprintf(&summary, "Stations filtered out by name: %d\n", *r.FilteredByName)
+	}
+	if nil != r.FilteredByStatus {
+		fmt.Fprintf(&summary, "Stations filtered out by status: %d\n", *r.FilteredByStatus)
+	}
+	if 0 < len(r.Sampled) {
+		fmt.Fprintf(&summary, "Sampled stations: %s\n", strings.Join(r.Sampled, ", "))
+	}
+	if 0 < len(r.Warnings) {
+		fmt.Fprintf(&summary, "Warnings: %d\n", len(r.Warnings))
+	}
+	for _, message := range r.Warnings {
+		fmt.Fprintf(&summary, "  %s\n", message)
+	}
+	if nil != r.Metrics {
+		if err := r.Metrics.writeSummary(&summary); nil != err {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(writer, summary.String())
+	return err
+}
+
+// Write the statistics as JSON to the file at path, logging a failure to close
+// it to logger.
+func (r report) writeFile(path string, logger *log.Logger) error {
+	file, err := os.Create(path)
+	if nil != err {
+		return fmt.Errorf("Failed to create %s: %w", path, err)
+	}
+	defer func(file *os.File, path string) {
+		if err := file.Close(); nil != err {
+			logger.Printf("Failed to close %s: %v\n", path, err)
+		}
+	}(file, path)
+
+	encoder := json.NewEncoder(file)
+	encoder.SetIndent("", "\t")
+	if err := encoder.Encode(r); nil != err {
+		return fmt.Errorf("Failed to write report to %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// The stations filtered out by name and by status are counted in the summary
+// and the report, and left out of both without the filters.
+func TestFilteredStationsReported(t *testing.T) {
+	writeFiles(t, map[string]string{
+		"lines.csv":    testLines,
+		"stations.csv": "Station,Ruby,Emerald,status\nFoo,true,false,open\nBar's,true,true,\nBaz,true,false,closed\nQux,false,true,planned\nDepot,true,false,open\n",
+	})
+	_, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-station-exclude", "^Depot$", "-exclude-status", "closed,planned", "-summary", "-report", "report.json")
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Stations: 2 ", "Stations filtered out by name: 1\n", "Stations filtered out by status: 2\n"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("Expected %q in the summary:\n%s", want, stderr)
+		}
+	}
+	text, err := os.ReadFile("report.json")
+	if nil != err {
+		t.Fatal(err)
+	}
+	var report map[string]any
+	if err := json.Unmarshal(text, &report); nil != err {
+		t.Fatal(err)
+	}
+	if 1.0 != report["filtered_by_name"] || 2.0 != report["filtered_by_status"] {
+		t.Errorf("Expected 1 station filtered by name and 2 by status in the report, got %v and %v", report["filtered_by_name"], report["filtered_by_status"])
+	}
+
+	// A filter that drops nothing is still counted
+	_, stderr, err = runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-station-filter", ".", "-summary", "-report", "report.json")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr, "Stations filtered out by name: 0\n") || strings.Contains(stderr, "by status") {
+		t.Errorf("Expected only the stations filtered by name in the summary:\n%s", stderr)
+	}
+
+	_, stderr, err = runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-summary", "-report", "report.json")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if strings.Contains(stderr, "filtered out") {
+		t.Errorf("Expected no filtered stations in the summary without filters:\n%s", stderr)
+	}
+	if text, err = os.ReadFile("report.json"); nil != err {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(text), "filtered_by") {
+		t.Errorf("Expected no filtered stations in the report without filters:\n%s", text)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Stations CSV of four stations, the second of which has an invalid rail line
+// cell and the third a record of the wrong width.
+const windowStations = "Station,Ruby,Emerald\nFoo,true,false\nBar,maybe,true\nBaz,true\nQux,false,true\n"
+
+func TestSkipAndLimitRows(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": basicStations + "Baz,false,true\nQux,true,true\n"})
+	tests := []struct {
+		args []string
+		want []string // Station inserts, in order
+	}{
+		{nil, []string{"(1, 'Foo')", "(2, 'Bar''s')", "(3, 'Baz')", "(4, 'Qux')"}},
+		{[]string{"-skip-rows", "1"}, []string{"(1, 'Bar''s')", "(2, 'Baz')", "(3, 'Qux')"}},
+		{[]string{"-limit-rows", "2"}, []string{"(1, 'Foo')", "(2, 'Bar''s')"}},
+		{[]string{"-skip-rows", "1", "-limit-rows", "2"}, []string{"(1, 'Bar''s')", "(2, 'Baz')"}},
+		{[]string{"-skip-rows", "1", "-limit-rows", "2", "-preserve-ids"}, []string{"(2, 'Bar''s')", "(3, 'Baz')"}},
+		{[]string{"-skip-rows", "3", "-limit-rows", "5", "-preserve-ids"}, []string{"(4, 'Qux')"}},
+		{[]string{"-limit-rows", "0"}, []string{"(1, 'Foo')", "(2, 'Bar''s')", "(3, 'Baz')", "(4, 'Qux')"}},
+	}
+	for _, test := range tests {
+		stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv"}, test.args...)...)
+		if nil != err {
+			t.Fatalf("Failed with %v: %v", test.args, err)
+		}
+		var got []string
+		for _, line := range strings.Split(stdout, "\n") {
+			if values, found := strings.CutPrefix(line, "INSERT INTO Stations VALUES "); found {
+				got = append(got, strings.TrimSuffix(values, ";"))
+			}
+		}
+		if strings.Join(test.want, " ") != strings.Join(got, " ") {
+			t.Errorf("Expected %v with %v, got %v", test.want, test.args, got)
+		}
+	}
+}
+
+// Errors in the skipped records are only reported with -validate-skipped,
+// while those after them always are.
+func TestValidateSkipped(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": windowStations})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-skip-rows", "3", "-preserve-ids")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout, "INSERT INTO Stations VALUES (4, 'Qux');\n") || strings.Contains(stdout, "'Bar'") {
+		t.Errorf("Expected only Qux:\n%s", stdout)
+	}
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-skip-rows", "3", "-validate-skipped"}, "Failed to parse boolean value for Bar"},
+		{[]string{"-skip-rows", "2", "-limit-rows", "1", "-validate-skipped"}, "Failed to parse boolean value for Bar"},
+		{[]string{"-skip-rows", "1"}, "Failed to parse boolean value for Bar"},
+		{[]string{"-skip-rows", "2"}, "Failed to read record for station 3"},
+	} {
+		if _, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv"}, test.args...)...); nil == err || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected an error containing %q with %v, got %v", test.err, test.args, err)
+		}
+	}
+}
+
+func TestRowWindowErrors(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": basicStations})
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-skip-rows", "-5", "-preserve-ids"}, "Invalid number of rows to skip: -5"},
+		{[]string{"-limit-rows", "-1"}, "Invalid number of rows to convert: -1"},
+	} {
+		if _, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv"}, test.args...)...); nil == err || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected %q with %v, got %v", test.err, test.args, err)
+		}
+	}
+}
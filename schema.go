@@ -0,0 +1,219 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Table of the database schema read with -schema-file.
+type schemaTable struct {
+	name    string // Name of the table as spelled in the schema
+	columns []schemaColumn
+}
+
+// Column of a [schemaTable], in the order of the table.
+type schemaColumn struct {
+	name       string
+	notNull    bool // Whether the column is NOT NULL
+	hasDefault bool // Whether the column has a DEFAULT
+}
+
+// Start of every CREATE TABLE statement, up to the opening parenthesis.
+var createTablePattern = regexp.MustCompile(`(?i)CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([^\s(]+)\s*\(`)
+
+// Parse the CREATE TABLE statements of the schema file at path. Only the column
+// names, NOT NULL, and DEFAULT are picked out of each column definition, while
+// table constraints like PRIMARY KEY and FOREIGN KEY are skipped.
+func parseSchemaFile(path string) (map[string]schemaTable, error) {
+	text, err := os.ReadFile(path)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to read %s: %w", path, err)
+	}
+	return parseSchema(string(text))
+}
+
+// Parse the CREATE TABLE statements of a schema, see [parseSchemaFile].
+func parseSchema(text string) (map[string]schemaTable, error) {
+	text = uncomment(text)
+
+	tables := make(map[string]schemaTable)
+	for _, match := range createTablePattern.FindAllStringSubmatchIndex(text, -1) {
+		table := schemaTable{name: unquoteIdentifier(text[match[2]:match[3]])}
+		definitions, err := splitDefinitions(text[match[1]:])
+		if nil != err {
+			return nil, fmt.Errorf("Invalid definition of table %s: %w", table.name, err)
+		}
+		for _, definition := range definitions {
+			words := strings.Fields(definition)
+			if 0 == len(words) {
+				continue
+			}
+			switch strings.ToUpper(words[0]) {
+			case "PRIMARY", "FOREIGN", "UNIQUE", "CONSTRAINT", "CHECK":
+				continue
+			}
+			upper := strings.ToUpper(definition)
+			table.columns = append(table.columns, schemaColumn{
+				name:       unquoteIdentifier(words[0]),
+				notNull:    strings.Contains(upper, "NOT NULL"),
+				hasDefault: slices.Contains(strings.Fields(upper), "DEFAULT"),
+			})
+		}
+		tables[strings.ToLower(table.name)] = table
+	}
+	if 0 == len(tables) {
+		return nil, fmt.Errorf("No CREATE TABLE statements found")
+	}
+	return tables, nil
+}
+
+// Remove the line comments from the text of a schema.
+func uncomment(text string) string {
+	var uncommented strings.Builder
+	for line := range strings.Lines(text) {
+		line, _, _ = strings.Cut(line, "--")
+		uncommented.WriteString(line + "\n")
+	}
+	return uncommented.String()
+}
+
+// Split the body of a CREATE TABLE statement following its opening parenthesis
+// into the column and constraint definitions, up to the closing parenthesis.
+func splitDefinitions(body string) ([]string, error) {
+	var definitions []string
+	depth, start := 0, 0
+	for i, char := range body {
+		switch char {
+		case '(':
+			depth++
+		case ')':
+			if 0 == depth {
+				return append(definitions, body[start:i]), nil
+			}
+			depth--
+		case ',':
+			if 0 == depth {
+				definitions = append(definitions, body[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return nil, fmt.Errorf("Missing closing parenthesis")
+}
+
+// Remove the quotes around an identifier.
+func unquoteIdentifier(identifier string) string {
+	return strings.Trim(identifier, "\"`[]")
+}
+
+// Check that every table the inserts are for is in the schema with all of the
+// columns they fill, and that they fill every NOT NULL column of the table
+// without a DEFAULT. The tables map each table name to the columns filled.
+func checkSchema(schema map[string]schemaTable, tables map[string][]string) error {
+	var errs []error
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		table, found := schema[strings.ToLower(name)]
+		if !found {
+			errs = append(errs, fmt.Errorf("Table %s is not in the schema", name))
+			continue
+		}
+		for _, column := range tables[name] {
+			if !slices.ContainsFunc(table.columns, func(c schemaColumn) bool { return strings.EqualFold(column, c.name) }) {
+				errs = append(errs, fmt.Errorf("Column %s of table %s is not in the schema", column, name))
+			}
+		}
+		for _, column := range table.columns {
+			if column.notNull && !column.hasDefault && !slices.ContainsFunc(tables[name], func(filled string) bool { return strings.EqualFold(column.name, filled) }) {
+				errs = append(errs, fmt.Errorf("Column %s of table %s is NOT NULL but has no value", column.name, name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Build an insert statement into the table as it is in the schema of the
+// conversion, listing its columns in the order of the schema. Columns without a
+// value are dealt with as -pad-nulls says: "" to list every column without a
+// DEFAULT with NULL for those, "columns" to list only the columns with a value,
+// or "positional" to list no columns and give every column of the table a
+// value, NULL or DEFAULT.
+func (c *conversion) schemaInsert(table string, columns []string, values []string) string {
+	current := c.schema[strings.ToLower(table)]
+	var schemaColumns, schemaValues []string
+	for _, column := range current.columns {
+		index := slices.IndexFunc(columns, func(name string) bool { return strings.EqualFold(column.name, name) })
+		value := "NULL"
+		if 0 <= index {
+			value = values[index]
+		} else if "positional" == c.padNulls && column.hasDefault {
+			value = "DEFAULT"
+		} else if "positional" != c.padNulls && (column.hasDefault || "columns" == c.padNulls) {
+			continue
+		}
+		schemaColumns = append(schemaColumns, column.name)
+		schemaValues = append(schemaValues, value)
+	}
+	if "positional" == c.padNulls {
+		return fmt.Sprintf("INSERT INTO %s VALUES (%s);\n", current.name, strings.Join(schemaValues, ", "))
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);\n", current.name, strings.Join(schemaColumns, ", "), strings.Join(schemaValues, ", "))
+}
+
+// Check that the tables the inserts are for can be filled positionally, which
+// in SQLite, or when the statements are run in it for the self-test, means
+// without any DEFAULT, as it has no DEFAULT keyword in VALUES. The tables map
+// each table name to the columns filled.
+func (c *conversion) checkPositional(tables map[string][]string, selfTest bool) error {
+	if "sqlite" != c.dialect && !selfTest {
+		return nil
+	}
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		for _, column := range c.schema[strings.ToLower(name)].columns {
+			if column.hasDefault && !slices.ContainsFunc(tables[name], func(filled string) bool { return strings.EqualFold(column.name, filled) }) {
+				errs = append(errs, fmt.Errorf("Column %s of table %s would need DEFAULT, which SQLite does not allow in VALUES", column.name, name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check the inserts against the schema of setup.sql, or whichever schema is
+// given with -check-schema, without adapting them to it: as [checkSchema] does,
+// and for the inserts giving their values without a column list, that the table
+// has exactly the columns given values in that order. The tables map each
+// table name to the columns filled, and positional gives the columns the inserts
+// into a table give values for in order when they have no column list.
+func checkDrift(expected map[string]schemaTable, tables map[string][]string, positional func(table string) ([]string, bool)) error {
+	errs := []error{checkSchema(expected, tables)}
+	for _, name := range slices.Sorted(maps.Keys(tables)) {
+		table, found := expected[strings.ToLower(name)]
+		filled, isPositional := positional(name)
+		if !found || !isPositional {
+			continue
+		}
+		names := make([]string, len(table.columns))
+		for i, column := range table.columns {
+			names[i] = column.name
+		}
+		if len(names) != len(filled) {
+			errs = append(errs, fmt.Errorf("%s has %d columns (%s) but the inserts supply %d values (%s)", table.name, len(names),
+				strings.Join(names, ", "), len(filled), strings.Join(filled, ", ")))
+		} else if !slices.EqualFunc(names, filled, strings.EqualFold) {
+			errs = append(errs, fmt.Errorf("%s has the columns (%s) but the inserts supply values for (%s) in that order", table.name,
+				strings.Join(names, ", "), strings.Join(filled, ", ")))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Rows of every table as column values, keyed by the table and column names in
+// lower case.
+type tableRows map[string][]map[string]string
+
+// Columns of each table in the order of setup.sql, for inserts without a column
+// list.
+var tableColumns = map[string][]string{
+	"networks":          {"id", "name"},
+	"modes":             {"id", "name"},
+	"agencies":          {"id", "name"},
+	"agencyattributes":  {"agency_id", "name", "value"},
+	"raillines":         {"id", "name", "red", "green", "blue"},
+	"stations":          {"id", "name"},
+	"linestations":      {"line_id", "station_id"},
+	"stationattributes": {"station_id", "name", "value"},
+	"stationaliases":    {"station_id", "alias"},
+	"stationnames":      {"station_id", "lang", "name"},
+	"complexes":         {"id", "name"},
+	"alarmzones":        {"id", "name"},
+	"stationzones":      {"station_id", "zone_id"},
+	"devices":           {"id", "station_id", "type", "serial"},
+	"entrances":         {"id", "station_id", "name", "latitude", "longitude", "emergency_only"},
+	"panels":            {"id", "station_id", "tag"},
+	"connections":       {"line_id", "from_station_id", "to_station_id"},
+}
+
+// Primary key columns of each table.
+var primaryKeys = map[string][]string{
+	"networks":          {"id"},
+	"modes":             {"id"},
+	"agencies":          {"id"},
+	"agencyattributes":  {"agency_id", "name"},
+	"raillines":         {"id"},
+	"stations":          {"id"},
+	"linestations":      {"line_id", "station_id"},
+	"stationattributes": {"station_id", "name"},
+	"stationaliases":    {"station_id", "alias"},
+	"stationnames":      {"station_id", "lang"},
+	"complexes":         {"id"},
+	"alarmzones":        {"id"},
+	"stationzones":      {"station_id", "zone_id"},
+	"devices":           {"id"},
+	"entrances":         {"id"},
+	"panels":            {"id"},
+	"connections":       {"line_id", "from_station_id", "to_station_id"},
+}
+
+// Column of a table referencing the id column of another.
+type foreignKey struct {
+	table, column, references string
+}
+
+// Every reference between the tables. Those through columns that are only
+// sometimes filled, like network_id, are only checked when the column is.
+var foreignKeys = []foreignKey{
+	{"raillines", "network_id", "networks"},
+	{"raillines", "mode_id", "modes"},
+	{"raillines", "agency_id", "agencies"},
+	{"agencyattributes", "agency_id", "agencies"},
+	{"stations", "network_id", "networks"},
+	{"stations", "zone_id", "alarmzones"},
+	{"stations", "complex_id", "complexes"},
+	{"linestations", "line_id", "raillines"},
+	{"linestations", "station_id", "stations"},
+	{"linestations", "network_id", "networks"},
+	{"stationattributes", "station_id", "stations"},
+	{"stationaliases", "station_id", "stations"},
+	{"stationnames", "station_id", "stations"},
+	{"stationzones", "station_id", "stations"},
+	{"stationzones", "zone_id", "alarmzones"},
+	{"devices", "station_id", "stations"},
+	{"entrances", "station_id", "stations"},
+	{"panels", "station_id", "stations"},
+	{"connections", "line_id", "raillines"},
+	{"connections", "from_station_id", "stations"},
+	{"connections", "to_station_id", "stations"},
+}
+
+// Gather the rows about to be emitted for the modes, agencies, rail lines,
+// stations, panels, complexes, zones, connections, devices, and entrances,
+// where deviceStationIds and entranceStationIds are the station IDs of the
+// devices and entrances and networkIds the IDs of the Networks rows.
+func plannedRows(lines []railLine, stations []station, modes []string, agencies []agency, complexes []string, zones []string, zoneLinks bool, deviceStationIds []int, entranceStationIds []int, networkIds []int, networkLinks bool, connections []connection) tableRows {
+	rows := make(tableRows)
+	withFields := func(row map[string]string, fields []field) map[string]string {
+		for _, f := range fields {
+			row[strings.ToLower(f.column)] = f.literal
+		}
+		return row
+	}
+
+	for _, networkId := range networkIds {
+		rows["networks"] = append(rows["networks"], map[string]string{"id": strconv.Itoa(networkId)})
+	}
+	for i := range modes {
+		rows["modes"] = append(rows["modes"], map[string]string{"id": strconv.Itoa(i + 1)})
+	}
+	for i, current := range agencies {
+		agencyId := strconv.Itoa(i + 1)
+		rows["agencies"] = append(rows["agencies"], map[string]string{"id": agencyId})
+		if "" != current.contact {
+			rows["agencyattributes"] = append(rows["agencyattributes"], map[string]string{"agency_id": agencyId, "name": "contact"})
+		}
+	}
+	for i, line := range lines {
+		rows["raillines"] = append(rows["raillines"], withFields(map[string]string{"id": strconv.Itoa(i + 1)}, line.fields))
+	}
+	for i := range complexes {
+		rows["complexes"] = append(rows["complexes"], map[string]string{"id": strconv.Itoa(i + 1)})
+	}
+	for i := range zones {
+		rows["alarmzones"] = append(rows["alarmzones"], map[string]string{"id": strconv.Itoa(i + 1)})
+	}
+	panelId := 0
+	for _, current := range stations {
+		stationId := strconv.Itoa(current.id)
+		for range current.panels {
+			panelId++
+			rows["panels"] = append(rows["panels"], map[string]string{"id": strconv.Itoa(panelId), "station_id": stationId})
+		}
+		rows["stations"] = append(rows["stations"], withFields(map[string]string{"id": stationId}, current.fields))
+		for _, lineId := range current.lines {
+			link := map[string]string{"line_id": strconv.Itoa(lineId), "station_id": stationId}
+			if index := slices.IndexFunc(current.fields, func(f field) bool { return networkIdColumn.name == f.column }); networkLinks && 0 <= index {
+				link[networkIdColumn.name] = current.fields[index].literal
+			}
+			rows["linestations"] = append(rows["linestations"], link)
+		}
+		for _, alias := range current.aliases {
+			rows["stationaliases"] = append(rows["stationaliases"], map[string]string{"station_id": stationId, "alias": alias})
+		}
+		for _, name := range current.names {
+			rows["stationnames"] = append(rows["stationnames"], map[string]string{"station_id": stationId, "lang": name.language})
+		}
+		for _, attr := range current.attributes {
+			rows["stationattributes"] = append(rows["stationattributes"], map[string]string{"station_id": stationId, "name": attr.key})
+		}
+		if zoneId := slices.Index(zones, current.zone); zoneLinks && 0 <= zoneId {
+			rows["stationzones"] = append(rows["stationzones"], map[string]string{"station_id": stationId, "zone_id": strconv.Itoa(zoneId + 1)})
+		}
+	}
+	for _, current := range connections {
+		rows["connections"] = append(rows["connections"], map[string]string{"line_id": strconv.Itoa(current.lineId),
+			"from_station_id": strconv.Itoa(current.fromStationId), "to_station_id": strconv.Itoa(current.toStationId)})
+	}
+	for i, stationId := range deviceStationIds {
+		rows["devices"] = append(rows["devices"], map[string]string{"id": strconv.Itoa(i + 1), "station_id": strconv.Itoa(stationId)})
+	}
+	for i, stationId := range entranceStationIds {
+		rows["entrances"] = append(rows["entrances"], map[string]string{"id": strconv.Itoa(i + 1), "station_id": strconv.Itoa(stationId)})
+	}
+	return rows
+}
+
+// Check that no primary key is in the rows twice and that every reference is
+// to a row that is there.
+func checkRows(rows tableRows) error {
+	var errs []error
+	for _, table := range slices.Sorted(maps.Keys(rows)) {
+		keyColumns, found := primaryKeys[table]
+		if !found {
+			continue
+		}
+		seen := make(map[string]bool, len(rows[table]))
+		for _, row := range rows[table] {
+			key := make([]string, len(keyColumns))
+			for i, column := range keyColumns {
+				key[i] = row[column]
+			}
+			if joined := strings.Join(key, ", "); seen[joined] {
+				errs = append(errs, fmt.Errorf("Duplicate primary key (%s) in %s", joined, table))
+			} else {
+				seen[joined] = true
+			}
+		}
+	}
+
+	for _, key := range foreignKeys {
+		ids := make(map[string]bool, len(rows[key.references]))
+		for _, row := range rows[key.references] {
+			ids[row["id"]] = true
+		}
+		for _, row := range rows[key.table] {
+			if value, found := row[key.column]; found && "NULL" != value && !ids[value] {
+				errs = append(errs, fmt.Errorf("%s.%s %s references no row of %s", key.table, key.column, value, key.references))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Insert statement as generated by [emitter.writeInsert].
+var insertPattern = regexp.MustCompile(`^INSERT INTO (\w+) (?:\(([^)]*)\) )?VALUES \((.*)\);$`)
+
+// Parse the rows back out of the generated insert statements, ignoring every
+// other statement.
+func (c *conversion) parseStatements(text string) (tableRows, error) {
+	rows := make(tableRows)
+	for i, line := range strings.Split(text, "\n") {
+		if !strings.HasPrefix(line, "INSERT ") {
+			continue
+		}
+		match := insertPattern.FindStringSubmatch(line)
+		if nil == match {
+			return nil, fmt.Errorf("Unrecognized statement on line %d: %s", i+1, line)
+		}
+
+		table := strings.ToLower(match[1])
+		columns := tableColumns[table]
+		if current, found := c.schema[table]; found {
+			columns = nil
+			for _, column := range current.columns {
+				columns = append(columns, strings.ToLower(column.name))
+			}
+		}
+		if "" != match[2] {
+			columns = strings.Split(strings.ToLower(match[2]), ", ")
+		}
+		values, err := splitValues(match[3])
+		if nil != err {
+			return nil, fmt.Errorf("Invalid values on line %d: %w", i+1, err)
+		}
+		if len(columns) != len(values) {
+			return nil, fmt.Errorf("Expected %d values for %s on line %d but found %d", len(columns), table, i+1, len(values))
+		}
+
+		row := make(map[string]string, len(values))
+		for j, column := range columns {
+			row[column] = values[j]
+		}
+		rows[table] = append(rows[table], row)
+	}
+	return rows, nil
+}
+
+// Split a list of SQL literals on the commas between them, skipping over those
+// inside string literals in any string style and inside parentheses.
+func splitValues(list string) ([]string, error) {
+	var values []string
+	depth, start := 0, 0
+	for i := 0; i < len(list); i++ {
+		switch list[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if 0 == depth {
+				values = append(values, strings.TrimSpace(list[start:i]))
+				start = i + 1
+			}
+		case '\'':
+			escaped := 0 < i && 'E' == list[i-1]
+			for i++; i < len(list); i++ {
+				if escaped && '\\' == list[i] {
+					i++
+				} else if '\'' == list[i] {
+					if i+1 < len(list) && '\'' == list[i+1] {
+						i++
+					} else {
+						break
+					}
+				}
+			}
+			if len(list) <= i {
+				return nil, fmt.Errorf("Unterminated string in %s", list)
+			}
+		case '$':
+			end := strings.IndexByte(list[i+1:], '$')
+			if 0 > end {
+				return nil, fmt.Errorf("Unterminated dollar quote in %s", list)
+			}
+			tag := list[i : i+end+2]
+			closing := strings.Index(list[i+len(tag):], tag)
+			if 0 > closing {
+				return nil, fmt.Errorf("Unterminated dollar quote in %s", list)
+			}
+			i += len(tag) + closing + len(tag) - 1
+		}
+	}
+	return append(values, strings.TrimSpace(list[start:])), nil
+}
+
+// Start of a PostgreSQL dollar quote tag.
+var dollarTagPattern = regexp.MustCompile(`^\$[A-Za-z_0-9]*\$`)
+
+// Check that the statements are complete: every string literal, quoted
+// identifier, dollar quote, and comment is closed, the parentheses balance, and
+// the text ends with a semicolon outside of all of them, ignoring whitespace and
+// comments after it. Text of nothing but comments is allowed. Backslashes escape
+// the next character in E-strings, and in every quoted string of the mysql
+// target dialect.
+func checkSyntax(text string, target string) error {
+	depth, terminated := 0, true
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case ' ' == c || '\t' == c || '\r' == c || '\n' == c:
+		case strings.HasPrefix(text[i:], "--"):
+			end := strings.IndexByte(text[i:], '\n')
+			if 0 > end {
+				end = len(text) - i
+			}
+			i += end
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if 0 > end {
+				return fmt.Errorf("Unterminated comment at byte %d", i)
+			}
+			i += end + 3
+		case ';' == c:
+			if 0 != depth {
+				return fmt.Errorf("Semicolon inside parentheses at byte %d", i)
+			}
+			terminated = true
+		default:
+			terminated = false
+			switch c {
+			case '(':
+				depth++
+			case ')':
+				if depth--; 0 > depth {
+					return fmt.Errorf("Unbalanced closing parenthesis at byte %d", i)
+				}
+			case '\'', '"':
+				start := i
+				escaped := "mysql" == target || ('\'' == c && 0 < i && ('E' == text[i-1] || 'e' == text[i-1]))
+				for i++; ; i++ {
+					if len(text) <= i {
+						return fmt.Errorf("Unterminated quote starting at byte %d", start)
+					}
+					if escaped && '\\' == text[i] {
+						i++
+					} else if c == text[i] {
+						if i+1 < len(text) && c == text[i+1] {
+							i++
+						} else {
+							break
+						}
+					}
+				}
+			case '$':
+				if tag := dollarTagPattern.FindString(text[i:]); "" != tag {
+					end := strings.Index(text[i+len(tag):], tag)
+					if 0 > end {
+						return fmt.Errorf("Unterminated dollar quote starting at byte %d", i)
+					}
+					i += len(tag) + end + len(tag) - 1
+				}
+			}
+		}
+	}
+	if 0 != depth {
+		return fmt.Errorf("Missing %d closing parentheses", depth)
+	}
+	if !terminated {
+		return fmt.Errorf("Missing terminating semicolon")
+	}
+	return nil
+}
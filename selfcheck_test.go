@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckSyntax(t *testing.T) {
+	tests := []struct {
+		text   string
+		target string
+		valid  bool
+	}{
+		{`INSERT INTO Stations VALUES (1, 'Foo');`, "standard", true},
+		{`INSERT INTO Stations VALUES (1, 'Foo\');`, "standard", true},
+		{`INSERT INTO Stations VALUES (1, 'Foo\');`, "mysql", false},
+		{`INSERT INTO Stations VALUES (1, 'Foo\\');`, "mysql", true},
+		{`INSERT INTO Stations VALUES (1, 'It\'s');`, "mysql", true},
+		{`INSERT INTO Stations VALUES (1, 'It\'s');`, "standard", false},
+		{`INSERT INTO Stations VALUES (1, "It\"s");`, "mysql", true},
+		{`INSERT INTO Stations VALUES (1, E'It\'s');`, "postgres", true},
+		{`INSERT INTO Stations VALUES (1, 'O''Brien');`, "mysql", true},
+		{`INSERT INTO Stations VALUES (1, $csv$Foo\$csv$);`, "postgres", true},
+		{`INSERT INTO Stations VALUES (1, 'Foo')`, "standard", false},
+		{`INSERT INTO Stations VALUES (1, 'Foo';`, "standard", false},
+		{`-- Only a comment`, "mysql", true},
+	}
+	for _, test := range tests {
+		if err := checkSyntax(test.text, test.target); test.valid != (nil == err) {
+			t.Errorf("checkSyntax(%s, %s) = %v, want valid %t", test.text, test.target, err, test.valid)
+		}
+	}
+}
+
+func TestCheckSyntaxBackslashNames(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo\\,true,false\n"})
+	for _, dialect := range []string{"standard", "mysql"} {
+		if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-dialect", dialect, "-check-syntax"); nil != err {
+			t.Errorf("Syntax check failed for %s: %v", dialect, err)
+		}
+	}
+}
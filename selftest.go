@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"slices"
+)
+
+// In-memory SQLite database every statement is executed against on its way to
+// the output for -self-test. Every write is expected to be one or more complete
+// statements.
+type selfTest struct {
+	db     *sql.DB
+	writer io.Writer // Where the statements are written after they are executed
+	logger *log.Logger
+}
+
+// Create the in-memory database with the tables of the setup script.
+func newSelfTest(setup string, logger *log.Logger) (*selfTest, error) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if nil != err {
+		return nil, fmt.Errorf("Failed to open in-memory database: %w", err)
+	}
+	// Every connection would get a database of its own
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); nil != err {
+		db.Close()
+		return nil, fmt.Errorf("Failed to enable foreign keys: %w", err)
+	}
+	if _, err := db.Exec(setup); nil != err {
+		db.Close()
+		return nil, fmt.Errorf("Failed to create tables: %w", err)
+	}
+	return &selfTest{db: db, logger: logger}, nil
+}
+
+func (test *selfTest) Write(statement []byte) (int, error) {
+	if _, err := test.db.Exec(string(statement)); nil != err {
+		return 0, fmt.Errorf("SQLite failed to execute %q: %w", bytes.TrimSuffix(statement, []byte("\n")), err)
+	}
+	return test.writer.Write(statement)
+}
+
+// Check that every table has as many rows as planned.
+func (test *selfTest) checkCounts(planned tableRows) error {
+	var errs []error
+	for _, table := range slices.Sorted(maps.Keys(planned)) {
+		var count int
+		if err := test.db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); nil != err {
+			errs = append(errs, fmt.Errorf("Failed to count the rows of %s: %w", table, err))
+		} else if len(planned[table]) != count {
+			errs = append(errs, fmt.Errorf("Expected %d rows in %s but found %d", len(planned[table]), table, count))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Check that the views created by -with-views can be queried and have a row
+// for every link and every rail line.
+func (test *selfTest) checkViews(planned tableRows) error {
+	var errs []error
+	for _, expected := range []struct{ view, table string }{{"StationLines", "linestations"}, {"LineStationCounts", "raillines"}} {
+		view, table := expected.view, expected.table
+		var count int
+		if err := test.db.QueryRow("SELECT COUNT(*) FROM " + view).Scan(&count); nil != err {
+			errs = append(errs, fmt.Errorf("Failed to query view %s: %w", view, err))
+		} else if len(planned[table]) != count {
+			errs = append(errs, fmt.Errorf("Expected %d rows in view %s but found %d", len(planned[table]), view, count))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close the in-memory database.
+func (test *selfTest) close() {
+	if err := test.db.Close(); nil != err {
+		test.logger.Println("Failed to close in-memory database", err)
+	}
+}
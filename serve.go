@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Error document returned by POST /convert. Row and column are only given when
+// the failure is in a known place of a CSV file.
+type convertError struct {
+	Error  string `json:"error"`
+	Row    int    `json:"row,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// Serve conversions over HTTP, for the serve subcommand.
+func runServe(args []string, stdout io.Writer, stderr io.Writer) error {
+	flags := flag.NewFlagSet("csv2sql serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	listen := flags.String("listen", ":8080", "Address to listen on")
+	maxBytes := flags.Int64("max-bytes", 10<<20, "Largest request body accepted, in bytes")
+	timeout := flags.Duration("timeout", 30*time.Second, "Longest time to read a request and convert it")
+	if err := flags.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return nil
+	} else if nil != err {
+		return errUsage
+	}
+	if 0 >= *maxBytes {
+		return fmt.Errorf("Invalid maximum request size: %d", *maxBytes)
+	}
+	if 0 >= *timeout {
+		return fmt.Errorf("Invalid timeout: %s", *timeout)
+	}
+	server := &http.Server{
+		Addr:              *listen,
+		Handler:           http.TimeoutHandler(convertHandler(*maxBytes), *timeout, `{"error":"Timed out converting the request"}`),
+		ReadHeaderTimeout: *timeout,
+		ReadTimeout:       *timeout,
+	}
+	fmt.Fprintf(stderr, "Listening on %s\n", *listen)
+	return server.ListenAndServe()
+}
+
+// Handler of POST /convert, which converts the lines and stations CSV files of
+// a multipart upload, or the stations CSV file of the body with the lines CSV
+// file of the lines query parameter, with the [Options] of the other query
+// parameters, and responds with the statements or a [convertError]. Every
+// request is a conversion of its own, so any number can run at the same time.
+func convertHandler(maxBytes int64) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /convert", func(response http.ResponseWriter, request *http.Request) {
+		request.Body = http.MaxBytesReader(response, request.Body, maxBytes)
+		var options Options
+		var stations io.Reader
+		if mediaType, _, _ := mime.ParseMediaType(request.Header.Get("Content-Type")); "multipart/form-data" == mediaType {
+			err := request.ParseMultipartForm(maxBytes)
+			if nil != request.MultipartForm {
+				defer func() {
+					if err := request.MultipartForm.RemoveAll(); nil != err {
+						log.Printf("Failed to remove uploaded files: %v\n", err)
+					}
+				}()
+			}
+			if nil != err {
+				writeUploadError(response, maxBytes, fmt.Errorf("Expected a multipart upload of lines and stations: %w", err))
+				return
+			}
+			lines, err := readUpload(request.MultipartForm, "lines")
+			if nil != err {
+				writeConvertError(response, http.StatusBadRequest, err)
+				return
+			}
+			options.Lines = string(lines)
+			text, err := readUpload(request.MultipartForm, "stations")
+			if nil != err {
+				writeConvertError(response, http.StatusBadRequest, err)
+				return
+			}
+			stations = bytes.NewReader(text)
+		} else {
+			if !request.URL.Query().Has("lines") {
+				writeConvertError(response, http.StatusBadRequest, fmt.Errorf("Missing the lines CSV file, as the lines query parameter or a multipart upload"))
+				return
+			}
+			text, err := io.ReadAll(request.Body)
+			if nil != err {
+				writeUploadError(response, maxBytes, fmt.Errorf("Failed to read the stations CSV file: %w", err))
+				return
+			}
+			options.Lines, stations = request.URL.Query().Get("lines"), bytes.NewReader(text)
+		}
+		for parameter, values := range request.URL.Query() {
+			if "lines" == parameter {
+				continue
+			}
+			for _, value := range values {
+				if err := setConvertParameter(&options, parameter, value); nil != err {
+					writeConvertError(response, http.StatusBadRequest, err)
+					return
+				}
+			}
+		}
+
+		var script bytes.Buffer
+		for statement, err := range StatementsContext(request.Context(), stations, options) {
+			// The timeout handler has already responded once the context is done,
+			// leaving only the conversion to stop
+			if nil != request.Context().Err() {
+				return
+			} else if nil != err {
+				writeConvertError(response, http.StatusUnprocessableEntity, err)
+				return
+			}
+			script.WriteString(statement.Sql)
+		}
+		response.Header().Set("Content-Type", "application/sql; charset=utf-8")
+		if _, err := script.WriteTo(response); nil != err {
+			log.Printf("Failed to write the statements: %v\n", err)
+		}
+	})
+	return mux
+}
+
+// Set the field of the options for a query parameter of POST /convert besides
+// lines, which has the name of its flag.
+func setConvertParameter(options *Options, parameter string, value string) error {
+	var err error
+	switch parameter {
+	case "dialect":
+		options.Dialect = value
+	case "network-id":
+		options.NetworkId, err = strconv.Atoi(value)
+	case "sort-stations":
+		options.SortStations = value
+	case "skip-rows":
+		options.SkipRows, err = strconv.Atoi(value)
+	case "preserve-ids":
+		options.PreserveIds, err = strconv.ParseBool(value)
+	case "bool-style":
+		options.BoolStyle = value
+	case "strict":
+		options.Strict, err = strconv.ParseBool(value)
+	default:
+		return fmt.Errorf("Unknown query parameter %s", parameter)
+	}
+	if nil != err {
+		return fmt.Errorf("Invalid %s query parameter %q: %w", parameter, value, err)
+	}
+	return nil
+}
+
+// Read the first file uploaded as the named form field.
+func readUpload(form *multipart.Form, name string) ([]byte, error) {
+	headers := form.File[name]
+	if 0 == len(headers) {
+		return nil, fmt.Errorf("Missing the %s CSV file", name)
+	}
+	upload, err := headers[0].Open()
+	if nil != err {
+		return nil, fmt.Errorf("Failed to read the %s CSV file: %w", name, err)
+	}
+	defer func() {
+		if err := upload.Close(); nil != err {
+			log.Printf("Failed to close the %s CSV file: %v\n", name, err)
+		}
+	}()
+	text, err := io.ReadAll(upload)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to read the %s CSV file: %w", name, err)
+	}
+	return text, nil
+}
+
+// Respond to a request that could not be read with the error, or with status
+// 413 when it is larger than the maximum.
+func writeUploadError(response http.ResponseWriter, maxBytes int64, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeConvertError(response, http.StatusRequestEntityTooLarge, fmt.Errorf("Request is larger than %d bytes", maxBytes))
+	} else {
+		writeConvertError(response, http.StatusBadRequest, err)
+	}
+}
+
+// Error document of the error, finding the row and column from a CSV parse
+// error or the [rowError] of a record.
+func newConvertError(err error) convertError {
+	document := convertError{Error: err.Error()}
+	var parseErr *csv.ParseError
+	var recordErr *rowError
+	if errors.As(err, &parseErr) {
+		document.Row, document.Column = parseErr.Line, parseErr.Column
+	} else if errors.As(err, &recordErr) {
+		document.Row, document.Column = recordErr.row, recordErr.column
+	}
+	return document
+}
+
+// Respond with the error as a [convertError].
+func writeConvertError(response http.ResponseWriter, status int, err error) {
+	response.Header().Set("Content-Type", "application/json")
+	response.WriteHeader(status)
+	if err := json.NewEncoder(response).Encode(newConvertError(err)); nil != err {
+		log.Printf("Failed to write the error: %v\n", err)
+	}
+}
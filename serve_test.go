@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Stations CSV of the basic.sql golden file.
+const basicStations = "Station,Ruby,Emerald\nFoo,true,false\nBar's,true,true\n"
+
+// Body of a multipart upload of the lines and stations CSV files, with its
+// content type.
+func multipartUpload(t *testing.T, lines string, stations string) (*bytes.Buffer, string) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, text := range map[string]string{"lines": lines, "stations": stations} {
+		part, err := form.CreateFormFile(name, name+".csv")
+		if nil != err {
+			t.Fatal(err)
+		}
+		if _, err := part.Write([]byte(text)); nil != err {
+			t.Fatal(err)
+		}
+	}
+	if err := form.Close(); nil != err {
+		t.Fatal(err)
+	}
+	return &body, form.FormDataContentType()
+}
+
+// Post the request to a server of the convert handler, returning the response
+// with its body read.
+func postConvert(t *testing.T, maxBytes int64, query string, body *bytes.Buffer, contentType string) (*http.Response, string) {
+	t.Helper()
+	server := httptest.NewServer(convertHandler(maxBytes))
+	defer server.Close()
+	response, err := http.Post(server.URL+"/convert?"+query, contentType, body)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer response.Body.Close()
+	var text bytes.Buffer
+	if _, err := text.ReadFrom(response.Body); nil != err {
+		t.Fatal(err)
+	}
+	return response, text.String()
+}
+
+// Decode the error document of the response, checking its status.
+func checkConvertError(t *testing.T, response *http.Response, body string, status int) convertError {
+	t.Helper()
+	if status != response.StatusCode {
+		t.Errorf("Expected status %d, got %d: %s", status, response.StatusCode, body)
+	}
+	if contentType := response.Header.Get("Content-Type"); "application/json" != contentType {
+		t.Errorf("Expected a JSON error document, got %s", contentType)
+	}
+	var document convertError
+	if err := json.Unmarshal([]byte(body), &document); nil != err {
+		t.Fatalf("Invalid error document %q: %v", body, err)
+	}
+	return document
+}
+
+// Both kinds of upload give the statements of a conversion from the command
+// line, without leaving any file behind.
+func TestServeConvert(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+	raw := bytes.NewBufferString(basicStations)
+	upload, contentType := multipartUpload(t, testLines, basicStations)
+	for _, test := range []struct {
+		name        string
+		query       string
+		body        *bytes.Buffer
+		contentType string
+	}{
+		{"multipart", "", upload, contentType},
+		{"raw body", "lines=" + url.QueryEscape(testLines), raw, "text/csv"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			response, body := postConvert(t, 1<<20, test.query, test.body, test.contentType)
+			if http.StatusOK != response.StatusCode {
+				t.Fatalf("Expected status 200, got %d: %s", response.StatusCode, body)
+			}
+			if contentType := response.Header.Get("Content-Type"); "application/sql; charset=utf-8" != contentType {
+				t.Errorf("Expected SQL, got %s", contentType)
+			}
+			checkGolden(t, "basic.sql", body)
+		})
+	}
+	if left, _ := filepath.Glob(filepath.Join(os.Getenv("TMPDIR"), "*")); 0 != len(left) {
+		t.Errorf("Uploads left behind: %v", left)
+	}
+}
+
+// Invalid requests and conversions are refused with an error document giving
+// the row and column when known.
+func TestServeConvertErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		stations string
+		status   int
+		want     convertError
+	}{
+		{"bad boolean", "", "Station,Ruby,Emerald\nFoo,maybe,false\n", http.StatusUnprocessableEntity,
+			convertError{Error: `Failed to parse stations: Failed to parse boolean value for Foo, line Ruby: strconv.ParseBool: parsing "maybe": invalid syntax`, Row: 2, Column: 2}},
+		{"bad position", "bool-style=sequence", "Station,Ruby,Emerald\nFoo,1,\n\nBar,2,x\n", http.StatusUnprocessableEntity,
+			convertError{Error: `Failed to parse stations: Invalid position "x" for Bar, line Emerald`, Row: 4, Column: 3}},
+		{"short record", "", "Station,Ruby,Emerald\nFoo,true,false\nBar,true\n", http.StatusUnprocessableEntity,
+			convertError{Error: "Failed to parse stations: Failed to read record for station 2: record on line 3: wrong number of fields", Row: 3, Column: 1}},
+		{"unknown parameter", "color=red", basicStations, http.StatusBadRequest, convertError{Error: "Unknown query parameter color"}},
+		{"invalid parameter", "strict=maybe", basicStations, http.StatusBadRequest,
+			convertError{Error: `Invalid strict query parameter "maybe": strconv.ParseBool: parsing "maybe": invalid syntax`}},
+		{"invalid dialect", "dialect=db2", basicStations, http.StatusUnprocessableEntity, convertError{Error: "Invalid dialect: db2"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			body, contentType := multipartUpload(t, testLines, test.stations)
+			response, text := postConvert(t, 1<<20, test.query, body, contentType)
+			if document := checkConvertError(t, response, text, test.status); test.want != document {
+				t.Errorf("Error document %+v, want %+v", document, test.want)
+			}
+		})
+	}
+
+	response, text := postConvert(t, 1<<20, "", bytes.NewBufferString(basicStations), "text/csv")
+	if document := checkConvertError(t, response, text, http.StatusBadRequest); !strings.Contains(document.Error, "Missing the lines CSV file") {
+		t.Errorf("Expected the missing rail lines to be named, got %q", document.Error)
+	}
+}
+
+// A request larger than the maximum is refused with status 413, as a multipart
+// upload or a raw body.
+func TestServeConvertTooLarge(t *testing.T) {
+	stations := "Station,Ruby,Emerald\n" + strings.Repeat("Foo,true,false\n", 100)
+	upload, contentType := multipartUpload(t, testLines, stations)
+	for _, test := range []struct {
+		name        string
+		query       string
+		body        *bytes.Buffer
+		contentType string
+	}{
+		{"multipart", "", upload, contentType},
+		{"raw body", "lines=" + url.QueryEscape(testLines), bytes.NewBufferString(stations), "text/csv"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			response, text := postConvert(t, 512, test.query, test.body, test.contentType)
+			if document := checkConvertError(t, response, text, http.StatusRequestEntityTooLarge); "Request is larger than 512 bytes" != document.Error {
+				t.Errorf("Unexpected error %q", document.Error)
+			}
+		})
+	}
+}
@@ -30,4 +30,4 @@ CREATE TABLE IF NOT EXISTS UserStations (
     FOREIGN KEY (station_id) REFERENCES Stations(id),
     PRIMARY KEY (user_id, station_id)
 );
-COMMIT;
\ No newline at end of file
+COMMIT;
@@ -0,0 +1,6 @@
+//go:build !js
+
+package main
+
+// SQLite driver for -self-test and -sync, which cannot be built for the browser.
+import _ "modernc.org/sqlite"
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+)
+
+// SQLite database file the statements are executed in for -sqlite-out, all in
+// one transaction. Every write is expected to be one complete statement.
+type sqliteOutput struct {
+	path   string
+	db     *sql.DB
+	tx     *sql.Tx
+	done   bool
+	logger *log.Logger
+}
+
+// Create the database file with the tables of the setup script and start the
+// transaction of the statements. An existing file is an error unless it is to be
+// overwritten.
+func newSqliteOutput(path string, setup string, overwrite bool, logger *log.Logger) (*sqliteOutput, error) {
+	if _, err := os.Stat(path); nil == err && !overwrite {
+		return nil, fmt.Errorf("Database %s already exists, give -overwrite to replace it", path)
+	} else if nil == err {
+		if err := os.Remove(path); nil != err {
+			return nil, fmt.Errorf("Failed to remove the existing database: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("Failed to check for an existing database: %w", err)
+	}
+	db, err := sql.Open("sqlite", path)
+	if nil != err {
+		return nil, fmt.Errorf("Failed to open database %s: %w", path, err)
+	}
+	// A transaction only holds on to one connection anyway
+	db.SetMaxOpenConns(1)
+	output := &sqliteOutput{path: path, db: db, logger: logger}
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); nil != err {
+		return nil, errors.Join(fmt.Errorf("Failed to enable foreign keys: %w", err), output.remove())
+	}
+	if _, err := db.Exec(setup); nil != err {
+		return nil, errors.Join(fmt.Errorf("Failed to create tables: %w", err), output.remove())
+	}
+	if output.tx, err = db.Begin(); nil != err {
+		return nil, errors.Join(fmt.Errorf("Failed to start a transaction: %w", err), output.remove())
+	}
+	return output, nil
+}
+
+func (output *sqliteOutput) Write(statement []byte) (int, error) {
+	if _, err := output.tx.Exec(string(statement)); nil != err {
+		return 0, fmt.Errorf("SQLite failed to execute %q: %w", bytes.TrimSuffix(statement, []byte("\n")), err)
+	}
+	return len(statement), nil
+}
+
+// Commit the statements and close the database.
+func (output *sqliteOutput) finish() error {
+	output.done = true
+	if err := output.tx.Commit(); nil != err {
+		return errors.Join(fmt.Errorf("Failed to commit the statements: %w", err), output.remove())
+	}
+	if err := output.db.Close(); nil != err {
+		return fmt.Errorf("Failed to close database %s: %w", output.path, err)
+	}
+	return nil
+}
+
+// Roll back the statements and remove the partial database file when the
+// conversion stopped early.
+func (output *sqliteOutput) abort() {
+	if output.done {
+		return
+	}
+	output.done = true
+	if err := output.tx.Rollback(); nil != err {
+		output.logger.Println("Failed to roll back the statements", err)
+	}
+	if err := output.remove(); nil != err {
+		output.logger.Println(err)
+	}
+}
+
+// Close the database and remove its file.
+func (output *sqliteOutput) remove() error {
+	if err := output.db.Close(); nil != err {
+		output.logger.Printf("Failed to close database %s: %v\n", output.path, err)
+	}
+	for _, path := range []string{output.path, output.path + "-journal"} {
+		if err := os.Remove(path); nil != err && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("Failed to remove the partial database: %w", err)
+		}
+	}
+	return nil
+}
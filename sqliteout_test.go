@@ -0,0 +1,53 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSqliteOut(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nBar's,true,true\nBaz,false,true\n"})
+	args := []string{"-lines", "lines.csv", "-stations", "stations.csv", "-sqlite-out", "network.db"}
+	if _, _, err := runArgs(t, args...); nil != err {
+		t.Fatal(err)
+	}
+
+	db, err := sql.Open("sqlite", "network.db")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	for table, want := range map[string]int{"RailLines": 2, "Stations": 3, "LineStations": 4} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); nil != err {
+			t.Fatal(err)
+		}
+		if want != count {
+			t.Errorf("Expected %d rows in %s, got %d", want, table, count)
+		}
+	}
+	var name string
+	if err := db.QueryRow("SELECT name FROM Stations WHERE id = 2").Scan(&name); nil != err || "Bar's" != name {
+		t.Errorf("Expected station 2 to be Bar's, got %q and %v", name, err)
+	}
+
+	if _, _, err := runArgs(t, args...); nil == err || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected an existing database to be an error, got %v", err)
+	}
+	if _, _, err := runArgs(t, append(args, "-overwrite")...); nil != err {
+		t.Errorf("Overwriting failed: %v", err)
+	}
+}
+
+// A failed conversion leaves no database behind.
+func TestSqliteOutFailure(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nA Very Long Station Name Indeed,true,true\n"})
+	if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-sqlite-out", "network.db", "-max-statement-bytes", "60"); nil == err {
+		t.Fatal("Expected the long statement to fail")
+	}
+	if _, err := os.Stat("network.db"); !os.IsNotExist(err) {
+		t.Errorf("Expected no database after the failure, got %v", err)
+	}
+}
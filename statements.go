@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// Statement generated by [Statements]. Table and Values are only set for the
+// rows inserted with their values, giving the table in lower case and the SQL
+// literal of every column by its lower case name.
+type Statement struct {
+	Sql    string // Text of the statement as written, ending in a newline
+	Table  string
+	Values map[string]string
+}
+
+// Error the conversion is stopped with when the caller of [Statements] breaks
+// out of its loop.
+var errStopped = errors.New("Conversion stopped")
+
+// Writer passing every statement to the hook of the emitter before writing it,
+// with the row the emitter is writing, if any. Every write is expected to be one
+// complete statement.
+type statementHookWriter struct {
+	writer  io.Writer
+	emitter *emitter
+}
+
+func (w statementHookWriter) Write(text []byte) (int, error) {
+	statement := Statement{Sql: string(text)}
+	if row := &w.emitter.row; "" != row.table {
+		statement.Table, statement.Values = strings.ToLower(row.table), make(map[string]string, len(row.columns))
+		for i, column := range row.columns {
+			statement.Values[strings.ToLower(column)] = row.values[i]
+		}
+		*row = insertedRow{}
+	}
+	if err := w.emitter.hook(statement); nil != err {
+		return 0, err
+	}
+	return w.writer.Write(text)
+}
+
+// Row an emitter is about to write, for the hook to be given with its statement.
+type insertedRow struct {
+	table   string   // Table the row is inserted into, or "" when the statement is not the insert of a row
+	columns []string // Columns of the row
+	values  []string // SQL literals of the columns
+}
+
+// Record the row about to be written for the hook, if there is one.
+func (e *emitter) inserting(table string, columns []string, values []string) {
+	if nil != e.hook {
+		e.row = insertedRow{table, columns, values}
+	}
+}
+
+// Lazily convert the stations CSV file read from r with the options, yielding
+// every statement as it is generated rather than once the whole script is.
+// Every range over the statements is a conversion of its own, reading r, so
+// that any number can run at the same time. The conversion runs within the
+// loop over the statements, so breaking out of it stops the conversion, which
+// releases everything it holds as it returns. A failed conversion yields its
+// error once as the last value.
+func Statements(r io.Reader, opts Options) iter.Seq2[Statement, error] {
+	return StatementsContext(context.Background(), r, opts)
+}
+
+// Convert like [Statements], stopping the conversion at the next statement once
+// ctx is done, with the error of ctx as the last value.
+func StatementsContext(ctx context.Context, r io.Reader, opts Options) iter.Seq2[Statement, error] {
+	return func(yield func(Statement, error) bool) {
+		c := newConversion()
+		c.inlineInputs = map[string]io.Reader{"lines": strings.NewReader(opts.Lines), "stations": r}
+		stopped := false
+		c.hook = func(statement Statement) error {
+			// Nothing more is yielded once the caller broke out, like the ROLLBACK of
+			// the transaction it cut short
+			if stopped {
+				return errStopped
+			}
+			if err := ctx.Err(); nil != err {
+				return err
+			}
+			if stopped = !yield(statement, nil); stopped {
+				return errStopped
+			}
+			return nil
+		}
+
+		var messages bytes.Buffer
+		if err := c.run(opts.args(), io.Discard, &messages); errors.Is(err, errStopped) {
+			return
+		} else if nil != err && nil != ctx.Err() && errors.Is(err, ctx.Err()) {
+			yield(Statement{}, ctx.Err())
+		} else if errors.Is(err, errUsage) {
+			yield(Statement{}, fmt.Errorf("Invalid options: %s", strings.TrimSpace(messages.String())))
+		} else if nil != err {
+			yield(Statement{}, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func ExampleStatements() {
+	stations := "Station,Ruby,Emerald\nFoo,true,false\nBar's,true,true\n"
+	for statement, err := range Statements(strings.NewReader(stations), Options{Lines: testLines}) {
+		if nil != err {
+			fmt.Println(err)
+			return
+		}
+		if "stations" == statement.Table {
+			fmt.Println(statement.Values["id"], statement.Values["name"])
+		}
+	}
+	// Output:
+	// 1 'Foo'
+	// 2 'Bar''s'
+}
+
+// The values of every insert come from the row written, for each table.
+func TestStatementsValues(t *testing.T) {
+	var tables []string
+	for statement, err := range Statements(strings.NewReader("Station,Ruby,Emerald\nFoo,true,false\n"), Options{Lines: testLines, Dialect: "mysql"}) {
+		if nil != err {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(statement.Sql, "INSERT ") != ("" != statement.Table) {
+			t.Errorf("Table %q given for %q", statement.Table, statement.Sql)
+		}
+		switch statement.Table {
+		case "raillines":
+			if "'Ruby'" != statement.Values["name"] && "'Emerald'" != statement.Values["name"] {
+				t.Errorf("Unexpected rail line values %v", statement.Values)
+			}
+		case "linestations":
+			if want := map[string]string{"line_id": "1", "station_id": "1"}; fmt.Sprint(want) != fmt.Sprint(statement.Values) {
+				t.Errorf("Link values %v, want %v", statement.Values, want)
+			}
+		}
+		tables = append(tables, statement.Table)
+	}
+	if want := ",raillines,raillines,,,stations,linestations,"; want != strings.Join(tables, ",") {
+		t.Errorf("Tables %s, want %s", strings.Join(tables, ","), want)
+	}
+}
+
+// Breaking out of the loop stops the conversion without yielding anything more,
+// and leaves nothing behind for the next.
+func TestStatementsBreak(t *testing.T) {
+	stations := "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n"
+	count := 0
+	for statement, err := range Statements(strings.NewReader(stations), Options{Lines: testLines}) {
+		if nil != err {
+			t.Fatal(err)
+		}
+		count++
+		if "stations" == statement.Table {
+			break
+		}
+	}
+	if 6 != count {
+		t.Errorf("Expected the loop to stop at the sixth statement, got %d", count)
+	}
+
+	var script strings.Builder
+	for statement, err := range Statements(strings.NewReader(stations), Options{Lines: testLines}) {
+		if nil != err {
+			t.Fatal(err)
+		}
+		script.WriteString(statement.Sql)
+	}
+	if !strings.HasSuffix(script.String(), "INSERT INTO LineStations VALUES (2, 2);\nCOMMIT;\n") {
+		t.Errorf("The conversion after the break did not finish:\n%s", script.String())
+	}
+}
+
+// A conversion whose context is done stops at the next statement, yielding the
+// error of the context as its last value.
+func TestStatementsContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stations := "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n"
+	var errs []error
+	statements := 0
+	for _, err := range StatementsContext(ctx, strings.NewReader(stations), Options{Lines: testLines}) {
+		if nil != err {
+			errs = append(errs, err)
+			continue
+		}
+		statements++
+		cancel()
+	}
+	if 1 != statements {
+		t.Errorf("Expected the conversion to stop after the first statement, got %d", statements)
+	}
+	if 1 != len(errs) || context.Canceled != errs[0] {
+		t.Errorf("Expected only the error of the context, got %v", errs)
+	}
+}
+
+// A failed conversion yields its error once as the last value, without any
+// statement when the stations or the options are invalid.
+func TestStatementsError(t *testing.T) {
+	tests := []struct {
+		name     string
+		stations string
+		options  Options
+		err      string // Text the error must contain
+	}{
+		{"bad boolean", "Station,Ruby,Emerald\nFoo,maybe,false\n", Options{Lines: testLines}, `parsing "maybe"`},
+		{"bad dialect", "Station,Ruby,Emerald\nFoo,true,false\n", Options{Lines: testLines, Dialect: "db2"}, "Invalid dialect: db2"},
+		{"bad flag value", "Station,Ruby,Emerald\nFoo,true,false\n", Options{Lines: testLines, SortStations: "size"}, "Invalid station order: size"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var errs []error
+			statements := 0
+			for _, err := range Statements(strings.NewReader(test.stations), test.options) {
+				if nil != err {
+					errs = append(errs, err)
+				} else if 0 < len(errs) {
+					t.Fatalf("Statement yielded after the error %v", errs[0])
+				} else {
+					statements++
+				}
+			}
+			if 1 != len(errs) {
+				t.Fatalf("Expected one error, got %v", errs)
+			}
+			if !strings.Contains(errs[0].Error(), test.err) {
+				t.Errorf("Expected an error containing %q, got %q", test.err, errs[0])
+			}
+			if 0 != statements {
+				t.Errorf("Expected no statement before the error, got %d", statements)
+			}
+		})
+	}
+}
+
+// Warnings go to the Standard Error of the run, or nowhere for [Statements],
+// leaving the process's logger alone so conversions can run side by side.
+func TestWarningsNotLoggedGlobally(t *testing.T) {
+	var global bytes.Buffer
+	log.SetOutput(&global)
+	defer log.SetOutput(os.Stderr)
+	stations := "Station,Ruby,,Emerald\nFoo,true,,false\n"
+	for _, err := range Statements(strings.NewReader(stations), Options{Lines: testLines}) {
+		if nil != err {
+			t.Fatal(err)
+		}
+	}
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": stations})
+	_, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	if want := "Warning: Dropped column 3 of the stations CSV"; !strings.Contains(stderr, want) {
+		t.Errorf("Expected %q in Standard Error, got:\n%s", want, stderr)
+	}
+	if 0 < global.Len() {
+		t.Errorf("Expected nothing logged globally, got:\n%s", global.String())
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Statuses a station can have by default, matching the constraint on the
+// status column of the Stations table.
+const defaultStatusValues = "open,closed,planned,construction"
+
+// Converts a station status to a SQL literal of its lower case form, which must
+// be one of the allowed statuses whatever its case.
+func (c *conversion) parseStatus(s string, options stationOptions) (string, error) {
+	status := strings.ToLower(s)
+	if !slices.Contains(options.statusValues, status) {
+		return "", fmt.Errorf("Must be one of %s", strings.Join(options.statusValues, ", "))
+	}
+	return c.quoteSqlString(status), nil
+}
+
+// Give the stations with a blank status the default one, or leave it NULL when
+// the default is "", and drop the stations with any of the excluded statuses,
+// writing them to the rejects file if there is one. Returns the stations kept.
+func (c *conversion) applyStatuses(stations []station, blank string, excluded []string, rejects *rejectsFile) []station {
+	for i := range stations {
+		if index := slices.Index(stations[i].fields, field{"status", "NULL"}); "" != blank && 0 <= index {
+			stations[i].fields[index].literal = c.quoteSqlString(blank)
+		}
+	}
+	if 0 == len(excluded) {
+		return stations
+	}
+	literals := make([]field, len(excluded))
+	for i, status := range excluded {
+		literals[i] = field{"status", c.quoteSqlString(status)}
+	}
+	return slices.DeleteFunc(stations, func(s station) bool {
+		index := slices.IndexFunc(literals, func(f field) bool { return slices.Contains(s.fields, f) })
+		if 0 <= index && nil != rejects {
+			rejects.reject(s, fmt.Sprintf("Status %s is excluded by -exclude-status", excluded[index]))
+		}
+		return 0 <= index
+	})
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// How the rail lines and stations are synchronized with a database, from the
+// -sync flags.
+type syncOptions struct {
+	dsn      string // Data source name of the SQLite database
+	foldCase bool   // Whether names are matched ignoring case
+	execute  bool   // Whether the changes are applied to the database instead of written out
+	prune    bool   // Whether rows not in the CSV files are deleted
+}
+
+// Bring the rail lines and stations in the database up to date with those from
+// the CSV files, keeping the IDs already in the database. The changes are
+// written out as statements, or applied to the database with options.execute.
+// Rail lines, stations, and links in the database but not in the CSV files are
+// reported and only deleted with options.prune.
+func (c *conversion) syncDatabase(lines []railLine, stations []station, options syncOptions, stdout io.Writer, stderr io.Writer) error {
+	db, err := sql.Open("sqlite", options.dsn)
+	if nil != err {
+		return fmt.Errorf("Failed to open database %s: %w", options.dsn, err)
+	}
+	defer func() {
+		if err := db.Close(); nil != err {
+			c.logger.Println("Failed to close database", options.dsn, err)
+		}
+	}()
+
+	loaded, err := loadNetwork(db)
+	if nil != err {
+		return fmt.Errorf("Failed to read database %s: %w", options.dsn, err)
+	}
+	key := strings.TrimSpace
+	if options.foldCase {
+		key = func(name string) string { return strings.ToLower(strings.TrimSpace(name)) }
+	}
+	changes := diffNetwork(loaded, lines, stations, key)
+	tables, err := loadTableNames(db)
+	if nil != err {
+		return fmt.Errorf("Failed to read database %s: %w", options.dsn, err)
+	}
+	changes.dependents = slices.DeleteFunc(dependentTables(allTableGroups()), func(dependent dependentRows) bool { return !tables[dependent.table] })
+	changes.subscriptions = tables["UserStations"]
+
+	if !options.prune {
+		lineNames := make(map[int]string, len(loaded.lineIds))
+		for name, lineId := range loaded.lineIds {
+			lineNames[lineId] = name
+		}
+		stationNames := make(map[int]string, len(loaded.stations))
+		for _, current := range loaded.stations {
+			stationNames[current.id] = current.name
+		}
+		for _, lineId := range changes.removedLines {
+			c.warn("sync", "Rail line %s is in the database but not in the CSV files, give -prune to delete it", lineNames[lineId])
+		}
+		for _, stationId := range changes.removedStations {
+			c.warn("sync", "Station %s is in the database but not in the CSV files, give -prune to delete it", stationNames[stationId])
+		}
+		for _, link := range changes.removedLinks {
+			c.warn("sync", "Station %s is linked to rail line %s in the database but not in the CSV files, give -prune to delete the link", stationNames[link[1]], lineNames[link[0]])
+		}
+		changes.removedLines, changes.removedStations, changes.removedLinks = nil, nil, nil
+	}
+	changes.summarize(stderr)
+	if err := c.checkWarnings(); nil != err {
+		return err
+	}
+
+	if !options.execute {
+		return c.emitMigration(changes, stdout)
+	}
+	transaction, err := db.Begin()
+	if nil != err {
+		return fmt.Errorf("Failed to begin transaction: %w", err)
+	}
+	if err := changes.execute(transaction); nil != err {
+		if err := transaction.Rollback(); nil != err {
+			c.logger.Println("Failed to roll back transaction", err)
+		}
+		return fmt.Errorf("Failed to apply changes to database %s: %w", options.dsn, err)
+	}
+	if err := transaction.Commit(); nil != err {
+		return fmt.Errorf("Failed to commit changes to database %s: %w", options.dsn, err)
+	}
+	return nil
+}
+
+// Read the names of the tables in the database.
+func loadTableNames(db *sql.DB) (map[string]bool, error) {
+	rows, err := db.Query("SELECT name FROM sqlite_master WHERE type = 'table'")
+	if nil != err {
+		return nil, fmt.Errorf("Failed to query tables: %w", err)
+	}
+	defer rows.Close()
+	tables := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); nil != err {
+			return nil, fmt.Errorf("Failed to read table name: %w", err)
+		}
+		tables[name] = true
+	}
+	return tables, rows.Err()
+}
+
+// Read the rail lines, stations, and links in the database.
+func loadNetwork(db *sql.DB) (loadedNetwork, error) {
+	loaded := loadedNetwork{lineIds: make(map[string]int)}
+	lineRows, err := db.Query("SELECT id, name FROM RailLines")
+	if nil != err {
+		return loadedNetwork{}, fmt.Errorf("Failed to query rail lines: %w", err)
+	}
+	defer lineRows.Close()
+	for lineRows.Next() {
+		var lineId int
+		var name string
+		if err := lineRows.Scan(&lineId, &name); nil != err {
+			return loadedNetwork{}, fmt.Errorf("Failed to read rail line: %w", err)
+		}
+		loaded.lineIds[name] = lineId
+	}
+	if err := lineRows.Err(); nil != err {
+		return loadedNetwork{}, fmt.Errorf("Failed to read rail lines: %w", err)
+	}
+
+	stationRows, err := db.Query("SELECT id, name FROM Stations ORDER BY id")
+	if nil != err {
+		return loadedNetwork{}, fmt.Errorf("Failed to query stations: %w", err)
+	}
+	defer stationRows.Close()
+	indexes := make(map[int]int) // Index in loaded.stations of each station ID
+	for stationRows.Next() {
+		var current station
+		if err := stationRows.Scan(&current.id, &current.name); nil != err {
+			return loadedNetwork{}, fmt.Errorf("Failed to read station: %w", err)
+		}
+		indexes[current.id] = len(loaded.stations)
+		loaded.stations = append(loaded.stations, current)
+	}
+	if err := stationRows.Err(); nil != err {
+		return loadedNetwork{}, fmt.Errorf("Failed to read stations: %w", err)
+	}
+
+	linkRows, err := db.Query("SELECT line_id, station_id FROM LineStations ORDER BY line_id")
+	if nil != err {
+		return loadedNetwork{}, fmt.Errorf("Failed to query links: %w", err)
+	}
+	defer linkRows.Close()
+	for linkRows.Next() {
+		var lineId, stationId int
+		if err := linkRows.Scan(&lineId, &stationId); nil != err {
+			return loadedNetwork{}, fmt.Errorf("Failed to read link: %w", err)
+		}
+		if index, found := indexes[stationId]; found {
+			loaded.stations[index].lines = append(loaded.stations[index].lines, lineId)
+		}
+	}
+	if err := linkRows.Err(); nil != err {
+		return loadedNetwork{}, fmt.Errorf("Failed to read links: %w", err)
+	}
+	return loaded, nil
+}
+
+// Apply the changes to the database with prepared statements, in the same order
+// as [migration.statements], failing on the subscriptions to removed stations
+// instead of deleting them.
+func (changes migration) execute(transaction *sql.Tx) error {
+	prepared := make(map[string]*sql.Stmt)
+	defer func() {
+		for _, statement := range prepared {
+			statement.Close()
+		}
+	}()
+	run := func(query string, args []any) error {
+		statement, found := prepared[query]
+		if !found {
+			var err error
+			if statement, err = transaction.Prepare(query); nil != err {
+				return fmt.Errorf("Failed to prepare %s: %w", query, err)
+			}
+			prepared[query] = statement
+		}
+		if _, err := statement.Exec(args...); nil != err {
+			return fmt.Errorf("Failed to execute %s with %v: %w", query, args, err)
+		}
+		return nil
+	}
+
+	if query := changes.subscriptionQuery(); "" != query {
+		var userId, stationId int
+		if err := transaction.QueryRow(query).Scan(&userId, &stationId); nil == err {
+			return fmt.Errorf("%s, like user %d to station %d", subscriptionConflict, userId, stationId)
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("Failed to query subscriptions: %w", err)
+		}
+	}
+	for _, deletion := range changes.deletions() {
+		conditions := make([]string, len(deletion.columns))
+		args := make([]any, len(deletion.ids))
+		for i, column := range deletion.columns {
+			conditions[i], args[i] = column+" = ?", deletion.ids[i]
+		}
+		if err := run(fmt.Sprintf("DELETE FROM %s WHERE %s", deletion.table, strings.Join(conditions, " AND ")), args); nil != err {
+			return err
+		}
+	}
+
+	var addedLines, addedStations, addedLinks [][]any
+	for i, line := range changes.addedLines {
+		addedLines = append(addedLines, []any{changes.addedLineIds[i], line.name, line.red, line.green, line.blue})
+	}
+	for _, current := range changes.addedStations {
+		addedStations = append(addedStations, []any{current.id, current.name})
+	}
+	for _, link := range changes.addedLinks {
+		addedLinks = append(addedLinks, []any{link[0], link[1]})
+	}
+
+	steps := []struct {
+		query string
+		rows  [][]any
+	}{
+		{"INSERT INTO RailLines (id, name, red, green, blue) VALUES (?, ?, ?, ?, ?)", addedLines},
+		{"INSERT INTO Stations (id, name) VALUES (?, ?)", addedStations},
+		{"INSERT INTO LineStations (line_id, station_id) VALUES (?, ?)", addedLinks},
+	}
+	for _, step := range steps {
+		for _, args := range step.rows {
+			if err := run(step.query, args); nil != err {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+// Pruning with -execute deletes the rows referencing the removed station and
+// rail line in the same order as the migrate subcommand, and fails on a user
+// subscribed to the station.
+func TestSyncPrune(t *testing.T) {
+	writeFiles(t, migrateFiles)
+	if _, _, err := runArgs(t, "-lines", "old-lines.csv", "-stations", "old.csv", "-devices", "devices.csv", "-sqlite-out", "network.db"); nil != err {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite", "network.db?_pragma=foreign_keys(1)")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("INSERT INTO Connections VALUES (3, 1, 3, 90), (1, 1, 2, 60); INSERT INTO Users VALUES (1, 'rider@example.com'); INSERT INTO UserStations VALUES (1, 2);"); nil != err {
+		t.Fatal(err)
+	}
+	sync := []string{"-lines", "lines.csv", "-stations", "new.csv", "-sync", "-dsn", "network.db?_pragma=foreign_keys(1)", "-prune"}
+
+	script, _, err := runArgs(t, sync...)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if !strings.Contains(script, "UserStations") || !strings.Contains(script, "DELETE FROM Devices WHERE station_id = 2;\n") || !strings.Contains(script, "DELETE FROM Connections WHERE line_id = 3;\n") {
+		t.Errorf("Script is missing the subscription check or the dependent rows:\n%s", script)
+	}
+
+	if _, _, err := runArgs(t, append(sync, "-execute")...); nil == err || !strings.Contains(err.Error(), "Users are subscribed to stations being removed") {
+		t.Fatalf("Expected the subscription to stop the sync, got %v", err)
+	}
+	if _, err := db.Exec("DELETE FROM UserStations"); nil != err {
+		t.Fatal(err)
+	}
+	if _, _, err := runArgs(t, append(sync, "-execute")...); nil != err {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	for table, want := range map[string]int{"Devices": 1, "Connections": 0, "Stations": 3, "RailLines": 2} {
+		var count int
+		if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); nil != err {
+			t.Fatal(err)
+		}
+		if want != count {
+			t.Errorf("Expected %d rows in %s, got %d", want, table, count)
+		}
+	}
+}
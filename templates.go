@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// Statement templates replacing the inserts of the rail lines, stations, and
+// links, each nil to emit the usual insert.
+type statementTemplates struct {
+	line, station, link *template.Template
+}
+
+// Fields of a rail line for its template.
+type lineTemplateData struct {
+	ID               int
+	Name             string // Escaped by [conversion.escapeForStyle], without the quotes
+	Red, Green, Blue uint8
+}
+
+// Fields of a station for its template.
+type stationTemplateData struct {
+	ID   int
+	Name string // Escaped by [conversion.escapeForStyle], without the quotes
+}
+
+// Fields of a link between a rail line and a station for its template.
+type linkTemplateData struct {
+	LineID    int
+	StationID int
+}
+
+// Parse the statement templates. The file, if any, defines the templates named
+// "line", "station", and "link", each of which is overridden by the template
+// given for it directly.
+func parseTemplates(path string, line string, station string, link string) (statementTemplates, error) {
+	var parsed statementTemplates
+	if "" != path {
+		text, err := os.ReadFile(path)
+		if nil != err {
+			return statementTemplates{}, fmt.Errorf("Failed to read %s: %w", path, err)
+		}
+		file, err := template.New(path).Option("missingkey=error").Parse(string(text))
+		if nil != err {
+			return statementTemplates{}, fmt.Errorf("Failed to parse %s: %w", path, err)
+		}
+		parsed = statementTemplates{file.Lookup("line"), file.Lookup("station"), file.Lookup("link")}
+		if nil == parsed.line && nil == parsed.station && nil == parsed.link {
+			return statementTemplates{}, fmt.Errorf("%s defines none of the templates line, station, or link", path)
+		}
+	}
+
+	for _, current := range []struct {
+		name   string
+		text   string
+		parsed **template.Template
+	}{{"line", line, &parsed.line}, {"station", station, &parsed.station}, {"link", link, &parsed.link}} {
+		if "" == current.text {
+			continue
+		}
+		tmpl, err := template.New(current.name).Option("missingkey=error").Parse(current.text)
+		if nil != err {
+			return statementTemplates{}, fmt.Errorf("Failed to parse %s template: %w", current.name, err)
+		}
+		*current.parsed = tmpl
+	}
+	return parsed, nil
+}
+
+// Write the statement generated by the template for the data, ending it with a
+// newline if the template does not.
+func writeTemplate(writer io.Writer, tmpl *template.Template, data any) error {
+	var statement strings.Builder
+	if err := tmpl.Execute(&statement, data); nil != err {
+		return err
+	}
+	if !strings.HasSuffix(statement.String(), "\n") {
+		statement.WriteString("\n")
+	}
+	_, err := io.WriteString(writer, statement.String())
+	return err
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemplateNamesEscapedForDialect(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\n\"O'Brien \\\",true,false\n"})
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, `REPLACE INTO Stations VALUES (1, 'O''Brien \');`},
+		{[]string{"-dialect", "mysql"}, `REPLACE INTO Stations VALUES (1, 'O''Brien \\');`},
+		{[]string{"-dialect", "postgres", "-string-style", "estring"}, `REPLACE INTO Stations VALUES (1, 'O''Brien \\');`},
+	}
+	for _, test := range tests {
+		stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-template-station", "REPLACE INTO Stations VALUES ({{.ID}}, '{{.Name}}');"}, test.args...)...)
+		if nil != err {
+			t.Fatal(err)
+		}
+		if !strings.Contains(stdout, test.want+"\n") {
+			t.Errorf("Expected %s with %v in:\n%s", test.want, test.args, stdout)
+		}
+	}
+
+	_, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-dialect", "postgres", "-string-style", "dollar", "-template-station", "{{.Name}}")
+	if nil == err || !strings.Contains(err.Error(), "dollar quotes") {
+		t.Errorf("Expected templates to be rejected in dollar quotes, got %v", err)
+	}
+}
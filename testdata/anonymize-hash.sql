@@ -0,0 +1,13 @@
+BEGIN;
+INSERT INTO RailLines VALUES (1, 'Line A', 255, 0, 0);
+INSERT INTO RailLines VALUES (2, 'Line B', 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations (id, name, status) VALUES (1, 'Station 001', '9e4608972b92');
+INSERT INTO LineStations VALUES (1, 1);
+INSERT INTO StationAttributes VALUES (1, 'district', '43f853bbb592');
+INSERT INTO Stations (id, name, status) VALUES (2, 'Station 002', 'aa18277d5f40');
+INSERT INTO LineStations VALUES (1, 2);
+INSERT INTO LineStations VALUES (2, 2);
+INSERT INTO StationAttributes VALUES (2, 'district', '43f853bbb592');
+COMMIT;
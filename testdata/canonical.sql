@@ -0,0 +1,15 @@
+BEGIN;
+INSERT INTO RailLines (id, name, red, green, blue, updated) VALUES (1, 'Emerald', 0, 255, 0, '2026-01-01 00:00:00');
+INSERT INTO RailLines (id, name, red, green, blue, updated) VALUES (2, 'Ruby', 255, 0, 0, '2026-01-01 00:00:00');
+COMMIT;
+BEGIN;
+INSERT INTO Stations (id, name, exit_count, updated) VALUES (1, 'Bar''s', NULL, '2026-01-01 00:00:00');
+INSERT INTO LineStations VALUES (1, 1);
+INSERT INTO LineStations VALUES (2, 1);
+INSERT INTO Stations (id, name, exit_count, updated) VALUES (2, 'Baz', 1, '2026-01-01 00:00:00');
+INSERT INTO LineStations VALUES (1, 2);
+INSERT INTO StationAttributes VALUES (2, 'note', 'Closed');
+INSERT INTO Stations (id, name, exit_count, updated) VALUES (3, 'Foo', 2, '2026-01-01 00:00:00');
+INSERT INTO LineStations VALUES (2, 3);
+INSERT INTO StationAttributes VALUES (3, 'note', 'Rebuilt');
+COMMIT;
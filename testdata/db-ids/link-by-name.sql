@@ -0,0 +1,12 @@
+BEGIN;
+INSERT INTO RailLines (name, red, green, blue) SELECT 'Ruby', 255, 0, 0 WHERE NOT EXISTS (SELECT 1 FROM RailLines WHERE name = 'Ruby');
+INSERT INTO RailLines (name, red, green, blue) SELECT 'Emerald', 0, 255, 0 WHERE NOT EXISTS (SELECT 1 FROM RailLines WHERE name = 'Emerald');
+COMMIT;
+BEGIN;
+INSERT INTO Stations (name) SELECT 'Foo' WHERE NOT EXISTS (SELECT 1 FROM Stations WHERE name = 'Foo');
+INSERT INTO LineStations (line_id, station_id) SELECT l.id, s.id FROM RailLines AS l, Stations AS s WHERE l.name = 'Ruby' AND s.name = 'Foo' AND NOT EXISTS (SELECT 1 FROM LineStations WHERE line_id = l.id AND station_id = s.id);
+INSERT INTO StationAttributes (station_id, name, value) SELECT s.id, 'platform', 'island' FROM Stations AS s WHERE s.name = 'Foo' AND NOT EXISTS (SELECT 1 FROM StationAttributes WHERE station_id = s.id AND name = 'platform');
+INSERT INTO Stations (name) SELECT 'Bar''s' WHERE NOT EXISTS (SELECT 1 FROM Stations WHERE name = 'Bar''s');
+INSERT INTO LineStations (line_id, station_id) SELECT l.id, s.id FROM RailLines AS l, Stations AS s WHERE l.name = 'Ruby' AND s.name = 'Bar''s' AND NOT EXISTS (SELECT 1 FROM LineStations WHERE line_id = l.id AND station_id = s.id);
+INSERT INTO LineStations (line_id, station_id) SELECT l.id, s.id FROM RailLines AS l, Stations AS s WHERE l.name = 'Emerald' AND s.name = 'Bar''s' AND NOT EXISTS (SELECT 1 FROM LineStations WHERE line_id = l.id AND station_id = s.id);
+COMMIT;
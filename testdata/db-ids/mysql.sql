@@ -0,0 +1,12 @@
+BEGIN;
+INSERT INTO RailLines (name, red, green, blue) VALUES ('Ruby', 255, 0, 0);
+INSERT INTO RailLines (name, red, green, blue) VALUES ('Emerald', 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations (name) VALUES ('Foo');
+INSERT INTO LineStations (line_id, station_id) SELECT l.id, s.id FROM RailLines AS l, Stations AS s WHERE l.name = 'Ruby' AND s.name = 'Foo' AND NOT EXISTS (SELECT 1 FROM LineStations WHERE line_id = l.id AND station_id = s.id);
+INSERT INTO StationAttributes (station_id, name, value) SELECT s.id, 'platform', 'island' FROM Stations AS s WHERE s.name = 'Foo' AND NOT EXISTS (SELECT 1 FROM StationAttributes WHERE station_id = s.id AND name = 'platform');
+INSERT INTO Stations (name) VALUES ('Bar''s');
+INSERT INTO LineStations (line_id, station_id) SELECT l.id, s.id FROM RailLines AS l, Stations AS s WHERE l.name = 'Ruby' AND s.name = 'Bar''s' AND NOT EXISTS (SELECT 1 FROM LineStations WHERE line_id = l.id AND station_id = s.id);
+INSERT INTO LineStations (line_id, station_id) SELECT l.id, s.id FROM RailLines AS l, Stations AS s WHERE l.name = 'Emerald' AND s.name = 'Bar''s' AND NOT EXISTS (SELECT 1 FROM LineStations WHERE line_id = l.id AND station_id = s.id);
+COMMIT;
@@ -0,0 +1,22 @@
+BEGIN;
+SELECT CASE WHEN EXISTS (SELECT user_id, station_id FROM UserStations WHERE station_id IN (2)) THEN json_extract('{}', 'Users are subscribed to stations being removed') END;
+DELETE FROM LineStations WHERE line_id = 3 AND station_id = 1;
+DELETE FROM LineStations WHERE line_id = 3 AND station_id = 3;
+DELETE FROM LineStations WHERE line_id = 1 AND station_id = 2;
+DELETE FROM LineStations WHERE line_id = 2 AND station_id = 2;
+DELETE FROM Connections WHERE from_station_id = 2;
+DELETE FROM Connections WHERE to_station_id = 2;
+DELETE FROM Entrances WHERE station_id = 2;
+DELETE FROM Devices WHERE station_id = 2;
+DELETE FROM StationZones WHERE station_id = 2;
+DELETE FROM Panels WHERE station_id = 2;
+DELETE FROM StationNames WHERE station_id = 2;
+DELETE FROM StationAliases WHERE station_id = 2;
+DELETE FROM StationAttributes WHERE station_id = 2;
+DELETE FROM Stations WHERE id = 2;
+DELETE FROM Connections WHERE line_id = 3;
+DELETE FROM RailLines WHERE id = 3;
+INSERT INTO Stations VALUES (4, 'Qux');
+INSERT INTO LineStations VALUES (1, 4);
+INSERT INTO LineStations VALUES (2, 4);
+COMMIT;
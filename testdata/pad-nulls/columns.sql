@@ -0,0 +1,11 @@
+BEGIN;
+INSERT INTO RailLines (id, name, red, green, blue) VALUES (1, 'Ruby', 255, 0, 0);
+INSERT INTO RailLines (id, name, red, green, blue) VALUES (2, 'Emerald', 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations (id, name) VALUES (1, 'Foo');
+INSERT INTO LineStations (line_id, station_id) VALUES (1, 1);
+INSERT INTO Stations (id, name) VALUES (2, 'Bar');
+INSERT INTO LineStations (line_id, station_id) VALUES (1, 2);
+INSERT INTO LineStations (line_id, station_id) VALUES (2, 2);
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+INSERT INTO RailLines VALUES (1, 'Ruby', NULL, 255, 0, 0);
+INSERT INTO RailLines VALUES (2, 'Emerald', NULL, 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations VALUES (1, 'Foo', NULL, DEFAULT);
+INSERT INTO LineStations VALUES (1, 1, NULL);
+INSERT INTO Stations VALUES (2, 'Bar', NULL, DEFAULT);
+INSERT INTO LineStations VALUES (1, 2, NULL);
+INSERT INTO LineStations VALUES (2, 2, NULL);
+COMMIT;
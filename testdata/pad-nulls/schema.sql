@@ -0,0 +1,11 @@
+BEGIN;
+INSERT INTO RailLines (id, name, opened, red, green, blue) VALUES (1, 'Ruby', NULL, 255, 0, 0);
+INSERT INTO RailLines (id, name, opened, red, green, blue) VALUES (2, 'Emerald', NULL, 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations (id, name, opened) VALUES (1, 'Foo', NULL);
+INSERT INTO LineStations (line_id, station_id, since) VALUES (1, 1, NULL);
+INSERT INTO Stations (id, name, opened) VALUES (2, 'Bar', NULL);
+INSERT INTO LineStations (line_id, station_id, since) VALUES (1, 2, NULL);
+INSERT INTO LineStations (line_id, station_id, since) VALUES (2, 2, NULL);
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+INSERT INTO RailLines VALUES (1, 'Ruby', 255, 0, 0);
+INSERT INTO RailLines VALUES (2, 'Emerald', 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations (id, name, geom) VALUES (1, 'Foo', ST_SetSRID(ST_MakePoint(-77.0365, 38.8977), 4326));
+INSERT INTO LineStations VALUES (1, 1);
+INSERT INTO Stations (id, name, geom) VALUES (2, 'Bar', NULL);
+INSERT INTO LineStations VALUES (1, 2);
+INSERT INTO LineStations VALUES (2, 2);
+COMMIT;
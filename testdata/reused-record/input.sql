@@ -0,0 +1,19 @@
+BEGIN;
+INSERT INTO RailLines VALUES (1, 'Ruby', 255, 0, 0);
+INSERT INTO RailLines VALUES (2, 'Emerald', 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations (id, name, exit_count) VALUES (1, 'Zebra Crossing Interchange', 12);
+INSERT INTO LineStations VALUES (1, 1);
+INSERT INTO LineStations VALUES (2, 1);
+INSERT INTO StationAliases VALUES (1, 'Zebra');
+INSERT INTO StationAliases VALUES (1, 'ZCI');
+INSERT INTO StationNames VALUES (1, 'fr', 'Passage Zèbre');
+INSERT INTO StationAttributes VALUES (1, 'note', 'Rebuilt in 1999');
+INSERT INTO Stations (id, name, exit_count) VALUES (2, 'Ant', 1);
+INSERT INTO LineStations VALUES (1, 2);
+INSERT INTO Stations (id, name, exit_count) VALUES (3, 'Bee', NULL);
+INSERT INTO LineStations VALUES (2, 3);
+INSERT INTO StationAliases VALUES (3, 'B');
+INSERT INTO StationNames VALUES (3, 'fr', 'Abeille');
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+INSERT INTO RailLines VALUES (1, 'Ruby', 255, 0, 0);
+INSERT INTO RailLines VALUES (2, 'Emerald', 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations (id, name, exit_count) VALUES (1, 'Ant', 1);
+INSERT INTO LineStations VALUES (1, 1);
+INSERT INTO Stations (id, name, exit_count) VALUES (2, 'Bee', NULL);
+INSERT INTO LineStations VALUES (2, 2);
+INSERT INTO StationAliases VALUES (2, 'B');
+INSERT INTO StationNames VALUES (2, 'fr', 'Abeille');
+INSERT INTO Stations (id, name, exit_count) VALUES (3, 'Zebra Crossing Interchange', 12);
+INSERT INTO LineStations VALUES (1, 3);
+INSERT INTO LineStations VALUES (2, 3);
+INSERT INTO StationAliases VALUES (3, 'Zebra');
+INSERT INTO StationAliases VALUES (3, 'ZCI');
+INSERT INTO StationNames VALUES (3, 'fr', 'Passage Zèbre');
+INSERT INTO StationAttributes VALUES (3, 'note', 'Rebuilt in 1999');
+COMMIT;
@@ -0,0 +1,151 @@
+BEGIN;
+CREATE TABLE IF NOT EXISTS Networks (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    name VARCHAR(128) NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS Modes (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    -- Transit mode like rail, bus, or streetcar
+    name VARCHAR(32) NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS Agencies (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    name VARCHAR(128) NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS AgencyAttributes (
+    agency_id INTEGER NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    value TEXT NOT NULL,
+    FOREIGN KEY (agency_id) REFERENCES Agencies(id),
+    PRIMARY KEY (agency_id, name)
+);
+CREATE TABLE IF NOT EXISTS Complexes (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    name VARCHAR(128) NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS AlarmZones (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    name VARCHAR(128) NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS RailLines (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    name VARCHAR(16) NOT NULL UNIQUE,
+    -- Rail lines typically have colors associated with them, these are for the RGB value
+    red SMALLINT NOT NULL,
+    green SMALLINT NOT NULL,
+    blue SMALLINT NOT NULL,
+    -- Network the row is from, with -network or -merge
+    network_id INTEGER,
+    mode_id INTEGER,
+    agency_id INTEGER,
+    FOREIGN KEY (network_id) REFERENCES Networks(id),
+    FOREIGN KEY (mode_id) REFERENCES Modes(id),
+    FOREIGN KEY (agency_id) REFERENCES Agencies(id)
+);
+CREATE TABLE IF NOT EXISTS Stations (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    name VARCHAR(128) NOT NULL UNIQUE,
+    -- Network the row is from, with -network or -merge
+    network_id INTEGER,
+    complex_id INTEGER,
+    zone_id INTEGER,
+    exit_count INTEGER,
+    evac_capacity INTEGER,
+    capacity INTEGER,
+    elevators INTEGER,
+    escalators INTEGER,
+    accessible BOOLEAN,
+    underground BOOLEAN,
+    status VARCHAR(32),
+    latitude DOUBLE PRECISION,
+    longitude DOUBLE PRECISION,
+    FOREIGN KEY (network_id) REFERENCES Networks(id),
+    FOREIGN KEY (complex_id) REFERENCES Complexes(id),
+    FOREIGN KEY (zone_id) REFERENCES AlarmZones(id)
+);
+CREATE TABLE IF NOT EXISTS LineStations (
+    line_id INTEGER NOT NULL,
+    station_id INTEGER NOT NULL,
+    -- Network the row is from, with -network or -merge
+    network_id INTEGER,
+    FOREIGN KEY (line_id) REFERENCES RailLines(id),
+    FOREIGN KEY (station_id) REFERENCES Stations(id),
+    FOREIGN KEY (network_id) REFERENCES Networks(id),
+    PRIMARY KEY (line_id, station_id)
+);
+CREATE TABLE IF NOT EXISTS StationAttributes (
+    station_id INTEGER NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    value TEXT NOT NULL,
+    FOREIGN KEY (station_id) REFERENCES Stations(id),
+    PRIMARY KEY (station_id, name)
+);
+CREATE TABLE IF NOT EXISTS StationAliases (
+    station_id INTEGER NOT NULL,
+    alias VARCHAR(128) NOT NULL,
+    FOREIGN KEY (station_id) REFERENCES Stations(id),
+    PRIMARY KEY (station_id, alias)
+);
+CREATE TABLE IF NOT EXISTS StationNames (
+    station_id INTEGER NOT NULL,
+    -- BCP 47 language tag of the name
+    lang VARCHAR(35) NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    FOREIGN KEY (station_id) REFERENCES Stations(id),
+    PRIMARY KEY (station_id, lang)
+);
+CREATE TABLE IF NOT EXISTS Panels (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    station_id INTEGER NOT NULL,
+    -- Asset tag of the fire alarm panel
+    tag VARCHAR(64) NOT NULL UNIQUE,
+    FOREIGN KEY (station_id) REFERENCES Stations(id)
+);
+CREATE TABLE IF NOT EXISTS StationZones (
+    station_id INTEGER NOT NULL,
+    zone_id INTEGER NOT NULL,
+    FOREIGN KEY (station_id) REFERENCES Stations(id),
+    FOREIGN KEY (zone_id) REFERENCES AlarmZones(id),
+    PRIMARY KEY (station_id, zone_id)
+);
+CREATE TABLE IF NOT EXISTS Devices (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    station_id INTEGER NOT NULL,
+    type VARCHAR(64) NOT NULL,
+    serial VARCHAR(128) NOT NULL UNIQUE,
+    FOREIGN KEY (station_id) REFERENCES Stations(id)
+);
+CREATE TABLE IF NOT EXISTS Entrances (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    station_id INTEGER NOT NULL,
+    name VARCHAR(128) NOT NULL,
+    latitude DOUBLE PRECISION NOT NULL,
+    longitude DOUBLE PRECISION NOT NULL,
+    -- Whether the entrance is only opened in emergencies
+    emergency_only BOOLEAN NOT NULL,
+    FOREIGN KEY (station_id) REFERENCES Stations(id)
+);
+CREATE TABLE IF NOT EXISTS Connections (
+    line_id INTEGER NOT NULL,
+    from_station_id INTEGER NOT NULL,
+    to_station_id INTEGER NOT NULL,
+    -- Travel time between the stations, if known
+    seconds INTEGER,
+    FOREIGN KEY (line_id) REFERENCES RailLines(id),
+    FOREIGN KEY (from_station_id) REFERENCES Stations(id),
+    FOREIGN KEY (to_station_id) REFERENCES Stations(id),
+    PRIMARY KEY (line_id, from_station_id, to_station_id)
+);
+CREATE TABLE IF NOT EXISTS Users (
+    id INTEGER PRIMARY KEY NOT NULL UNIQUE,
+    -- Maximum length of an email address from https://datatracker.ietf.org/doc/html/rfc5321#section-4.5.3.1
+    email VARCHAR(320) NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS UserStations (
+    user_id INTEGER NOT NULL,
+    station_id INTEGER NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES Users(id),
+    FOREIGN KEY (station_id) REFERENCES Stations(id),
+    PRIMARY KEY (user_id, station_id)
+);
+COMMIT;
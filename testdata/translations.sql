@@ -0,0 +1,16 @@
+BEGIN;
+INSERT INTO RailLines VALUES (1, 'Ruby', 255, 0, 0);
+INSERT INTO RailLines VALUES (2, 'Emerald', 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations VALUES (1, 'Foo');
+INSERT INTO LineStations VALUES (1, 1);
+INSERT INTO StationNames VALUES (1, 'es', 'Calle Foo');
+INSERT INTO StationNames VALUES (1, 'zh-Hant', '福街');
+INSERT INTO Stations VALUES (2, 'Bar');
+INSERT INTO LineStations VALUES (1, 2);
+INSERT INTO LineStations VALUES (2, 2);
+INSERT INTO StationNames VALUES (2, 'zh-Hant', '酒吧');
+INSERT INTO Stations VALUES (3, 'Baz');
+INSERT INTO LineStations VALUES (2, 3);
+COMMIT;
@@ -0,0 +1,9 @@
+BEGIN TRANSACTION;
+MERGE INTO RailLines AS target USING (VALUES (1, 'Ruby', 255, 0, 0), (2, 'Emerald', 0, 255, 0)) AS src (id, name, red, green, blue) ON target.id = src.id WHEN MATCHED THEN UPDATE SET name = src.name, red = src.red, green = src.green, blue = src.blue WHEN NOT MATCHED THEN INSERT (id, name, red, green, blue) VALUES (src.id, src.name, src.red, src.green, src.blue);
+COMMIT;
+BEGIN TRANSACTION;
+MERGE INTO Stations AS target USING (VALUES (1, 'O''Brien'), (2, 'Bar "Quoted"')) AS src (id, name) ON target.id = src.id WHEN MATCHED THEN UPDATE SET name = src.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (src.id, src.name);
+MERGE INTO Stations AS target USING (VALUES (3, 'Baz')) AS src (id, name) ON target.id = src.id WHEN MATCHED THEN UPDATE SET name = src.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (src.id, src.name);
+MERGE INTO LineStations AS target USING (VALUES (1, 1), (1, 2)) AS src (line_id, station_id) ON target.line_id = src.line_id AND target.station_id = src.station_id WHEN NOT MATCHED THEN INSERT (line_id, station_id) VALUES (src.line_id, src.station_id);
+MERGE INTO LineStations AS target USING (VALUES (2, 2), (2, 3)) AS src (line_id, station_id) ON target.line_id = src.line_id AND target.station_id = src.station_id WHEN NOT MATCHED THEN INSERT (line_id, station_id) VALUES (src.line_id, src.station_id);
+COMMIT;
@@ -0,0 +1,9 @@
+SET TRANSACTION READ WRITE;
+MERGE INTO RailLines target USING (SELECT 1 AS id, 'Ruby' AS name, 255 AS red, 0 AS green, 0 AS blue FROM dual UNION ALL SELECT 2, 'Emerald', 0, 255, 0 FROM dual) src ON (target.id = src.id) WHEN MATCHED THEN UPDATE SET name = src.name, red = src.red, green = src.green, blue = src.blue WHEN NOT MATCHED THEN INSERT (id, name, red, green, blue) VALUES (src.id, src.name, src.red, src.green, src.blue);
+COMMIT;
+SET TRANSACTION READ WRITE;
+MERGE INTO Stations target USING (SELECT 1 AS id, 'O''Brien' AS name FROM dual UNION ALL SELECT 2, 'Bar "Quoted"' FROM dual) src ON (target.id = src.id) WHEN MATCHED THEN UPDATE SET name = src.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (src.id, src.name);
+MERGE INTO Stations target USING (SELECT 3 AS id, 'Baz' AS name FROM dual) src ON (target.id = src.id) WHEN MATCHED THEN UPDATE SET name = src.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (src.id, src.name);
+MERGE INTO LineStations target USING (SELECT 1 AS line_id, 1 AS station_id FROM dual UNION ALL SELECT 1, 2 FROM dual) src ON (target.line_id = src.line_id AND target.station_id = src.station_id) WHEN NOT MATCHED THEN INSERT (line_id, station_id) VALUES (src.line_id, src.station_id);
+MERGE INTO LineStations target USING (SELECT 2 AS line_id, 2 AS station_id FROM dual UNION ALL SELECT 2, 3 FROM dual) src ON (target.line_id = src.line_id AND target.station_id = src.station_id) WHEN NOT MATCHED THEN INSERT (line_id, station_id) VALUES (src.line_id, src.station_id);
+COMMIT;
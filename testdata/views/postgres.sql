@@ -0,0 +1,14 @@
+BEGIN;
+INSERT INTO RailLines VALUES (1, 'Ruby', 255, 0, 0);
+INSERT INTO RailLines VALUES (2, 'Emerald', 0, 255, 0);
+COMMIT;
+BEGIN;
+INSERT INTO Stations VALUES (1, 'Foo');
+INSERT INTO LineStations VALUES (1, 1);
+INSERT INTO Stations VALUES (2, 'Bar');
+INSERT INTO LineStations VALUES (1, 2);
+INSERT INTO LineStations VALUES (2, 2);
+COMMIT;
+-- Views of the stations of each rail line
+CREATE OR REPLACE VIEW StationLines AS SELECT s.id AS station_id, s.name AS station, l.id AS line_id, l.name AS line FROM LineStations ls JOIN Stations s ON s.id = ls.station_id JOIN RailLines l ON l.id = ls.line_id;
+CREATE OR REPLACE VIEW LineStationCounts AS SELECT l.id AS line_id, l.name AS line, COUNT(ls.station_id) AS stations FROM RailLines l LEFT JOIN LineStations ls ON ls.line_id = l.id GROUP BY l.id, l.name;
@@ -0,0 +1,257 @@
+BEGIN;
+INSERT INTO RailLines VALUES (1, 'Red', 218, 27, 50);
+INSERT INTO RailLines VALUES (2, 'Orange', 246, 146, 31);
+INSERT INTO RailLines VALUES (3, 'Blue', 0, 154, 218);
+INSERT INTO RailLines VALUES (4, 'Green', 0, 177, 87);
+INSERT INTO RailLines VALUES (5, 'Yellow', 255, 223, 0);
+INSERT INTO RailLines VALUES (6, 'Silver', 126, 150, 154);
+COMMIT;
+BEGIN;
+INSERT INTO Stations VALUES (1, 'Addison Road-Seat Pleasant');
+INSERT INTO LineStations VALUES (3, 1);
+INSERT INTO LineStations VALUES (6, 1);
+INSERT INTO Stations VALUES (2, 'Anacostia');
+INSERT INTO LineStations VALUES (4, 2);
+INSERT INTO Stations VALUES (3, 'Archives-Navy Memorial-Penn Quarter');
+INSERT INTO LineStations VALUES (4, 3);
+INSERT INTO LineStations VALUES (5, 3);
+INSERT INTO Stations VALUES (4, 'Arlington Cemetery');
+INSERT INTO LineStations VALUES (3, 4);
+INSERT INTO Stations VALUES (5, 'Ashburn');
+INSERT INTO LineStations VALUES (6, 5);
+INSERT INTO Stations VALUES (6, 'Ballston-MU');
+INSERT INTO LineStations VALUES (2, 6);
+INSERT INTO LineStations VALUES (6, 6);
+INSERT INTO Stations VALUES (7, 'Benning Road');
+INSERT INTO LineStations VALUES (3, 7);
+INSERT INTO LineStations VALUES (6, 7);
+INSERT INTO Stations VALUES (8, 'Bethesda');
+INSERT INTO LineStations VALUES (1, 8);
+INSERT INTO Stations VALUES (9, 'Braddock Road');
+INSERT INTO LineStations VALUES (3, 9);
+INSERT INTO LineStations VALUES (5, 9);
+INSERT INTO Stations VALUES (10, 'Branch Ave');
+INSERT INTO LineStations VALUES (4, 10);
+INSERT INTO Stations VALUES (11, 'Brookland-CUA');
+INSERT INTO LineStations VALUES (1, 11);
+INSERT INTO Stations VALUES (12, 'Capitol Heights');
+INSERT INTO LineStations VALUES (3, 12);
+INSERT INTO LineStations VALUES (6, 12);
+INSERT INTO Stations VALUES (13, 'Capitol South');
+INSERT INTO LineStations VALUES (2, 13);
+INSERT INTO LineStations VALUES (3, 13);
+INSERT INTO LineStations VALUES (6, 13);
+INSERT INTO Stations VALUES (14, 'Cheverly');
+INSERT INTO LineStations VALUES (2, 14);
+INSERT INTO Stations VALUES (15, 'Clarendon');
+INSERT INTO LineStations VALUES (2, 15);
+INSERT INTO LineStations VALUES (6, 15);
+INSERT INTO Stations VALUES (16, 'Cleveland Park');
+INSERT INTO LineStations VALUES (1, 16);
+INSERT INTO Stations VALUES (17, 'College Park-U of Md');
+INSERT INTO LineStations VALUES (4, 17);
+INSERT INTO Stations VALUES (18, 'Columbia Heights');
+INSERT INTO LineStations VALUES (4, 18);
+INSERT INTO Stations VALUES (19, 'Congress Heights');
+INSERT INTO LineStations VALUES (4, 19);
+INSERT INTO Stations VALUES (20, 'Court House');
+INSERT INTO LineStations VALUES (2, 20);
+INSERT INTO LineStations VALUES (6, 20);
+INSERT INTO Stations VALUES (21, 'Crystal City');
+INSERT INTO LineStations VALUES (3, 21);
+INSERT INTO LineStations VALUES (5, 21);
+INSERT INTO Stations VALUES (22, 'Deanwood');
+INSERT INTO LineStations VALUES (2, 22);
+INSERT INTO Stations VALUES (23, 'Downtown Largo');
+INSERT INTO LineStations VALUES (3, 23);
+INSERT INTO LineStations VALUES (6, 23);
+INSERT INTO Stations VALUES (24, 'Dunn Loring-Merrifield');
+INSERT INTO LineStations VALUES (2, 24);
+INSERT INTO Stations VALUES (25, 'Dupont Circle');
+INSERT INTO LineStations VALUES (1, 25);
+INSERT INTO Stations VALUES (26, 'East Falls Church');
+INSERT INTO LineStations VALUES (2, 26);
+INSERT INTO LineStations VALUES (6, 26);
+INSERT INTO Stations VALUES (27, 'Eastern Market');
+INSERT INTO LineStations VALUES (2, 27);
+INSERT INTO LineStations VALUES (3, 27);
+INSERT INTO LineStations VALUES (6, 27);
+INSERT INTO Stations VALUES (28, 'Eisenhower Avenue');
+INSERT INTO LineStations VALUES (5, 28);
+INSERT INTO Stations VALUES (29, 'Farragut North');
+INSERT INTO LineStations VALUES (1, 29);
+INSERT INTO Stations VALUES (30, 'Farragut West');
+INSERT INTO LineStations VALUES (2, 30);
+INSERT INTO LineStations VALUES (3, 30);
+INSERT INTO LineStations VALUES (6, 30);
+INSERT INTO Stations VALUES (31, 'Federal Center SW');
+INSERT INTO LineStations VALUES (2, 31);
+INSERT INTO LineStations VALUES (3, 31);
+INSERT INTO LineStations VALUES (6, 31);
+INSERT INTO Stations VALUES (32, 'Federal Triangle');
+INSERT INTO LineStations VALUES (2, 32);
+INSERT INTO LineStations VALUES (3, 32);
+INSERT INTO LineStations VALUES (6, 32);
+INSERT INTO Stations VALUES (33, 'Foggy Bottom-GWU');
+INSERT INTO LineStations VALUES (2, 33);
+INSERT INTO LineStations VALUES (3, 33);
+INSERT INTO LineStations VALUES (6, 33);
+INSERT INTO Stations VALUES (34, 'Forest Glen');
+INSERT INTO LineStations VALUES (1, 34);
+INSERT INTO Stations VALUES (35, 'Fort Totten');
+INSERT INTO LineStations VALUES (1, 35);
+INSERT INTO LineStations VALUES (4, 35);
+INSERT INTO Stations VALUES (36, 'Franconia-Springfield');
+INSERT INTO LineStations VALUES (3, 36);
+INSERT INTO Stations VALUES (37, 'Friendship Heights');
+INSERT INTO LineStations VALUES (1, 37);
+INSERT INTO Stations VALUES (38, 'Gallery Pl-Chinatown');
+INSERT INTO LineStations VALUES (1, 38);
+INSERT INTO LineStations VALUES (4, 38);
+INSERT INTO LineStations VALUES (5, 38);
+INSERT INTO Stations VALUES (39, 'Georgia Ave-Petworth');
+INSERT INTO LineStations VALUES (4, 39);
+INSERT INTO Stations VALUES (40, 'Glenmont');
+INSERT INTO LineStations VALUES (1, 40);
+INSERT INTO Stations VALUES (41, 'Greenbelt');
+INSERT INTO LineStations VALUES (4, 41);
+INSERT INTO Stations VALUES (42, 'Greensboro');
+INSERT INTO LineStations VALUES (6, 42);
+INSERT INTO Stations VALUES (43, 'Grosvenor-Strathmore');
+INSERT INTO LineStations VALUES (1, 43);
+INSERT INTO Stations VALUES (44, 'Herndon');
+INSERT INTO LineStations VALUES (6, 44);
+INSERT INTO Stations VALUES (45, 'Huntington');
+INSERT INTO LineStations VALUES (5, 45);
+INSERT INTO Stations VALUES (46, 'Hyattsville Crossing');
+INSERT INTO LineStations VALUES (4, 46);
+INSERT INTO Stations VALUES (47, 'Innovation Center');
+INSERT INTO LineStations VALUES (6, 47);
+INSERT INTO Stations VALUES (48, 'Judiciary Square');
+INSERT INTO LineStations VALUES (1, 48);
+INSERT INTO Stations VALUES (49, 'King St-Old Town');
+INSERT INTO LineStations VALUES (3, 49);
+INSERT INTO LineStations VALUES (5, 49);
+INSERT INTO Stations VALUES (50, 'L''Enfant Plaza');
+INSERT INTO LineStations VALUES (2, 50);
+INSERT INTO LineStations VALUES (3, 50);
+INSERT INTO LineStations VALUES (4, 50);
+INSERT INTO LineStations VALUES (5, 50);
+INSERT INTO LineStations VALUES (6, 50);
+INSERT INTO Stations VALUES (51, 'Landover');
+INSERT INTO LineStations VALUES (2, 51);
+INSERT INTO Stations VALUES (52, 'Loudoun Gateway');
+INSERT INTO LineStations VALUES (6, 52);
+INSERT INTO Stations VALUES (53, 'McLean');
+INSERT INTO LineStations VALUES (6, 53);
+INSERT INTO Stations VALUES (54, 'McPherson Square');
+INSERT INTO LineStations VALUES (2, 54);
+INSERT INTO LineStations VALUES (3, 54);
+INSERT INTO LineStations VALUES (6, 54);
+INSERT INTO Stations VALUES (55, 'Medical Center');
+INSERT INTO LineStations VALUES (1, 55);
+INSERT INTO Stations VALUES (56, 'Metro Center');
+INSERT INTO LineStations VALUES (1, 56);
+INSERT INTO LineStations VALUES (2, 56);
+INSERT INTO LineStations VALUES (3, 56);
+INSERT INTO LineStations VALUES (6, 56);
+INSERT INTO Stations VALUES (57, 'Minnesota Ave');
+INSERT INTO LineStations VALUES (2, 57);
+INSERT INTO Stations VALUES (58, 'Morgan Boulevard');
+INSERT INTO LineStations VALUES (3, 58);
+INSERT INTO LineStations VALUES (6, 58);
+INSERT INTO Stations VALUES (59, 'Mt Vernon Sq 7th St-Convention Center');
+INSERT INTO LineStations VALUES (4, 59);
+INSERT INTO LineStations VALUES (5, 59);
+INSERT INTO Stations VALUES (60, 'Navy Yard-Ballpark');
+INSERT INTO LineStations VALUES (4, 60);
+INSERT INTO Stations VALUES (61, 'Naylor Road');
+INSERT INTO LineStations VALUES (4, 61);
+INSERT INTO Stations VALUES (62, 'New Carrollton');
+INSERT INTO LineStations VALUES (2, 62);
+INSERT INTO Stations VALUES (63, 'NoMa-Gallaudet U');
+INSERT INTO LineStations VALUES (1, 63);
+INSERT INTO Stations VALUES (64, 'North Bethesda');
+INSERT INTO LineStations VALUES (1, 64);
+INSERT INTO Stations VALUES (65, 'Pentagon');
+INSERT INTO LineStations VALUES (3, 65);
+INSERT INTO LineStations VALUES (5, 65);
+INSERT INTO Stations VALUES (66, 'Pentagon City');
+INSERT INTO LineStations VALUES (3, 66);
+INSERT INTO LineStations VALUES (5, 66);
+INSERT INTO Stations VALUES (67, 'Potomac Ave');
+INSERT INTO LineStations VALUES (2, 67);
+INSERT INTO LineStations VALUES (3, 67);
+INSERT INTO LineStations VALUES (6, 67);
+INSERT INTO Stations VALUES (68, 'Potomac Yard');
+INSERT INTO LineStations VALUES (3, 68);
+INSERT INTO LineStations VALUES (5, 68);
+INSERT INTO Stations VALUES (69, 'Reston Town Center');
+INSERT INTO LineStations VALUES (6, 69);
+INSERT INTO Stations VALUES (70, 'Rhode Island Ave-Brentwood');
+INSERT INTO LineStations VALUES (1, 70);
+INSERT INTO Stations VALUES (71, 'Rockville');
+INSERT INTO LineStations VALUES (1, 71);
+INSERT INTO Stations VALUES (72, 'Ronald Reagan Washington National Airport');
+INSERT INTO LineStations VALUES (3, 72);
+INSERT INTO LineStations VALUES (5, 72);
+INSERT INTO Stations VALUES (73, 'Rosslyn');
+INSERT INTO LineStations VALUES (2, 73);
+INSERT INTO LineStations VALUES (3, 73);
+INSERT INTO LineStations VALUES (6, 73);
+INSERT INTO Stations VALUES (74, 'Shady Grove');
+INSERT INTO LineStations VALUES (1, 74);
+INSERT INTO Stations VALUES (75, 'Shaw-Howard U');
+INSERT INTO LineStations VALUES (4, 75);
+INSERT INTO Stations VALUES (76, 'Silver Spring');
+INSERT INTO LineStations VALUES (1, 76);
+INSERT INTO Stations VALUES (77, 'Smithsonian');
+INSERT INTO LineStations VALUES (2, 77);
+INSERT INTO LineStations VALUES (3, 77);
+INSERT INTO LineStations VALUES (6, 77);
+INSERT INTO Stations VALUES (78, 'Southern Avenue');
+INSERT INTO LineStations VALUES (4, 78);
+INSERT INTO Stations VALUES (79, 'Spring Hill');
+INSERT INTO LineStations VALUES (6, 79);
+INSERT INTO Stations VALUES (80, 'Stadium-Armory');
+INSERT INTO LineStations VALUES (2, 80);
+INSERT INTO LineStations VALUES (3, 80);
+INSERT INTO LineStations VALUES (6, 80);
+INSERT INTO Stations VALUES (81, 'Suitland');
+INSERT INTO LineStations VALUES (4, 81);
+INSERT INTO Stations VALUES (82, 'Takoma');
+INSERT INTO LineStations VALUES (1, 82);
+INSERT INTO Stations VALUES (83, 'Tenleytown-AU');
+INSERT INTO LineStations VALUES (1, 83);
+INSERT INTO Stations VALUES (84, 'Twinbrook');
+INSERT INTO LineStations VALUES (1, 84);
+INSERT INTO Stations VALUES (85, 'Tysons');
+INSERT INTO LineStations VALUES (6, 85);
+INSERT INTO Stations VALUES (86, 'U Street/African-Amer Civil War Memorial/Cardozo');
+INSERT INTO LineStations VALUES (4, 86);
+INSERT INTO Stations VALUES (87, 'Union Station');
+INSERT INTO LineStations VALUES (1, 87);
+INSERT INTO Stations VALUES (88, 'Van Dorn Street');
+INSERT INTO LineStations VALUES (3, 88);
+INSERT INTO Stations VALUES (89, 'Van Ness-UDC');
+INSERT INTO LineStations VALUES (1, 89);
+INSERT INTO Stations VALUES (90, 'Vienna/Fairfax-GMU');
+INSERT INTO LineStations VALUES (2, 90);
+INSERT INTO Stations VALUES (91, 'Virginia Square-GMU');
+INSERT INTO LineStations VALUES (2, 91);
+INSERT INTO LineStations VALUES (6, 91);
+INSERT INTO Stations VALUES (92, 'Washington Dulles International Airport');
+INSERT INTO LineStations VALUES (6, 92);
+INSERT INTO Stations VALUES (93, 'Waterfront');
+INSERT INTO LineStations VALUES (4, 93);
+INSERT INTO Stations VALUES (94, 'West Falls Church');
+INSERT INTO LineStations VALUES (2, 94);
+INSERT INTO Stations VALUES (95, 'West Hyattsville');
+INSERT INTO LineStations VALUES (4, 95);
+INSERT INTO Stations VALUES (96, 'Wheaton');
+INSERT INTO LineStations VALUES (1, 96);
+INSERT INTO Stations VALUES (97, 'Wiehle-Reston East');
+INSERT INTO LineStations VALUES (6, 97);
+INSERT INTO Stations VALUES (98, 'Woodley Park-Zoo/Adams Morgan');
+INSERT INTO LineStations VALUES (1, 98);
+COMMIT;
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Stations CSV with two translations, one left empty for Bar and both for Baz,
+// and a language tag in another case that is written out canonically.
+const translationStations = "Station,Ruby,name:es,Emerald, NAME:zh-hant \n" +
+	"Foo,true,Calle Foo,false,福街\n" +
+	"Bar,true,,true,酒吧\n" +
+	"Baz,false,,true,\n"
+
+func TestTranslationsGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": translationStations})
+	stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv")
+	if nil != err {
+		t.Fatal(err)
+	}
+	checkGolden(t, "translations.sql", stdout)
+}
+
+func TestTranslationsInvalidTag(t *testing.T) {
+	for _, test := range []struct {
+		stations string
+		err      string
+	}{
+		{"Station,Ruby,name:not a tag\nFoo,true,x\n", "Invalid language tag in column 3"},
+		{"Station,Ruby,Emerald,name:\nFoo,true,false,x\n", "Invalid language tag in column 4"},
+	} {
+		writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": test.stations})
+		if _, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv"); nil == err || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected %q with %q, got %v", test.err, test.stations, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Rows an emitter holds back with -batch-size to write as one MERGE, all for
+// the same table and columns.
+type mergeBatch struct {
+	table   string
+	columns []string
+	rows    [][]string // SQL literals of the columns of each row
+}
+
+// Write the rows as a MERGE on the primary key of the table in the dialect,
+// which updates the other columns of a row that is already there and inserts it
+// otherwise. Tables whose columns are all in the key, like LineStations, have
+// nothing to update, and match on every column of their key. The rows are a
+// VALUES table in every dialect but oracle, which selects each FROM dual instead
+// and takes neither AS for the aliases nor an ON without parentheses.
+func mergeStatement(dialect string, table string, columns []string, rows [][]string) string {
+	keys := primaryKeys[strings.ToLower(table)]
+	var on, set []string
+	sources := make([]string, len(columns))
+	for i, column := range columns {
+		sources[i] = "src." + column
+		if slices.Contains(keys, column) {
+			on = append(on, fmt.Sprintf("target.%s = src.%s", column, column))
+		} else {
+			set = append(set, fmt.Sprintf("%s = src.%s", column, column))
+		}
+	}
+	list := strings.Join(columns, ", ")
+	var statement string
+	if "oracle" == dialect {
+		selects := make([]string, len(rows))
+		for i, values := range rows {
+			if 0 == i {
+				aliased := make([]string, len(values))
+				for j, value := range values {
+					aliased[j] = value + " AS " + columns[j]
+				}
+				values = aliased
+			}
+			selects[i] = "SELECT " + strings.Join(values, ", ") + " FROM dual"
+		}
+		statement = fmt.Sprintf("MERGE INTO %s target USING (%s) src ON (%s)", table, strings.Join(selects, " UNION ALL "), strings.Join(on, " AND "))
+	} else {
+		tuples := make([]string, len(rows))
+		for i, values := range rows {
+			tuples[i] = "(" + strings.Join(values, ", ") + ")"
+		}
+		statement = fmt.Sprintf("MERGE INTO %s AS target USING (VALUES %s) AS src (%s) ON %s", table, strings.Join(tuples, ", "), list, strings.Join(on, " AND "))
+	}
+	if 0 < len(set) {
+		statement += " WHEN MATCHED THEN UPDATE SET " + strings.Join(set, ", ")
+	}
+	return statement + fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);\n", list, strings.Join(sources, ", "))
+}
+
+// Write the row as a MERGE, or with -batch-size add it to the rows held back to
+// be written as one. Those are written first when the row cannot join them:
+// when it is for another table or other columns, when there are already
+// -batch-size of them, or when the MERGE would grow longer than
+// -max-statement-bytes.
+func (e *emitter) writeMerge(writer io.Writer, table string, columns []string, values []string) error {
+	if 1 >= e.batchSize {
+		_, err := io.WriteString(writer, mergeStatement(e.dialect, table, columns, [][]string{values}))
+		return err
+	}
+	if batch := e.merge; 0 < len(batch.rows) {
+		joins := table == batch.table && slices.Equal(columns, batch.columns) && e.batchSize > len(batch.rows)
+		if joins && 0 < e.maxStatementBytes {
+			joins = e.maxStatementBytes >= len(mergeStatement(e.dialect, table, columns, append(slices.Clip(batch.rows), values)))
+		}
+		if !joins {
+			if err := e.writeMergeBatch(writer); nil != err {
+				return err
+			}
+		}
+	}
+	if 0 == len(e.merge.rows) {
+		e.merge.table, e.merge.columns = table, slices.Clone(columns)
+	}
+	e.merge.rows = append(e.merge.rows, slices.Clone(values))
+	return nil
+}
+
+// Write the rows held back by [emitter.writeMerge] as one MERGE, if there are
+// any.
+func (e *emitter) writeMergeBatch(writer io.Writer) error {
+	if 0 == len(e.merge.rows) {
+		return nil
+	}
+	statement := mergeStatement(e.dialect, e.merge.table, e.merge.columns, e.merge.rows)
+	e.merge = mergeBatch{}
+	_, err := io.WriteString(writer, statement)
+	return err
+}
+
+// Writer writing the rows its emitter holds back for a MERGE before any other
+// statement, so that they keep their place ahead of it. Every write is expected
+// to be one complete statement.
+type mergeFlusher struct {
+	writer  io.Writer
+	emitter *emitter
+}
+
+func (w mergeFlusher) Write(statement []byte) (int, error) {
+	if err := w.emitter.writeMergeBatch(w.writer); nil != err {
+		return 0, err
+	}
+	return w.writer.Write(statement)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const upsertStations = "Station,Ruby,Emerald\nO'Brien,true,false\n\"Bar \"\"Quoted\"\"\",true,true\nBaz,false,true\n"
+
+func TestUpsertGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": upsertStations})
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"standard", nil},
+		{"postgres", []string{"-dialect", "postgres"}},
+		{"mssql", []string{"-dialect", "mssql"}},
+		{"oracle", []string{"-dialect", "oracle"}},
+		{"mssql-batch", []string{"-dialect", "mssql", "-batch-size", "2", "-group-by-table"}},
+		{"oracle-batch", []string{"-dialect", "oracle", "-batch-size", "2", "-group-by-table"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stdout, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv", "-upsert", "merge"}, test.args...)...)
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, "upsert/"+test.name+".sql", stdout)
+		})
+	}
+}
+
+// Batches go no further than -max-statement-bytes, leaving a row that only fits
+// on its own in a MERGE of its own.
+func TestUpsertBatchStatementLength(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": upsertStations})
+	args := []string{"-lines", "lines.csv", "-stations", "stations.csv", "-upsert", "merge", "-batch-size", "3", "-group-by-table"}
+	batched, _, err := runArgs(t, args...)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 1 != strings.Count(batched, "MERGE INTO RailLines ") || 1 != strings.Count(batched, "MERGE INTO Stations ") {
+		t.Fatalf("Expected the rail lines and the stations in a batch each:\n%s", batched)
+	}
+	// Long enough for a rail line but not for both
+	limited, _, err := runArgs(t, append(args, "-max-statement-bytes", "350")...)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if 2 != strings.Count(limited, "MERGE INTO RailLines ") || 1 != strings.Count(limited, "MERGE INTO Stations ") {
+		t.Errorf("Expected only the rail lines cut down to batches of one:\n%s", limited)
+	}
+}
+
+func TestUpsertErrors(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": upsertStations})
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"-upsert", "merge", "-dialect", "mysql"}, "MERGE cannot be written in the mysql dialect"},
+		{[]string{"-batch-size", "2"}, "A batch size requires -upsert merge"},
+		{[]string{"-upsert", "merge", "-batch-size", "0"}, "Invalid batch size: 0"},
+		{[]string{"-upsert", "merge", "-batch-size", "2", "-stations-format", "matrix", "-output", "output.sql", "-checkpoint", "output.checkpoint"}, "MERGE cannot be used with -checkpoint"},
+	} {
+		if _, _, err := runArgs(t, append([]string{"-lines", "lines.csv", "-stations", "stations.csv"}, test.args...)...); nil == err || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected %q with %v, got %v", test.err, test.args, err)
+		}
+	}
+}
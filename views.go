@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// Convenience views over the rail lines and stations, by name.
+var views = []struct {
+	name, query string
+}{
+	{"StationLines", "SELECT s.id AS station_id, s.name AS station, l.id AS line_id, l.name AS line FROM LineStations ls " +
+		"JOIN Stations s ON s.id = ls.station_id JOIN RailLines l ON l.id = ls.line_id"},
+	{"LineStationCounts", "SELECT l.id AS line_id, l.name AS line, COUNT(ls.station_id) AS stations FROM RailLines l " +
+		"LEFT JOIN LineStations ls ON ls.line_id = l.id GROUP BY l.id, l.name"},
+}
+
+// Write the statements creating the [views] in the dialect of the conversion,
+// which replace or keep any existing view of the same name where the dialect
+// can.
+func (c *conversion) viewStatements(writer io.Writer) error {
+	create := "CREATE VIEW"
+	switch c.dialect {
+	case "sqlite":
+		create = "CREATE VIEW IF NOT EXISTS"
+	case "postgres", "mysql":
+		create = "CREATE OR REPLACE VIEW"
+	}
+	statements := []string{"-- Views of the stations of each rail line"}
+	for _, view := range views {
+		statements = append(statements, fmt.Sprintf("%s %s AS %s;", create, view.name, view.query))
+	}
+	return writeStatements(writer, statements)
+}
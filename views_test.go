@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestViewsGolden(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n"})
+	for _, dialect := range []string{"standard", "postgres", "mysql", "sqlite"} {
+		t.Run(dialect, func(t *testing.T) {
+			stdout, _, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-with-views", "-dialect", dialect)
+			if nil != err {
+				t.Fatal(err)
+			}
+			checkGolden(t, "views/"+dialect+".sql", stdout)
+		})
+	}
+}
+
+// The views of the self-test count the rows of its database.
+func TestViewsSelfTest(t *testing.T) {
+	writeFiles(t, map[string]string{"lines.csv": testLines, "stations.csv": "Station,Ruby,Emerald\nFoo,true,false\nBar,true,true\n"})
+	if _, stderr, err := runArgs(t, "-lines", "lines.csv", "-stations", "stations.csv", "-with-views", "-self-test"); nil != err {
+		t.Fatalf("Self-test of the views failed: %v\n%s", err, stderr)
+	}
+}
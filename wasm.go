@@ -0,0 +1,18 @@
+//go:build js && wasm
+
+package main
+
+import "syscall/js"
+
+// Export convert(csvText, optionsJSON) to JavaScript, returning what
+// [convertCall] does, and keep running for its calls.
+func main() {
+	js.Global().Set("convert", js.FuncOf(func(this js.Value, args []js.Value) any {
+		texts := make([]string, len(args))
+		for i, arg := range args {
+			texts[i] = arg.String()
+		}
+		return convertCall(texts)
+	}))
+	select {}
+}
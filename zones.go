@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// List the distinct alarm zones of the stations in the order they are first
+// seen. The ID of each zone is its position in the list plus one.
+func collectZones(stations []station) []string {
+	var zones []string
+	for _, current := range stations {
+		if "" != current.zone && !slices.Contains(zones, current.zone) {
+			zones = append(zones, current.zone)
+		}
+	}
+	return zones
+}
+
+// Column of the 'Stations' table with the AlarmZones row of the alarm zone of
+// the station, unless it is linked through StationZones with -zone-links.
+var zoneIdColumn = columnDefinition{"zone_id", "INTEGER", ""}
+
+// Fill the zone_id column of every station with the ID of its alarm zone.
+func assignZoneFields(stations []station, zones []string) {
+	if 0 == len(zones) {
+		return
+	}
+	for i := range stations {
+		literal := "NULL"
+		if zoneId := slices.Index(zones, stations[i].zone); 0 <= zoneId {
+			literal = strconv.Itoa(zoneId + 1)
+		}
+		stations[i].fields = append(stations[i].fields, field{zoneIdColumn.name, literal})
+	}
+}
+
+// Generate the SQL statements for populating the 'AlarmZones' table.
+func (e *emitter) zoneStatements(zones []string, writer io.Writer) error {
+	for i, zone := range zones {
+		if err := e.writeInsert(writer, "AlarmZones", []string{"id", "name"}, []string{strconv.Itoa(i + 1), e.quoteSqlString(zone)}, nil, nil); nil != err {
+			return fmt.Errorf("Failed to write zone insert statement: %w", err)
+		}
+	}
+	return nil
+}
+
+// Generate the SQL statements for populating the 'StationZones' table.
+func (e *emitter) zoneLinkStatements(stations []station, zones []string, writer io.Writer) error {
+	for _, current := range stations {
+		if zoneId := slices.Index(zones, current.zone); 0 <= zoneId {
+			if err := e.writeInsert(writer, "StationZones", []string{"station_id", "zone_id"}, []string{strconv.Itoa(current.id), strconv.Itoa(zoneId + 1)}, nil, nil); nil != err {
+				return fmt.Errorf("Failed to write zone link statement: %w", err)
+			}
+		}
+	}
+	return nil
+}